@@ -5,11 +5,37 @@ set -e
 # URL pública de Cloudflare R2 para archivos estáticos
 export R2_PUBLIC_URL="https://your-bucket-url.example.com/"
 
+# URLs de las imágenes en las respuestas: modo (public, signed o none), base pública o de la CDN
+# (vacía usa R2_PUBLIC_URL, o STORAGE_PUBLIC_URL con los backends local y memory) y validez de las URLs firmadas
+export IMAGE_URL_MODE="public"
+export IMAGE_PUBLIC_BASE_URL=""
+export IMAGE_SIGNED_URL_TTL="1h"
+
 # Credenciales para acceso a Cloudflare R2 (almacenamiento de objetos)
 export R2_ACCESS_KEY="your_access_key_here" 
 export R2_SECRET_KEY="your_secret_key_here"
 export R2_ACCOUNT_ID="your_account_id_here"
 
+# Almacenamiento de archivos: backend (s3, local o memory) y configuración S3; sin endpoint se usa el de R2
+# de R2_ACCOUNT_ID y sin credenciales las de R2. STORAGE_S3_PATH_STYLE=true para MinIO. STORAGE_S3_KEY_PREFIX
+# guarda los archivos bajo un prefijo del bucket (por ejemplo products/), el único que recorre la reconciliación
+export STORAGE_BACKEND="s3"
+export STORAGE_S3_ENDPOINT=""
+export STORAGE_S3_BUCKET="ecommerce"
+export STORAGE_S3_REGION="auto"
+export STORAGE_S3_PATH_STYLE="false"
+export STORAGE_S3_KEY_PREFIX=""
+export STORAGE_S3_ACCESS_KEY=""
+export STORAGE_S3_SECRET_KEY=""
+# Cliente S3: conexiones inactivas del pool, intentos por operación y espera máxima entre reintentos
+export STORAGE_MAX_IDLE_CONNS="100"
+export STORAGE_RETRY_MAX_ATTEMPTS="3"
+export STORAGE_RETRY_MAX_BACKOFF="5s"
+# Backends local y memory: directorio, URL de la ruta estática que los sirve y secreto de las subidas firmadas
+export STORAGE_LOCAL_DIR="data/storage"
+export STORAGE_PUBLIC_URL="http://localhost:8080/storage"
+export STORAGE_SIGNING_SECRET=""
+
 # Secret para firmar y verificar tokens JWT de autenticación
 export JWT_SECRET="your_jwt_secret_here"
 
@@ -22,5 +48,91 @@ export PORT="${PORT:-8080}"
 # Modo de ejecución de Gin (debug/release)
 export GIN_MODE=debug
 
+# Métricas de Prometheus (endpoint, ruta e intervalo de recálculo de métricas del catálogo)
+export METRICS_ENABLED=true
+export METRICS_PATH="/metrics"
+export METRICS_CATALOG_REFRESH_INTERVAL="5m"
+
+# Trazas OpenTelemetry: exportador (otlp, stdout o none), nombre del servicio, endpoint OTLP y ratio de muestreo
+export OTEL_TRACES_EXPORTER="none"
+export OTEL_SERVICE_NAME="ecommerce-product-service"
+export OTEL_EXPORTER_OTLP_ENDPOINT="http://localhost:4318"
+export OTEL_TRACES_SAMPLE_RATIO="1"
+
+# Plazo máximo por petición y plazos por ruta ("METHOD /plantilla=duración" separados por comas)
+export REQUEST_TIMEOUT="30s"
+export REQUEST_TIMEOUT_ROUTES="GET /api/v1/products/search=10s,GET /api/v1/products/pages=10s"
+
+# Logs estructurados: nivel (debug, info, warn, error), formato (json o text; por defecto json en modo release)
+# y tasa de muestreo de las peticiones exitosas en el log de acceso (0 a 1)
+export LOG_LEVEL="info"
+export LOG_FORMAT=""
+export LOG_SUCCESS_SAMPLE_RATE="1"
+
+# Permisos: IDs del claim permissionIds que conceden cada permiso ("permiso=id|id" separados por comas)
+# y permiso requerido por ruta ("METHOD /plantilla=permiso" separados por comas); vacío usa los valores por defecto
+export PERMISSION_IDS=""
+export ROUTE_PERMISSIONS=""
+# Roles del claim roles que pueden gestionar productos de cualquier autor (separados por comas)
+export ADMIN_ROLES="ADMIN"
+
+# IP del cliente: proxies de confianza para X-Forwarded-For (IPs o CIDR separados por comas; vacío no confía
+# en ninguno) y plataforma que indica la IP en su cabecera (cloudflare, google-app-engine, flyio o una cabecera)
+export TRUSTED_PROXIES=""
+export TRUSTED_PLATFORM=""
+
+# Catálogo público (/api/v1/public): peticiones por segundo y ráfaga permitidas por IP, IPs que se siguen
+# a la vez (las nuevas comparten un límite común al superarlo) y max-age de Cache-Control
+export PUBLIC_RATE_LIMIT_RPS="10"
+export PUBLIC_RATE_LIMIT_BURST="20"
+export PUBLIC_RATE_LIMIT_MAX_CLIENTS="10000"
+export PUBLIC_CACHE_MAX_AGE="60s"
+
+# Intervalo del programador de publicaciones (publishAt/unpublishAt); 0 lo desactiva
+export PRODUCT_SCHEDULER_INTERVAL="1m"
+
+# Papelera: intervalo del job de purga (0 lo desactiva) y tiempo que se conservan los productos borrados
+export PRODUCT_PURGE_INTERVAL="1h"
+export PRODUCT_DELETED_RETENTION="720h"
+
+# Idempotency-Key: store de respuestas (firestore o memory), tiempo que se conservan
+# y tiempo máximo que una petición en curso mantiene reservada su clave
+export IDEMPOTENCY_STORE="firestore"
+export IDEMPOTENCY_TTL="24h"
+export IDEMPOTENCY_LOCK_TIMEOUT="1m"
+
+# Número máximo de imágenes de la galería de cada producto
+export PRODUCT_MAX_IMAGES="10"
+
+# Tamaño máximo en bytes de cada imagen añadida a la galería
+export PRODUCT_IMAGE_MAX_SIZE="10485760"
+
+# Variantes en WebP de cada imagen (nombre=tamaño máximo del lado mayor) y píxeles máximos del original para generarlas
+export PRODUCT_IMAGE_RENDITIONS="thumbnail=160,medium=640,large=1280"
+export PRODUCT_IMAGE_MAX_PIXELS="40000000"
+
+# Subidas directas al bucket: validez de la URL firmada, tiempo para confirmar la subida e
+# intervalo del job que elimina las no confirmadas (0 lo desactiva)
+export PRODUCT_IMAGE_UPLOAD_URL_TTL="15m"
+export PRODUCT_IMAGE_UPLOAD_TTL="1h"
+export PRODUCT_IMAGE_UPLOAD_SWEEP_INTERVAL="15m"
+
+# Antigüedad mínima de un archivo huérfano para que la reconciliación del almacenamiento lo elimine
+export STORAGE_RECONCILE_GRACE_PERIOD="24h"
+
+# Importación de productos: tamaño máximo del archivo en bytes, filas que se procesan durante la petición,
+# duración máxima de un trabajo, trabajos en segundo plano a la vez por instancia, cada cuánto se marcan como
+# fallidos los trabajos abandonados y hosts permitidos en las URLs de imágenes (separados por comas; vacío no permite
+# URLs y * permite cualquier host con dirección pública)
+export IMPORT_MAX_FILE_SIZE="10485760"
+export IMPORT_SYNC_MAX_ROWS="200"
+export IMPORT_JOB_TIMEOUT="30m"
+export IMPORT_MAX_CONCURRENT_JOBS="2"
+export IMPORT_JOB_SWEEP_INTERVAL="15m"
+export IMPORT_IMAGE_ALLOWED_HOSTS=""
+
+# Exportación de productos: documentos que se leen de Firestore en cada bloque
+export EXPORT_CHUNK_SIZE="500"
+
 # Ejecutar la aplicación
 go run main.go
\ No newline at end of file
@@ -1,24 +1,74 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/command"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/job"
+	"github.com/ruiborda/ecommerce-product-service/src/logging"
+	appMiddleware "github.com/ruiborda/ecommerce-product-service/src/middleware"
 	"github.com/ruiborda/ecommerce-product-service/src/route"
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
 	"github.com/ruiborda/go-swagger-generator/src/middleware"
 	"github.com/ruiborda/go-swagger-generator/src/openapi"
 	"github.com/ruiborda/go-swagger-generator/src/swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 func main() {
-	router := gin.Default()
+	// Contexto que se cancela al recibir SIGINT/SIGTERM para detener jobs y servidor
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logging.Init()
+
+	shutdownTracing, err := tracing.Init(ctx)
+	if err != nil {
+		slog.Error("Error initializing tracing", "error", err)
+		os.Exit(1)
+	}
+	flushTracing := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}
+	defer flushTracing()
+
+	// Con argumentos se ejecuta un comando de mantenimiento (por ejemplo backfill-renditions) en lugar del servidor
+	if len(os.Args) > 1 {
+		exitCode := command.Run(ctx, os.Args[1:])
+		flushTracing()
+		os.Exit(exitCode)
+	}
+
+	router := gin.New()
+	if err := appMiddleware.ConfigureTrustedProxies(router); err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+	router.Use(gin.Recovery())
+	router.Use(otelgin.Middleware(tracing.ServiceName()))
+	router.Use(appMiddleware.RequestId())
+	router.Use(appMiddleware.AccessLog(config.GetEnvFloat("LOG_SUCCESS_SAMPLE_RATE", 1)))
+	router.Use(appMiddleware.Metrics())
+	router.Use(appMiddleware.Timeout(appMiddleware.LoadTimeoutConfig()))
+	router.Use(appMiddleware.ErrorHandler())
+	router.NoRoute(appMiddleware.NoRoute())
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"*"},
 		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Retry-After", appMiddleware.IdempotentReplayedHeader, appMiddleware.RequestIdHeader},
 		AllowCredentials: true,
 		AllowOriginFunc: func(origin string) bool {
 			return origin == "*"
@@ -38,12 +88,44 @@ func main() {
 			In("header")
 	})
 
+	route.MetricsRouter(router)
 	route.ApiRouter(router)
+	route.PublicRouter(router)
+	route.StorageRouter(router)
+
+	job.NewCatalogMetricsJob(config.GetEnvDuration("METRICS_CATALOG_REFRESH_INTERVAL", 5*time.Minute)).Start(ctx)
+	job.NewProductScheduleJob(config.GetEnvDuration("PRODUCT_SCHEDULER_INTERVAL", time.Minute)).Start(ctx)
+	job.NewProductPurgeJob(
+		config.GetEnvDuration("PRODUCT_PURGE_INTERVAL", time.Hour),
+		config.GetEnvDuration("PRODUCT_DELETED_RETENTION", 30*24*time.Hour),
+	).Start(ctx)
+	job.NewImageUploadSweepJob(config.GetEnvDuration("PRODUCT_IMAGE_UPLOAD_SWEEP_INTERVAL", 15*time.Minute)).Start(ctx)
+	job.NewImportJobSweepJob(config.GetEnvDuration("IMPORT_JOB_SWEEP_INTERVAL", 15*time.Minute)).Start(ctx)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	slog.Info("Starting server http://localhost:" + port)
-	router.Run(":" + port)
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	go func() {
+		slog.Info("Starting server http://localhost:" + port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Error starting server", "error", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Error shutting down server", "error", err)
+	}
+	// Las importaciones en segundo plano que no terminen a tiempo se interrumpen y guardan su estado
+	serviceImpl.ShutdownImportJobs(shutdownCtx)
 }
@@ -0,0 +1,28 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+)
+
+// backfillRenditions genera las variantes de las imágenes subidas antes de que existieran o
+// después de cambiar PRODUCT_IMAGE_RENDITIONS, y escribe el informe en JSON en la salida estándar
+func backfillRenditions(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	force := flags.Bool("force", false, "regenerate the renditions of every image, even if they already exist")
+	dryRun := flags.Bool("dry-run", false, "only count the images whose renditions would be generated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := serviceImpl.NewProductImageServiceImpl().BackfillRenditions(ctx, *force, *dryRun)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+)
+
+// backfillStatus guarda el estado de los productos creados antes del ciclo de vida, para que la
+// consulta del catálogo público los encuentre, y escribe el informe en JSON en la salida estándar
+func backfillStatus(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := serviceImpl.NewProductServiceImpl().BackfillStatus(ctx)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+// command es una tarea de mantenimiento que se ejecuta desde la línea de comandos en lugar del servidor
+type command struct {
+	description string
+	run         func(ctx context.Context, flags *flag.FlagSet, args []string) error
+}
+
+// commands son los comandos disponibles por nombre
+var commands = map[string]command{
+	"backfill-renditions": {
+		description: "Generate the missing renditions of every product image",
+		run:         backfillRenditions,
+	},
+	"backfill-status": {
+		description: "Save the status of the products created before the product lifecycle",
+		run:         backfillStatus,
+	},
+	"reconcile-storage": {
+		description: "Report the storage files no product references and the references to missing files",
+		run:         reconcileStorage,
+	},
+	"sync-image-references": {
+		description: "Register the references of every product to its image files",
+		run:         syncImageReferences,
+	},
+}
+
+// Run ejecuta el comando de args[0] con el resto de argumentos y devuelve el código de salida
+func Run(ctx context.Context, args []string) int {
+	selected, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	if err := selected.run(ctx, flags, args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		slog.ErrorContext(ctx, "Command failed", "command", args[0], "error", err)
+		return 1
+	}
+	return 0
+}
+
+// printUsage lista los comandos disponibles
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-22s %s\n", name, commands[name].description)
+	}
+}
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+)
+
+// reconcileStorage cruza los archivos del almacenamiento con las imágenes de los productos y escribe
+// el informe de huérfanos y referencias rotas en JSON en la salida estándar
+func reconcileStorage(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	deleteOrphans := flags.Bool("delete", false, "delete the orphan files older than the grace period")
+	gracePeriod := flags.String("grace", "", "minimum age of an orphan to delete it (default STORAGE_RECONCILE_GRACE_PERIOD)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	request := &product.StorageReconcileRequest{DeleteOrphans: *deleteOrphans, GracePeriod: *gracePeriod}
+	report, err := serviceImpl.NewProductImageServiceImpl().ReconcileStorage(ctx, request)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	return err
+}
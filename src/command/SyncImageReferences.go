@@ -0,0 +1,26 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+)
+
+// syncImageReferences registra las referencias de los productos a las imágenes subidas antes del
+// recuento de referencias, y escribe el informe en JSON en la salida estándar
+func syncImageReferences(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := serviceImpl.NewProductImageServiceImpl().SyncImageReferences(ctx)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnv obtiene una variable de entorno o devuelve el valor por defecto
+func GetEnv(key string, defaultValue string) string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// GetEnvInt obtiene una variable de entorno entera o devuelve el valor por defecto
+func GetEnvInt(key string, defaultValue int) int {
+	value := GetEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Invalid integer environment variable, using default", "key", key, "value", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+// GetEnvFloat obtiene una variable de entorno decimal o devuelve el valor por defecto
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	value := GetEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		slog.Warn("Invalid float environment variable, using default", "key", key, "value", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+// GetEnvBool obtiene una variable de entorno booleana o devuelve el valor por defecto
+func GetEnvBool(key string, defaultValue bool) bool {
+	value := GetEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("Invalid boolean environment variable, using default", "key", key, "value", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+// GetEnvDuration obtiene una variable de entorno de duración (ej. "30s", "5m") o devuelve el valor por defecto
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := GetEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("Invalid duration environment variable, using default", "key", key, "value", value)
+		return defaultValue
+	}
+	return parsed
+}
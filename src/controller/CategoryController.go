@@ -29,27 +29,29 @@ var _ = swagger.Swagger().Path("/api/v1/categories").
 			OperationID("CreateCategory").
 			Tag("CategoryController").
 			Consume(mime.ApplicationJSON).
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			BodyParameter(func(param openapi.Parameter) {
 				param.Description("Category object that needs to be added to the system").
 					Required(true).
 					SchemaFromDTO(&category.CreateCategoryRequest{})
 			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
 	}).Doc()
 
 func (cc *CategoryController) CreateCategory(c *gin.Context) {
 	var createCategoryRequest = &category.CreateCategoryRequest{}
 
-	if err := c.BindJSON(createCategoryRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(createCategoryRequest); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
 
 	// Llamar al servicio para crear la categoría
-	response, err := cc.categoryService.CreateCategory(createCategoryRequest)
+	response, err := cc.categoryService.CreateCategory(c.Request.Context(), createCategoryRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -62,31 +64,29 @@ var _ = swagger.Swagger().Path("/api/v1/categories").
 			OperationID("UpdateCategory").
 			Tag("CategoryController").
 			Consume(mime.ApplicationJSON).
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			BodyParameter(func(param openapi.Parameter) {
 				param.Description("Category object with updated values").
 					Required(true).
 					SchemaFromDTO(&category.UpdateCategoryRequest{})
 			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
 	}).Doc()
 
 func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 	var updateCategoryRequest = &category.UpdateCategoryRequest{}
 
-	if err := c.BindJSON(updateCategoryRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(updateCategoryRequest); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
 
 	// Llamar al servicio para actualizar la categoría
-	response, err := cc.categoryService.UpdateCategory(updateCategoryRequest)
+	response, err := cc.categoryService.UpdateCategory(c.Request.Context(), updateCategoryRequest)
 	if err != nil {
-		if err.Error() == "category not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -98,17 +98,22 @@ var _ = swagger.Swagger().Path("/api/v1/categories").
 		operation.Summary("Get all categories").
 			OperationID("GetAllCategories").
 			Tag("CategoryController").
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			Response(http.StatusOK, func(response openapi.Response) {
 				response.Description("List of all categories").
 					SchemaFromDTO(&[]*category.GetCategoriesResponse{})
 			}).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadGateway)
 	}).Doc()
 
 func (cc *CategoryController) GetCategories(c *gin.Context) {
 	// Usar el método que devuelve un puntero a un array
-	response := cc.categoryService.GetAllCategoriesAsArray()
+	response, err := cc.categoryService.GetAllCategoriesAsArray(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
 	// Desreferenciar el puntero para obtener el array
 	c.JSON(http.StatusOK, *response)
 }
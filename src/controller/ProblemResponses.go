@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"net/http"
+
+	dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
+	"github.com/ruiborda/go-swagger-generator/src/openapi"
+	"github.com/ruiborda/go-swagger-generator/src/openapi_spec/mime"
+)
+
+// ApplicationProblemJSON es el tipo MIME de las respuestas de error
+const ApplicationProblemJSON = mime.MimeType(dto.ProblemDetailsContentType)
+
+// problemResponses documenta las respuestas de error application/problem+json de una operación.
+// Los errores 500 y 504 se añaden siempre porque cualquier operación puede producirlos.
+func problemResponses(operation openapi.Operation, statusCodes ...int) {
+	statusCodes = append(statusCodes, http.StatusInternalServerError, http.StatusGatewayTimeout)
+	for _, statusCode := range statusCodes {
+		operation.Response(statusCode, func(response openapi.Response) {
+			response.Description(http.StatusText(statusCode) + " (application/problem+json)").
+				SchemaFromDTO(&dto.ProblemDetails{})
+		})
+	}
+}
+
+// idempotencyKeyHeader es la cabecera que hace seguros los reintentos de las operaciones que modifican datos
+const idempotencyKeyHeader = middleware.IdempotencyKeyHeader
+
+// idempotencyKeyParameter documenta la cabecera Idempotency-Key
+func idempotencyKeyParameter(param openapi.Parameter) {
+	param.Description("Client-generated key that makes retries safe: the first successful response is replayed; 422 if reused with a different request, 409 while the first one is in progress").
+		Type("string")
+}
+
+// invalidBody crea el error para un cuerpo de solicitud que no se puede interpretar
+func invalidBody(err error) error {
+	return exception.Validation(exception.CodeInvalidRequest, "The request body is not valid: "+err.Error())
+}
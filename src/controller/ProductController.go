@@ -2,28 +2,31 @@
 package controller
 
 import (
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
 	dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
 	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
 	"github.com/ruiborda/ecommerce-product-service/src/service"
 	"github.com/ruiborda/ecommerce-product-service/src/service/impl"
-	"github.com/ruiborda/go-jwt/src/domain/entity"
 	"github.com/ruiborda/go-swagger-generator/src/openapi"
 	"github.com/ruiborda/go-swagger-generator/src/openapi_spec/mime"
 	"github.com/ruiborda/go-swagger-generator/src/swagger"
 )
 
 type ProductController struct {
-	productService service.ProductService
+	productService     service.ProductService
+	productBulkService service.ProductBulkService
 }
 
 func NewProductController() *ProductController {
 	return &ProductController{
-		productService: impl.NewProductServiceImpl(),
+		productService:     impl.NewProductServiceImpl(),
+		productBulkService: impl.NewProductBulkServiceImpl(),
 	}
 }
 
@@ -33,55 +36,87 @@ var _ = swagger.Swagger().Path("/api/v1/products").
 			OperationID("CreateProduct").
 			Tag("ProductController").
 			Consume(mime.ApplicationJSON).
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			BodyParameter(func(param openapi.Parameter) {
 				param.Description("Product object that needs to be added to the system").
 					Required(true).
 					SchemaFromDTO(&product.CreateProductRequest{})
 			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
 	}).Doc()
 
 func (pc *ProductController) CreateProduct(c *gin.Context) {
 	var createProductRequest = &product.CreateProductRequest{}
 
-	if err := c.BindJSON(createProductRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(createProductRequest); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
 
-	// Extraer el ID del autor del token JWT
-	claimsValue, exists := c.Get("jwtClaims")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No JWT claims found"})
+	// Obtener el usuario autenticado, que será el autor del producto
+	principal := middleware.GetPrincipal(c)
+	if principal == nil {
+		_ = c.Error(exception.Unauthorized(exception.CodeUnauthenticated, "User ID not found in token"))
 		return
 	}
 
-	// Obtener el ID del usuario (autor) desde el JWT
-	var authorId string
-
-	claims, ok := claimsValue.(*entity.JWTClaims[*auth.JwtPrivateClaims])
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid JWT claims format"})
+	response, err := pc.productService.CreateProduct(c.Request.Context(), createProductRequest, principal)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	// Obtener el subject (ID de usuario) desde los claims registrados
-	authorId = claims.RegisteredClaims.Subject
+	setETag(c, response.ETag)
+	c.JSON(http.StatusCreated, response)
+}
 
-	if authorId == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+var _ = swagger.Swagger().Path("/api/v1/products/bulk").
+	Post(func(operation openapi.Operation) {
+		operation.Summary("Run create/update/delete/stock-adjust operations on many products").
+			Description("Operations run in Firestore batches of up to 500 writes. In atomic mode (default, max 500 operations) either all operations are applied or none; in best-effort mode (max 1000) valid operations are applied even if others fail. Each result carries the status code the single-item endpoint would have returned and, on failure, a problem+json error.").
+			OperationID("BulkProducts").
+			Tag("ProductController").
+			Consume(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("Execution mode (atomic or best-effort) and list of operations").
+					Required(true).
+					SchemaFromDTO(&product.BulkProductRequest{})
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Per-operation results, in request order").
+					SchemaFromDTO(&product.BulkProductResponse{})
+			}).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (pc *ProductController) BulkProducts(c *gin.Context) {
+	var bulkRequest = &product.BulkProductRequest{}
+
+	if err := c.ShouldBindJSON(bulkRequest); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
 
-	// Llamar al servicio pasando tanto el DTO como el authorId extraído del JWT
-	response, err := pc.productService.CreateProduct(createProductRequest, authorId)
+	response, err := pc.productBulkService.ExecuteBulk(c.Request.Context(), bulkRequest, middleware.GetPrincipal(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, response)
+	// Los errores de cada operación se devuelven con el mismo formato que los de la API
+	for i := range response.Results {
+		if result := &response.Results[i]; result.Err != nil {
+			result.Error = middleware.ProblemFor(result.Err)
+			result.Status = result.Error.Status
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 var _ = swagger.Swagger().Path("/api/v1/products/{id}").
@@ -89,29 +124,34 @@ var _ = swagger.Swagger().Path("/api/v1/products/{id}").
 		operation.Summary("Get product by ID").
 			OperationID("GetProductById").
 			Tag("ProductController").
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			PathParameter("id", func(param openapi.Parameter) {
 				param.Description("ID of the product to get").
 					Required(true).
 					Type("string")
 			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Successful operation").
+					SchemaFromDTO(&product.GetProductByIdResponse{}).
+					Header("ETag", func(header openapi.Header) {
+						header.Description("Current version of the product, to send in If-Match").
+							Type("string")
+					})
+			}).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusBadGateway)
 	}).Doc()
 
 func (pc *ProductController) GetProductById(c *gin.Context) {
 	id := c.Param("id")
 
-	response, err := pc.productService.GetProductById(id)
+	response, err := pc.productService.GetProductById(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if response == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		_ = c.Error(err)
 		return
 	}
 
+	setETag(c, response.ETag)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -121,7 +161,7 @@ var _ = swagger.Swagger().Path("/api/v1/products/{id}").
 			OperationID("UpdateProduct").
 			Tag("ProductController").
 			Consume(mime.ApplicationJSON).
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			PathParameter("id", func(param openapi.Parameter) {
 				param.Description("ID of the product to update").
 					Required(true).
@@ -132,29 +172,92 @@ var _ = swagger.Swagger().Path("/api/v1/products/{id}").
 					Required(true).
 					SchemaFromDTO(&product.UpdateProductRequest{})
 			}).
+			HeaderParameter("If-Match", func(param openapi.Parameter) {
+				param.Description("ETag of the version being modified; 412 if the product has changed. Without it, a concurrent change is retried on the current version (409 if it keeps failing)").
+					Type("string")
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
 	}).Doc()
 
 func (pc *ProductController) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
 	var updateProductRequest = &product.UpdateProductRequest{}
 
-	if err := c.BindJSON(updateProductRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(updateProductRequest); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, err := pc.productService.UpdateProduct(c.Request.Context(), id, updateProductRequest, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}").
+	Patch(func(operation openapi.Operation) {
+		operation.Summary("Partially update a product (JSON Merge Patch or JSON Patch)").
+			OperationID("PatchProduct").
+			Tag("ProductController").
+			Consumes(mime.MimeType(product.MergePatch), mime.MimeType(product.JSONPatch)).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", func(param openapi.Parameter) {
+				param.Description("ID of the product to patch").
+					Required(true).
+					Type("string")
+			}).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("Merge patch with the fields to change (null removes optional fields), or a JSON Patch operation list").
+					Required(true).
+					SchemaFromDTO(&product.PatchProductDocument{})
+			}).
+			HeaderParameter("If-Match", func(param openapi.Parameter) {
+				param.Description("ETag of the version being modified; 412 if the product has changed. Without it, a concurrent change is retried on the current version (409 if it keeps failing)").
+					Type("string")
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Successful operation").
+					SchemaFromDTO(&product.UpdateProductResponse{})
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (pc *ProductController) PatchProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	var patchType product.PatchType
+	switch contentType := c.ContentType(); contentType {
+	case string(product.JSONPatch):
+		patchType = product.JSONPatch
+	case string(product.MergePatch), "application/json":
+		patchType = product.MergePatch
+	default:
+		_ = c.Error(exception.UnsupportedMediaType(exception.CodeUnsupportedMediaType,
+			"Content-Type must be "+string(product.MergePatch)+" or "+string(product.JSONPatch)))
 		return
 	}
 
-	response, err := pc.productService.UpdateProduct(id, updateProductRequest)
+	patch, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(invalidBody(err))
 		return
 	}
 
-	if response == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	response, err := pc.productService.PatchProduct(c.Request.Context(), id, patch, patchType, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
+	setETag(c, response.ETag)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -163,33 +266,70 @@ var _ = swagger.Swagger().Path("/api/v1/products/{id}").
 		operation.Summary("Delete a product").
 			OperationID("DeleteProduct").
 			Tag("ProductController").
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			PathParameter("id", func(param openapi.Parameter) {
 				param.Description("ID of the product to delete").
 					Required(true).
 					Type("string")
 			}).
+			HeaderParameter("If-Match", func(param openapi.Parameter) {
+				param.Description("ETag of the version being modified; 412 if the product has changed. Without it, a concurrent change is retried on the current version (409 if it keeps failing)").
+					Type("string")
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
 	}).Doc()
 
 func (pc *ProductController) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	response, err := pc.productService.DeleteProduct(id)
+	response, err := pc.productService.DeleteProduct(c.Request.Context(), id, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/restore").
+	Post(func(operation openapi.Operation) {
+		operation.Summary("Restore a deleted product").
+			OperationID("RestoreProduct").
+			Tag("ProductController").
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", func(param openapi.Parameter) {
+				param.Description("ID of the deleted product to restore").
+					Required(true).
+					Type("string")
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Restored product").
+					SchemaFromDTO(&product.GetProductByIdResponse{})
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (pc *ProductController) RestoreProduct(c *gin.Context) {
+	response, err := pc.productService.RestoreProduct(c.Request.Context(), c.Param("id"), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusOK, response)
+}
+
 var _ = swagger.Swagger().Path("/api/v1/products/pages").
 	Get(func(operation openapi.Operation) {
 		operation.Summary("Get paginated list of products").
 			OperationID("GetProductsPaginated").
 			Tag("ProductController").
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			QueryParameter("page", func(param openapi.Parameter) {
 				param.Description("Page number").
 					Type("integer").
@@ -200,7 +340,12 @@ var _ = swagger.Swagger().Path("/api/v1/products/pages").
 					Type("integer").
 					Format("int32")
 			}).
+			QueryParameter("status", func(param openapi.Parameter) {
+				param.Description("Filter by status (draft, published or archived)").
+					Type("string")
+			}).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadGateway)
 	}).Doc()
 
 func (pc *ProductController) GetProductsPaginated(c *gin.Context) {
@@ -209,9 +354,52 @@ func (pc *ProductController) GetProductsPaginated(c *gin.Context) {
 	query := c.DefaultQuery("query", "")
 
 	pageable := dto.NewPageable(pageStr, sizeStr, query)
-	response, err := pc.productService.GetProductsPaginated(pageable)
+	response, err := pc.productService.GetProductsPaginated(c.Request.Context(), pageable, c.Query("status"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/mine").
+	Get(func(operation openapi.Operation) {
+		operation.Summary("Get paginated list of the caller's products").
+			OperationID("GetMyProductsPaginated").
+			Tag("ProductController").
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			QueryParameter("page", func(param openapi.Parameter) {
+				param.Description("Page number").
+					Type("integer").
+					Format("int32")
+			}).
+			QueryParameter("size", func(param openapi.Parameter) {
+				param.Description("Page size").
+					Type("integer").
+					Format("int32")
+			}).
+			QueryParameter("status", func(param openapi.Parameter) {
+				param.Description("Filter by status (draft, published or archived)").
+					Type("string")
+			}).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadGateway)
+	}).Doc()
+
+func (pc *ProductController) GetMyProductsPaginated(c *gin.Context) {
+	pageable := dto.NewPageable(c.DefaultQuery("page", "1"), c.DefaultQuery("size", "10"), "")
+
+	// Obtener el usuario autenticado, cuyos productos se listan
+	principal := middleware.GetPrincipal(c)
+	if principal == nil {
+		_ = c.Error(exception.Unauthorized(exception.CodeUnauthenticated, "User ID not found in token"))
+		return
+	}
+
+	response, err := pc.productService.GetMyProductsPaginated(c.Request.Context(), pageable, c.Query("status"), principal)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -224,7 +412,7 @@ var _ = swagger.Swagger().Path("/api/v1/products/{id}/stock").
 			OperationID("AdjustProductStock").
 			Tag("ProductController").
 			Consume(mime.ApplicationJSON).
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			PathParameter("id", func(param openapi.Parameter) {
 				param.Description("ID of the product to adjust stock").
 					Required(true).
@@ -235,26 +423,72 @@ var _ = swagger.Swagger().Path("/api/v1/products/{id}/stock").
 					Required(true).
 					SchemaFromDTO(&product.AdjustProductStockRequest{})
 			}).
+			HeaderParameter("If-Match", func(param openapi.Parameter) {
+				param.Description("ETag of the version being modified; 412 if the product has changed. Without it, a concurrent change is retried on the current version (409 if it keeps failing)").
+					Type("string")
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
 	}).Doc()
 
 func (pc *ProductController) AdjustProductStock(c *gin.Context) {
 	id := c.Param("id")
 	var adjustStockRequest = &product.AdjustProductStockRequest{}
 
-	if err := c.BindJSON(adjustStockRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(adjustStockRequest); err != nil {
+		_ = c.Error(invalidBody(err))
 		return
 	}
 
-	response, err := pc.productService.AdjustProductStock(id, adjustStockRequest)
+	response, err := pc.productService.AdjustProductStock(c.Request.Context(), id, adjustStockRequest, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	if response == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	setETag(c, response.ETag)
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/status").
+	Put(func(operation openapi.Operation) {
+		operation.Summary("Change product lifecycle status").
+			OperationID("ChangeProductStatus").
+			Tag("ProductController").
+			Consume(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", func(param openapi.Parameter) {
+				param.Description("ID of the product").
+					Required(true).
+					Type("string")
+			}).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("Target status: draft → published → archived, archived → published").
+					Required(true).
+					SchemaFromDTO(&product.ChangeProductStatusRequest{})
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Status changed").
+					SchemaFromDTO(&product.ChangeProductStatusResponse{})
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (pc *ProductController) ChangeProductStatus(c *gin.Context) {
+	id := c.Param("id")
+	var changeStatusRequest = &product.ChangeProductStatusRequest{}
+
+	if err := c.ShouldBindJSON(changeStatusRequest); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, err := pc.productService.ChangeProductStatus(c.Request.Context(), id, changeStatusRequest, middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -266,7 +500,7 @@ var _ = swagger.Swagger().Path("/api/v1/products/search").
 		operation.Summary("Search products with advanced filters").
 			OperationID("SearchProducts").
 			Tag("ProductController").
-			Produces(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
 			QueryParameter("query", func(param openapi.Parameter) {
 				param.Description("Search query").
 					Type("string")
@@ -308,9 +542,24 @@ var _ = swagger.Swagger().Path("/api/v1/products/search").
 					SchemaFromDTO(&product.SearchProductsResponse{})
 			}).
 			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadGateway)
 	}).Doc()
 
 func (pc *ProductController) SearchProducts(c *gin.Context) {
+	searchRequest := searchRequestFromQuery(c)
+
+	// Llamar al servicio de búsqueda
+	response, err := pc.productService.SearchProducts(c.Request.Context(), searchRequest)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// searchRequestFromQuery construye la solicitud de búsqueda a partir de los parámetros de la URL
+func searchRequestFromQuery(c *gin.Context) *product.SearchProductsRequest {
 	query := c.Query("query")
 	pageStr := c.DefaultQuery("page", "1")
 	sizeStr := c.DefaultQuery("size", "10")
@@ -365,12 +614,15 @@ func (pc *ProductController) SearchProducts(c *gin.Context) {
 	searchRequest.SortBy = sortBy
 	searchRequest.SortDirection = sortDirection
 
-	// Llamar al servicio de búsqueda
-	response, err := pc.productService.SearchProducts(searchRequest)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	return searchRequest
+}
 
-	c.JSON(http.StatusOK, response)
+// ifMatchHeader es la cabecera con la que el cliente indica la versión del producto que modifica
+const ifMatchHeader = "If-Match"
+
+// setETag devuelve la versión del producto en la cabecera ETag
+func setETag(c *gin.Context, etag string) {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
 }
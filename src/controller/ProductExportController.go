@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	"github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/spreadsheet"
+	"github.com/ruiborda/go-swagger-generator/src/openapi"
+	"github.com/ruiborda/go-swagger-generator/src/openapi_spec/mime"
+	"github.com/ruiborda/go-swagger-generator/src/swagger"
+)
+
+// ProductExportController exporta el catálogo a CSV, JSON Lines y XLSX
+type ProductExportController struct {
+	productExportService service.ProductExportService
+}
+
+func NewProductExportController() *ProductExportController {
+	return &ProductExportController{
+		productExportService: impl.NewProductExportServiceImpl(),
+	}
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/export").
+	Get(func(operation openapi.Operation) {
+		operation.Summary("Export the catalog as CSV, JSON Lines or XLSX").
+			Description("Streams every product that matches the filters, ordered by ID, with the category name and the final price. The columns match the import fields, so the file can be imported again.").
+			OperationID("ExportProducts").
+			Tag("ProductExportController").
+			Produces(
+				mime.MimeType(spreadsheet.ContentType(spreadsheet.FormatCSV)),
+				mime.MimeType(spreadsheet.ContentType(spreadsheet.FormatJSONL)),
+				mime.MimeType(spreadsheet.ContentType(spreadsheet.FormatXLSX)),
+				ApplicationProblemJSON,
+			).
+			QueryParameter("format", func(param openapi.Parameter) {
+				param.Description("File format: csv (default), jsonl or xlsx").
+					Type("string")
+			}).
+			QueryParameter("status", func(param openapi.Parameter) {
+				param.Description("Filter by status (draft, published or archived)").
+					Type("string")
+			}).
+			QueryParameter("query", func(param openapi.Parameter) {
+				param.Description("Search query in the name or description").
+					Type("string")
+			}).
+			QueryParameter("categoryId", func(param openapi.Parameter) {
+				param.Description("Filter by category ID").
+					Type("string")
+			}).
+			QueryParameter("priceMin", func(param openapi.Parameter) {
+				param.Description("Minimum price").
+					Type("number").
+					Format("float")
+			}).
+			QueryParameter("priceMax", func(param openapi.Parameter) {
+				param.Description("Maximum price").
+					Type("number").
+					Format("float")
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Exported file").
+					Header("Content-Disposition", func(header openapi.Header) {
+						header.Description("Attachment with the file name").
+							Type("string")
+					})
+			}).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadGateway)
+	}).Doc()
+
+func (ec *ProductExportController) ExportProducts(c *gin.Context) {
+	format, err := spreadsheet.ParseFormat(c.DefaultQuery("format", string(spreadsheet.FormatCSV)))
+	if err != nil {
+		_ = c.Error(exception.Validation(exception.CodeInvalidRequest, "The export format is not valid").WithField("format", "must be csv, jsonl or xlsx"))
+		return
+	}
+
+	writer := &exportResponseWriter{
+		c:        c,
+		format:   format,
+		fileName: "products-" + time.Now().UTC().Format("20060102T150405Z") + "." + string(format),
+	}
+	// Si el error ocurre después de enviar datos la respuesta ya no se puede cambiar y el
+	// ErrorHandler lo ignora; el servicio lo registra
+	if err := ec.productExportService.ExportProducts(c.Request.Context(), format, searchRequestFromQuery(c), c.Query("status"), writer); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// exportResponseWriter escribe las cabeceras de descarga con los primeros datos, de modo que
+// un error anterior se pueda responder como problem+json
+type exportResponseWriter struct {
+	c        *gin.Context
+	format   spreadsheet.Format
+	fileName string
+}
+
+func (w *exportResponseWriter) Write(data []byte) (int, error) {
+	if !w.c.Writer.Written() {
+		w.c.Header("Content-Type", spreadsheet.ContentType(w.format))
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.fileName+`"`)
+		w.c.Header("Cache-Control", "no-store")
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(data)
+}
+
+// Flush envía al cliente lo escrito; sin Content-Length la respuesta se envía por bloques
+func (w *exportResponseWriter) Flush() {
+	w.c.Writer.Flush()
+}
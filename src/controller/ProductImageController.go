@@ -0,0 +1,451 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	"github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/go-swagger-generator/src/openapi"
+	"github.com/ruiborda/go-swagger-generator/src/openapi_spec/mime"
+	"github.com/ruiborda/go-swagger-generator/src/swagger"
+)
+
+// maxImageFieldSize limita los campos de texto que acompañan al archivo en las subidas multipart
+const maxImageFieldSize = 64 << 10
+
+// ProductImageController gestiona la galería de imágenes de los productos
+type ProductImageController struct {
+	productImageService service.ProductImageService
+	maxImageSize        int64
+}
+
+func NewProductImageController() *ProductImageController {
+	return &ProductImageController{
+		productImageService: impl.NewProductImageServiceImpl(),
+		maxImageSize:        int64(config.GetEnvInt("PRODUCT_IMAGE_MAX_SIZE", 10<<20)),
+	}
+}
+
+// productIdParameter documenta el parámetro de ruta con el ID del producto de la galería
+func productIdParameter(param openapi.Parameter) {
+	param.Description("ID of the product").
+		Required(true).
+		Type("string")
+}
+
+// imageIdParameter documenta el parámetro de ruta con el ID de la imagen
+func imageIdParameter(param openapi.Parameter) {
+	param.Description("ID of the image in the product gallery").
+		Required(true).
+		Type("string")
+}
+
+// ifMatchParameter documenta la cabecera If-Match de las operaciones que modifican la galería
+func ifMatchParameter(param openapi.Parameter) {
+	param.Description("ETag of the product version being modified; 412 if the product has changed. Without it, a concurrent change is retried on the current version (409 if it keeps failing)").
+		Type("string")
+}
+
+// productImagesResponse documenta la respuesta con la galería y el ETag del producto
+func productImagesResponse(response openapi.Response) {
+	response.Description("Product gallery").
+		SchemaFromDTO(&product.ProductImagesResponse{}).
+		Header("ETag", func(header openapi.Header) {
+			header.Description("Current version of the product, to send in If-Match").
+				Type("string")
+		})
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/images").
+	Get(func(operation openapi.Operation) {
+		operation.Summary("Get the image gallery of a product").
+			OperationID("GetProductImages").
+			Tag("ProductImageController").
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", productIdParameter).
+			Response(http.StatusOK, productImagesResponse).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) GetProductImages(c *gin.Context) {
+	response, err := ic.productImageService.GetProductImages(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/images").
+	Post(func(operation openapi.Operation) {
+		operation.Summary("Add an image to the product gallery").
+			Description("Send the image as multipart/form-data; it is streamed to the storage as it is received. The alt, primary and position fields must precede the file field. A JSON body with the image encoded in base64 (imageBase64, alt, primary, position) is also accepted. Only JPEG, PNG, WebP and AVIF images are accepted, detected from the content.").
+			OperationID("AddProductImage").
+			Tag("ProductImageController").
+			Consumes(multipartFormData, mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", productIdParameter).
+			FormParameter("alt", func(param openapi.Parameter) {
+				param.Description(`JSON object with the alternative text per locale, e.g. {"es": "Zapatilla roja", "en": "Red sneaker"}`).
+					Type("string")
+			}).
+			FormParameter("primary", func(param openapi.Parameter) {
+				param.Description("Make the image the primary image of the product").
+					Type("boolean")
+			}).
+			FormParameter("position", func(param openapi.Parameter) {
+				param.Description("Position of the image in the gallery, starting at 0; at the end by default").
+					Type("integer")
+			}).
+			FormParameter("file", func(param openapi.Parameter) {
+				param.Description("JPEG, PNG, WebP or AVIF image").
+					Required(true).
+					Type("file")
+			}).
+			HeaderParameter("If-Match", ifMatchParameter).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusCreated, productImagesResponse).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) AddProductImage(c *gin.Context) {
+	if c.ContentType() == string(multipartFormData) {
+		ic.uploadProductImage(c)
+		return
+	}
+
+	var addImageRequest = &product.AddProductImageRequest{}
+	if err := c.ShouldBindJSON(addImageRequest); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, err := ic.productImageService.AddProductImage(c.Request.Context(), c.Param("id"), addImageRequest, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusCreated, response)
+}
+
+// uploadProductImage lee el cuerpo multipart parte a parte y pasa el archivo al servicio sin
+// guardarlo en memoria ni en disco
+func (ic *ProductImageController) uploadProductImage(c *gin.Context) {
+	// El cuerpo multipart incluye cabeceras y campos además del archivo: se deja un margen de 1 MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ic.maxImageSize+1<<20)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	uploadRequest := &product.UploadProductImageRequest{}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = c.Error(ic.multipartError(err))
+			return
+		}
+
+		if part.FormName() == "file" {
+			uploadRequest.File = part
+			response, err := ic.productImageService.UploadProductImage(c.Request.Context(), c.Param("id"), uploadRequest, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+			if err != nil {
+				_ = c.Error(err)
+				return
+			}
+
+			setETag(c, response.ETag)
+			c.JSON(http.StatusCreated, response)
+			return
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxImageFieldSize))
+		if err != nil {
+			_ = c.Error(ic.multipartError(err))
+			return
+		}
+		if err := parseImageField(uploadRequest, part.FormName(), string(value)); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	_ = c.Error(exception.Validation(exception.CodeInvalidRequest, "The request must be multipart/form-data with a file field").WithField("file", "is required"))
+}
+
+// multipartError distingue un cuerpo que supera el tamaño máximo de uno mal formado
+func (ic *ProductImageController) multipartError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return exception.PayloadTooLarge(exception.CodeFileTooLarge, "The image must not exceed "+strconv.FormatInt(ic.maxImageSize>>20, 10)+" MB")
+	}
+	return invalidBody(err)
+}
+
+// parseImageField asigna un campo de texto del formulario multipart; los campos desconocidos se ignoran
+func parseImageField(uploadRequest *product.UploadProductImageRequest, name string, value string) error {
+	switch name {
+	case "alt":
+		if err := json.Unmarshal([]byte(value), &uploadRequest.Alt); err != nil {
+			return exception.Validation(exception.CodeInvalidRequest, "The request is not valid").WithField("alt", "must be a JSON object of locale to text")
+		}
+	case "primary":
+		primary, err := strconv.ParseBool(value)
+		if err != nil {
+			return exception.Validation(exception.CodeInvalidRequest, "The request is not valid").WithField("primary", "must be true or false")
+		}
+		uploadRequest.Primary = primary
+	case "position":
+		position, err := strconv.Atoi(value)
+		if err != nil {
+			return exception.Validation(exception.CodeInvalidRequest, "The request is not valid").WithField("position", "must be an integer")
+		}
+		uploadRequest.Position = &position
+	}
+	return nil
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/images/uploads").
+	Post(func(operation openapi.Operation) {
+		operation.Summary("Get a presigned URL to upload an image directly to the storage").
+			Description("The URL accepts a single PUT of a file with exactly the declared content type and size, sent with the returned headers. The upload is added to the gallery once confirmed; unconfirmed uploads are deleted when they expire.").
+			OperationID("CreateImageUpload").
+			Tag("ProductImageController").
+			Consume(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", productIdParameter).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("Content type (image/jpeg, image/png, image/webp or image/avif) and exact size in bytes of the file").
+					Required(true).
+					SchemaFromDTO(&product.CreateImageUploadRequest{})
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusCreated, func(response openapi.Response) {
+				response.Description("Presigned upload").
+					SchemaFromDTO(&product.ImageUploadResponse{})
+			}).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) CreateImageUpload(c *gin.Context) {
+	var createUploadRequest = &product.CreateImageUploadRequest{}
+	if err := c.ShouldBindJSON(createUploadRequest); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, err := ic.productImageService.CreateImageUpload(c.Request.Context(), c.Param("id"), createUploadRequest, middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/images/uploads/{uploadId}/confirm").
+	Post(func(operation openapi.Operation) {
+		operation.Summary("Add an image uploaded with a presigned URL to the product gallery").
+			Description("Checks the content type and size of the uploaded object, copies it without metadata to the key derived from its content, so that identical images are stored once, and deletes the uploaded object. Responds 409 if the file has not been uploaded yet, 415 or 413 if it does not match the upload; in those cases the object is deleted.").
+			OperationID("ConfirmImageUpload").
+			Tag("ProductImageController").
+			Consume(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", productIdParameter).
+			PathParameter("uploadId", func(param openapi.Parameter) {
+				param.Description("ID of the upload returned with the presigned URL").
+					Required(true).
+					Type("string")
+			}).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("Alternative text per locale, position and primary flag of the image").
+					SchemaFromDTO(&product.ConfirmImageUploadRequest{})
+			}).
+			HeaderParameter("If-Match", ifMatchParameter).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusCreated, productImagesResponse).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) ConfirmImageUpload(c *gin.Context) {
+	var confirmRequest = &product.ConfirmImageUploadRequest{}
+	// El cuerpo es opcional: sin él la imagen se añade al final sin texto alternativo
+	if err := c.ShouldBindJSON(confirmRequest); err != nil && !errors.Is(err, io.EOF) {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, err := ic.productImageService.ConfirmImageUpload(c.Request.Context(), c.Param("id"), c.Param("uploadId"), confirmRequest, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusCreated, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/images").
+	Put(func(operation openapi.Operation) {
+		operation.Summary("Reorder the product gallery").
+			OperationID("ReorderProductImages").
+			Tag("ProductImageController").
+			Consume(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", productIdParameter).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("IDs of every image of the product in the new order").
+					Required(true).
+					SchemaFromDTO(&product.ReorderProductImagesRequest{})
+			}).
+			HeaderParameter("If-Match", ifMatchParameter).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusOK, productImagesResponse).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) ReorderProductImages(c *gin.Context) {
+	var reorderRequest = &product.ReorderProductImagesRequest{}
+	if err := c.ShouldBindJSON(reorderRequest); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, err := ic.productImageService.ReorderProductImages(c.Request.Context(), c.Param("id"), reorderRequest, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/images/{imageId}").
+	Patch(func(operation openapi.Operation) {
+		operation.Summary("Change the alternative text of an image or make it the primary image").
+			OperationID("UpdateProductImage").
+			Tag("ProductImageController").
+			Consume(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", productIdParameter).
+			PathParameter("imageId", imageIdParameter).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("Alternative text per locale (replaces the current one) and primary flag").
+					Required(true).
+					SchemaFromDTO(&product.UpdateProductImageRequest{})
+			}).
+			HeaderParameter("If-Match", ifMatchParameter).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusOK, productImagesResponse).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) UpdateProductImage(c *gin.Context) {
+	var updateImageRequest = &product.UpdateProductImageRequest{}
+	if err := c.ShouldBindJSON(updateImageRequest); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, err := ic.productImageService.UpdateProductImage(c.Request.Context(), c.Param("id"), c.Param("imageId"), updateImageRequest, c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/{id}/images/{imageId}").
+	Delete(func(operation openapi.Operation) {
+		operation.Summary("Remove an image from the product gallery").
+			OperationID("DeleteProductImage").
+			Tag("ProductImageController").
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", productIdParameter).
+			PathParameter("imageId", imageIdParameter).
+			HeaderParameter("If-Match", ifMatchParameter).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusOK, productImagesResponse).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) DeleteProductImage(c *gin.Context) {
+	response, err := ic.productImageService.DeleteProductImage(c.Request.Context(), c.Param("id"), c.Param("imageId"), c.GetHeader(ifMatchHeader), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/admin/storage/reconcile").
+	Post(func(operation openapi.Operation) {
+		operation.Summary("Cross-reference the storage objects with the product images").
+			Description("Lists every object of the storage and reports the orphans, files no product references, and the broken references, product image fields pointing to missing files. Products in the trash are included. With deleteOrphans the orphans older than the grace period are deleted with their renditions. Only for administrators.").
+			OperationID("ReconcileStorage").
+			Tag("ProductImageController").
+			Consume(mime.ApplicationJSON).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			BodyParameter(func(param openapi.Parameter) {
+				param.Description("Whether to delete the orphans and the minimum age to delete them").
+					SchemaFromDTO(&product.StorageReconcileRequest{})
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Reconciliation report").
+					SchemaFromDTO(&product.StorageReconcileReport{})
+			}).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImageController) ReconcileStorage(c *gin.Context) {
+	if principal := middleware.GetPrincipal(c); principal == nil || !principal.Admin {
+		_ = c.Error(exception.Forbidden(exception.CodeForbidden, "Reconciling the storage is only allowed to administrators"))
+		return
+	}
+
+	var reconcileRequest = &product.StorageReconcileRequest{}
+	// El cuerpo es opcional: sin él solo se genera el informe
+	if err := c.ShouldBindJSON(reconcileRequest); err != nil && !errors.Is(err, io.EOF) {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	report, err := ic.productImageService.ReconcileStorage(c.Request.Context(), reconcileRequest)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
+	"github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+)
+
+// streamingImageService lee el archivo de la subida como el servicio real, sin guardarlo, y avisa
+// en firstByte cuando llegan los primeros bytes
+type streamingImageService struct {
+	service.ProductImageService
+	firstByte chan struct{}
+}
+
+func (s *streamingImageService) UploadProductImage(_ context.Context, productId string, request *product.UploadProductImageRequest, _ string, _ *auth.Principal) (*product.ProductImagesResponse, error) {
+	first := make([]byte, 1)
+	_, err := io.ReadFull(request.File, first)
+	if err == nil {
+		if s.firstByte != nil {
+			close(s.firstByte)
+			s.firstByte = nil
+		}
+		_, err = io.Copy(io.Discard, request.File)
+	}
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return nil, exception.PayloadTooLarge(exception.CodeFileTooLarge, "The image is too large")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product.ProductImagesResponse{ProductId: productId}, nil
+}
+
+const testMaxImageSize = 2 << 20
+
+func newUploadRouter(imageService service.ProductImageService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	imageController := &ProductImageController{productImageService: imageService, maxImageSize: testMaxImageSize}
+	router := gin.New()
+	router.POST("/api/v1/products/:id/images",
+		middleware.ErrorHandler(),
+		middleware.Idempotency(impl.NewInMemoryIdempotencyRepository(), middleware.IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute}),
+		imageController.AddProductImage,
+	)
+	return router
+}
+
+// writeUpload escribe en form un archivo de size bytes y lo cierra. Si before no es nil, escribe
+// el primer bloque del archivo y espera a que se cierre antes de escribir el resto.
+func writeUpload(form *multipart.Writer, size int, before <-chan struct{}) error {
+	part, err := form.CreateFormFile("file", "image.png")
+	if err != nil {
+		return err
+	}
+	chunk := bytes.Repeat([]byte{0x89}, 64<<10)
+	for written := 0; written < size; written += len(chunk) {
+		if _, err := part.Write(chunk[:min(len(chunk), size-written)]); err != nil {
+			return err
+		}
+		if before != nil {
+			select {
+			case <-before:
+			case <-time.After(5 * time.Second):
+				return errors.New("the handler did not receive the file before the whole body was sent")
+			}
+			before = nil
+		}
+	}
+	return form.Close()
+}
+
+func uploadRequest(t *testing.T, size int, idempotencyKey string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	// Un reintento envía exactamente el mismo cuerpo, boundary incluido
+	if err := form.SetBoundary("product-image-upload"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeUpload(form, size, nil); err != nil {
+		t.Fatal(err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/products/p1/images", &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request.Header.Set(middleware.IdempotencyKeyHeader, idempotencyKey)
+	return request
+}
+
+func TestUploadProductImageWithIdempotencyKeyRejectsOversizedFile(t *testing.T) {
+	router := newUploadRouter(&streamingImageService{})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, uploadRequest(t, testMaxImageSize+2<<20, "upload-1"))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: got %d, want %d: %s", recorder.Code, http.StatusRequestEntityTooLarge, recorder.Body)
+	}
+
+	// El error libera la clave: el reintento con un archivo válido se procesa
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, uploadRequest(t, 1<<20, "upload-1"))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("retry: got %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body)
+	}
+
+	// El mismo cuerpo se repite y otro cuerpo con la misma clave se rechaza
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, uploadRequest(t, 1<<20, "upload-1"))
+	if recorder.Code != http.StatusCreated || recorder.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Fatalf("replay: got %d, replayed %q", recorder.Code, recorder.Header().Get(middleware.IdempotentReplayedHeader))
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, uploadRequest(t, 1<<20+1, "upload-1"))
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key: got %d, want %d", recorder.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestUploadProductImageWithIdempotencyKeyStreams(t *testing.T) {
+	firstByte := make(chan struct{})
+	router := newUploadRouter(&streamingImageService{firstByte: firstByte})
+
+	// El resto del archivo solo se envía cuando el servicio ya ha recibido el principio: si el
+	// middleware cargara el cuerpo entero antes de llamar al controlador, la subida no terminaría
+	bodyReader, bodyWriter := io.Pipe()
+	form := multipart.NewWriter(bodyWriter)
+	writeErr := make(chan error, 1)
+	go func() {
+		err := writeUpload(form, 1<<20, firstByte)
+		_ = bodyWriter.CloseWithError(err)
+		writeErr <- err
+	}()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/products/p1/images", bodyReader)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request.Header.Set(middleware.IdempotencyKeyHeader, "upload-2")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if err := <-writeErr; err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("got %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body)
+	}
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	"github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/go-swagger-generator/src/openapi"
+	"github.com/ruiborda/go-swagger-generator/src/openapi_spec/mime"
+	"github.com/ruiborda/go-swagger-generator/src/swagger"
+)
+
+// multipartFormData es el tipo MIME de las subidas de archivos
+const multipartFormData = mime.MimeType("multipart/form-data")
+
+// ProductImportController importa productos desde hojas de cálculo CSV y XLSX
+type ProductImportController struct {
+	productImportService service.ProductImportService
+	maxFileSize          int64
+}
+
+func NewProductImportController() *ProductImportController {
+	return &ProductImportController{
+		productImportService: impl.NewProductImportServiceImpl(),
+		maxFileSize:          int64(config.GetEnvInt("IMPORT_MAX_FILE_SIZE", 10<<20)),
+	}
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/import").
+	Post(func(operation openapi.Operation) {
+		operation.Summary("Import products from a CSV or XLSX file (upsert by SKU)").
+			Description("Small files are processed during the request (200). Large files are processed in the background (202) and their progress is available at the Location of the import job; if too many imports are already running the request is rejected (429).").
+			OperationID("ImportProducts").
+			Tag("ProductImportController").
+			Consume(multipartFormData).
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			FormParameter("file", func(param openapi.Parameter) {
+				param.Description("CSV (comma or semicolon separated) or XLSX file; the first row is the header").
+					Required(true).
+					Type("file")
+			}).
+			FormParameter("dryRun", func(param openapi.Parameter) {
+				param.Description("Validate every row and report errors without writing anything").
+					Type("boolean")
+			}).
+			FormParameter("mapping", func(param openapi.Parameter) {
+				param.Description(`JSON object mapping column headers to fields, e.g. {"Código": "sku", "Precio": "price"}. Fields: sku, name, description, price, currency, discount, stock, category, image, status, publishAt, unpublishAt`).
+					Type("string")
+			}).
+			HeaderParameter(idempotencyKeyHeader, idempotencyKeyParameter).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Import report").
+					SchemaFromDTO(&product.ImportProductsResponse{})
+			}).
+			Response(http.StatusAccepted, func(response openapi.Response) {
+				response.Description("Import job queued").
+					SchemaFromDTO(&product.ImportProductsResponse{}).
+					Header("Location", func(header openapi.Header) {
+						header.Description("URL of the import job status").
+							Type("string")
+					})
+			}).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImportController) ImportProducts(c *gin.Context) {
+	// El cuerpo multipart incluye cabeceras además del archivo: se deja un margen de 1 MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ic.maxFileSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			_ = c.Error(ic.fileTooLarge())
+			return
+		}
+		_ = c.Error(exception.Validation(exception.CodeInvalidRequest, "The request must be multipart/form-data with a file field").WithField("file", "is required"))
+		return
+	}
+	if fileHeader.Size > ic.maxFileSize {
+		_ = c.Error(ic.fileTooLarge())
+		return
+	}
+
+	importRequest := &product.ImportProductsRequest{FileName: fileHeader.Filename}
+	if dryRun := c.PostForm("dryRun"); dryRun != "" {
+		if importRequest.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			_ = c.Error(exception.Validation(exception.CodeInvalidRequest, "The request is not valid").WithField("dryRun", "must be true or false"))
+			return
+		}
+	}
+	if mapping := c.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &importRequest.Mapping); err != nil {
+			_ = c.Error(exception.Validation(exception.CodeInvalidRequest, "The request is not valid").WithField("mapping", "must be a JSON object of column to field"))
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+	defer func() { _ = file.Close() }()
+	if importRequest.Content, err = io.ReadAll(file); err != nil {
+		_ = c.Error(invalidBody(err))
+		return
+	}
+
+	response, async, err := ic.productImportService.ImportProducts(c.Request.Context(), importRequest, middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if async {
+		c.Header("Location", "/api/v1/products/import/jobs/"+response.JobId)
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/products/import/jobs/{id}").
+	Get(func(operation openapi.Operation) {
+		operation.Summary("Get the status and report of an import job").
+			OperationID("GetImportJob").
+			Tag("ProductImportController").
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", func(param openapi.Parameter) {
+				param.Description("ID of the import job").
+					Required(true).
+					Type("string")
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Successful operation").
+					SchemaFromDTO(&product.ImportProductsResponse{})
+			}).
+			Security("BearerAuth")
+		problemResponses(operation, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusBadGateway)
+	}).Doc()
+
+func (ic *ProductImportController) GetImportJob(c *gin.Context) {
+	response, err := ic.productImportService.GetImportJob(c.Request.Context(), c.Param("id"), middleware.GetPrincipal(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (ic *ProductImportController) fileTooLarge() error {
+	return exception.PayloadTooLarge(exception.CodeFileTooLarge, "The file must not exceed "+strconv.FormatInt(ic.maxFileSize>>20, 10)+" MB")
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	"github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/go-swagger-generator/src/openapi"
+	"github.com/ruiborda/go-swagger-generator/src/openapi_spec/mime"
+	"github.com/ruiborda/go-swagger-generator/src/swagger"
+)
+
+// maxPublicPageSize limita el tamaño de página del catálogo público
+const maxPublicPageSize = 50
+
+// PublicProductController expone el catálogo de solo lectura para clientes no autenticados
+type PublicProductController struct {
+	productService service.ProductService
+}
+
+func NewPublicProductController() *PublicProductController {
+	return &PublicProductController{
+		productService: impl.NewProductServiceImpl(),
+	}
+}
+
+var _ = swagger.Swagger().Path("/api/v1/public/products").
+	Get(func(operation openapi.Operation) {
+		operation.Summary("Search published products (no authentication)").
+			OperationID("SearchPublicProducts").
+			Tag("PublicProductController").
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			QueryParameter("query", func(param openapi.Parameter) {
+				param.Description("Search query").
+					Type("string")
+			}).
+			QueryParameter("cursor", func(param openapi.Parameter) {
+				param.Description("nextCursor of the previous page; empty for the first page").
+					Type("string")
+			}).
+			QueryParameter("size", func(param openapi.Parameter) {
+				param.Description("Page size (max 50)").
+					Type("integer").
+					Format("int32")
+			}).
+			QueryParameter("categoryId", func(param openapi.Parameter) {
+				param.Description("Filter by category ID").
+					Type("string")
+			}).
+			QueryParameter("priceMin", func(param openapi.Parameter) {
+				param.Description("Minimum price").
+					Type("number").
+					Format("float")
+			}).
+			QueryParameter("priceMax", func(param openapi.Parameter) {
+				param.Description("Maximum price").
+					Type("number").
+					Format("float")
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Successful operation").
+					SchemaFromDTO(&product.SearchPublicProductsResponse{})
+			})
+		problemResponses(operation, http.StatusBadRequest, http.StatusTooManyRequests, http.StatusBadGateway)
+	}).Doc()
+
+func (pc *PublicProductController) SearchPublicProducts(c *gin.Context) {
+	// Los filtros son los de la búsqueda autenticada; la paginación es por cursor y sin orden
+	filters := searchRequestFromQuery(c)
+	searchRequest := &product.SearchPublicProductsRequest{
+		Cursor:     c.Query("cursor"),
+		Size:       min(filters.Size, maxPublicPageSize),
+		Query:      filters.Query,
+		CategoryId: filters.CategoryId,
+		PriceMin:   filters.PriceMin,
+		PriceMax:   filters.PriceMax,
+	}
+
+	response, err := pc.productService.SearchPublicProducts(c.Request.Context(), searchRequest)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+var _ = swagger.Swagger().Path("/api/v1/public/products/{id}").
+	Get(func(operation openapi.Operation) {
+		operation.Summary("Get a published product by ID (no authentication)").
+			OperationID("GetPublicProductById").
+			Tag("PublicProductController").
+			Produces(mime.ApplicationJSON, ApplicationProblemJSON).
+			PathParameter("id", func(param openapi.Parameter) {
+				param.Description("ID of the product to get").
+					Required(true).
+					Type("string")
+			}).
+			Response(http.StatusOK, func(response openapi.Response) {
+				response.Description("Successful operation").
+					SchemaFromDTO(&product.PublicProductResponse{})
+			})
+		problemResponses(operation, http.StatusNotFound, http.StatusTooManyRequests, http.StatusBadGateway)
+	}).Doc()
+
+func (pc *PublicProductController) GetPublicProductById(c *gin.Context) {
+	response, err := pc.productService.GetPublicProductById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+// StorageController sirve los archivos de los almacenamientos local y en memoria, que no tienen un
+// bucket propio, y recibe las subidas directas firmadas con PresignUpload. No forma parte de la API
+// documentada: solo existe con esos backends.
+type StorageController struct {
+	storageRepository repository.ServedStorageRepository
+	maxFileSize       int64
+}
+
+func NewStorageController(storageRepository repository.ServedStorageRepository) *StorageController {
+	return &StorageController{
+		storageRepository: storageRepository,
+		maxFileSize:       int64(config.GetEnvInt("PRODUCT_IMAGE_MAX_SIZE", 10<<20)),
+	}
+}
+
+// storageFileName devuelve la clave del archivo del comodín de la ruta
+func storageFileName(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("fileName"), "/")
+}
+
+// GetFile sirve un archivo con soporte de Range y de las cabeceras condicionales (también para HEAD)
+func (sc *StorageController) GetFile(c *gin.Context) {
+	fileName := storageFileName(c)
+	var reader io.ReadCloser
+	head, err := sc.storageRepository.HeadObject(c.Request.Context(), fileName)
+	if err == nil {
+		reader, err = sc.storageRepository.OpenFile(c.Request.Context(), fileName)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrFileNotFound) {
+			_ = c.Error(exception.NotFound(exception.CodeFileNotFound, "File not found"))
+			return
+		}
+		_ = c.Error(exception.StorageError(err))
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", head.ContentType)
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", time.Unix(head.LastModified, 0), seeker)
+		return
+	}
+	c.DataFromReader(http.StatusOK, head.ContentLength, head.ContentType, reader, nil)
+}
+
+// PutFile guarda un archivo subido con una URL de PresignUpload. La firma liga la subida al tipo y al
+// tamaño declarados, igual que en un bucket S3
+func (sc *StorageController) PutFile(c *gin.Context) {
+	fileName := storageFileName(c)
+	contentType := c.GetHeader("Content-Type")
+	size := c.Request.ContentLength
+	if size < 0 || size > sc.maxFileSize {
+		_ = c.Error(exception.PayloadTooLarge(exception.CodeFileTooLarge, "The file exceeds the maximum allowed size"))
+		return
+	}
+	if err := sc.storageRepository.VerifyUpload(fileName, c.Request.URL.Query(), contentType, size); err != nil {
+		_ = c.Error(exception.Forbidden(exception.CodeInvalidSignature, "The upload URL is not valid or has expired"))
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, size))
+	if err != nil || int64(len(data)) != size {
+		_ = c.Error(exception.Validation(exception.CodeInvalidFile, "The file does not match the declared Content-Length"))
+		return
+	}
+	if err := sc.storageRepository.PutFile(c.Request.Context(), fileName, data, contentType); err != nil {
+		_ = c.Error(exception.StorageError(err))
+		return
+	}
+	c.Status(http.StatusOK)
+}
@@ -1,8 +1,8 @@
 package auth
 
-// JwtPrivateClaims estructura que representa los claims personalizados en el token JWT
-type JwtPrivateClaims struct {
-	Email         string   `json:"email"`
-	Roles         []string `json:"roles"`
-	PermissionIds []int    `json:"permissionIds"`
-}
\ No newline at end of file
+import userAuth "github.com/ruiborda/ecommerce-user-service/src/dto/auth"
+
+// JwtPrivateClaims estructura que representa los claims personalizados en el token JWT.
+// Es un alias del tipo del servicio de usuarios, que es quien emite los tokens, para leer
+// los claims con la misma estructura.
+type JwtPrivateClaims = userAuth.JwtPrivateClaims
@@ -0,0 +1,35 @@
+package auth
+
+import "github.com/ruiborda/ecommerce-product-service/src/model"
+
+// Principal representa al usuario autenticado que realiza la petición
+type Principal struct {
+	Subject     string
+	Email       string
+	Roles       []string
+	Permissions []model.Permission
+	// Admin indica que el usuario tiene un rol de administrador y puede gestionar todos los productos
+	Admin bool
+}
+
+// HasPermission indica si el usuario tiene el permiso indicado
+func (p *Principal) HasPermission(permission model.Permission) bool {
+	if p == nil {
+		return false
+	}
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManage indica si el usuario puede gestionar un recurso del autor indicado:
+// los autores gestionan sus propios productos y los administradores todos
+func (p *Principal) CanManage(authorId string) bool {
+	if p == nil {
+		return false
+	}
+	return p.Admin || (authorId != "" && p.Subject == authorId)
+}
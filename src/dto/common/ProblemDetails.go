@@ -0,0 +1,18 @@
+package dto
+
+import "github.com/ruiborda/ecommerce-product-service/src/exception"
+
+// ProblemDetailsContentType es el tipo de contenido de las respuestas de error (RFC 7807)
+const ProblemDetailsContentType = "application/problem+json"
+
+// ProblemDetails representa una respuesta de error según RFC 7807
+type ProblemDetails struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code"`
+	RequestId string                 `json:"requestId,omitempty"`
+	Errors    []exception.FieldError `json:"errors,omitempty"`
+}
@@ -0,0 +1,9 @@
+package product
+
+// AddProductImageRequest añade una imagen a la galería de un producto
+type AddProductImageRequest struct {
+	ImageBase64 string            `json:"imageBase64"`
+	Alt         map[string]string `json:"alt"`      // texto alternativo por idioma, por ejemplo {"es": "Camiseta azul"}
+	Primary     bool              `json:"primary"`  // la convierte en la imagen principal
+	Position    *int              `json:"position"` // posición en la galería; por defecto al final
+}
@@ -5,4 +5,5 @@ type AdjustProductStockResponse struct {
 	Id            string `json:"id"`
 	PreviousStock int    `json:"previousStock"`
 	CurrentStock  int    `json:"currentStock"`
+	ETag          string `json:"-"` // se devuelve en la cabecera ETag
 }
@@ -0,0 +1,33 @@
+package product
+
+// Modos de ejecución de una petición masiva
+const (
+	// BulkModeAtomic aplica todas las operaciones o ninguna
+	BulkModeAtomic = "atomic"
+	// BulkModeBestEffort aplica las operaciones válidas aunque otras fallen
+	BulkModeBestEffort = "best-effort"
+)
+
+// Tipos de operación de una petición masiva
+const (
+	BulkOperationCreate      = "create"
+	BulkOperationUpdate      = "update"
+	BulkOperationDelete      = "delete"
+	BulkOperationStockAdjust = "stock-adjust"
+)
+
+// BulkProductRequest agrupa varias operaciones sobre productos en una sola petición
+type BulkProductRequest struct {
+	Mode       string                 `json:"mode"` // atomic (por defecto) o best-effort
+	Operations []BulkProductOperation `json:"operations"`
+}
+
+// BulkProductOperation es una operación de una petición masiva. Product se usa en create y
+// update, Quantity en stock-adjust e Id e IfMatch en todas salvo create.
+type BulkProductOperation struct {
+	Operation string                `json:"operation"` // create, update, delete o stock-adjust
+	Id        string                `json:"id"`
+	IfMatch   string                `json:"ifMatch"` // ETag de la versión que se modifica (opcional)
+	Product   *CreateProductRequest `json:"product"`
+	Quantity  int                   `json:"quantity"`
+}
@@ -0,0 +1,23 @@
+package product
+
+import dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
+
+// BulkProductResponse contiene el resultado de cada operación de una petición masiva, en el mismo orden
+type BulkProductResponse struct {
+	Mode      string              `json:"mode"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Results   []BulkProductResult `json:"results"`
+}
+
+// BulkProductResult es el resultado de una operación: el código HTTP que habría devuelto
+// la operación individual y, si falló, el error en formato problem+json
+type BulkProductResult struct {
+	Index     int                 `json:"index"`
+	Operation string              `json:"operation"`
+	Id        string              `json:"id,omitempty"`
+	Status    int                 `json:"status"`
+	ETag      string              `json:"etag,omitempty"`
+	Error     *dto.ProblemDetails `json:"error,omitempty"`
+	Err       error               `json:"-"` // el controlador lo convierte en Status y Error
+}
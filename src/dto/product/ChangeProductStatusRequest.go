@@ -0,0 +1,6 @@
+package product
+
+// ChangeProductStatusRequest solicita una transición del ciclo de vida del producto
+type ChangeProductStatusRequest struct {
+	Status string `json:"status"` // draft, published o archived
+}
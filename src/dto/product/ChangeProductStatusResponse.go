@@ -0,0 +1,8 @@
+package product
+
+// ChangeProductStatusResponse devuelve el estado anterior y el nuevo estado del producto
+type ChangeProductStatusResponse struct {
+	Id             string `json:"id"`
+	PreviousStatus string `json:"previousStatus"`
+	Status         string `json:"status"`
+}
@@ -0,0 +1,8 @@
+package product
+
+// ConfirmImageUploadRequest añade a la galería una imagen subida con una URL firmada
+type ConfirmImageUploadRequest struct {
+	Alt      map[string]string `json:"alt"`      // texto alternativo por idioma, por ejemplo {"es": "Camiseta azul"}
+	Primary  bool              `json:"primary"`  // la convierte en la imagen principal
+	Position *int              `json:"position"` // posición en la galería; por defecto al final
+}
@@ -0,0 +1,7 @@
+package product
+
+// CreateImageUploadRequest solicita una URL firmada para subir una imagen directamente al almacenamiento
+type CreateImageUploadRequest struct {
+	ContentType string `json:"contentType"` // image/jpeg, image/png, image/webp o image/avif
+	Size        int64  `json:"size"`        // tamaño exacto del archivo en bytes
+}
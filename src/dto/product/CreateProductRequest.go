@@ -10,4 +10,7 @@ type CreateProductRequest struct {
 	Sku         string  `json:"sku"`
 	Stock       int     `json:"stock"`
 	ImageBase64 string  `json:"imageBase64"`
+	Status      string  `json:"status"`      // draft (por defecto) o published
+	PublishAt   string  `json:"publishAt"`   // RFC 3339, publicación programada
+	UnpublishAt string  `json:"unpublishAt"` // RFC 3339, retirada programada
 }
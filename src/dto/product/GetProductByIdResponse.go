@@ -2,18 +2,24 @@
 package product
 
 type GetProductByIdResponse struct {
-	Id           string  `json:"id"`
-	CategoryId   string  `json:"categoryId"`
-	CategoryName string  `json:"categoryName"`
-	AuthorId     string  `json:"authorId"`
-	Name         string  `json:"name"`
-	Description  string  `json:"description"`
-	Price        float64 `json:"price"`
-	Currency     string  `json:"currency"`
-	Discount     float64 `json:"discount"`
-	Sku          string  `json:"sku"`
-	Stock        int     `json:"stock"`
-	FileImage    string  `json:"fileImage"`
-	CreatedAt    string  `json:"createdAt"`
-	UpdatedAt    string  `json:"updatedAt"`
+	Id           string                  `json:"id"`
+	CategoryId   string                  `json:"categoryId"`
+	CategoryName string                  `json:"categoryName"`
+	AuthorId     string                  `json:"authorId"`
+	Name         string                  `json:"name"`
+	Description  string                  `json:"description"`
+	Price        float64                 `json:"price"`
+	Currency     string                  `json:"currency"`
+	Discount     float64                 `json:"discount"`
+	Sku          string                  `json:"sku"`
+	Stock        int                     `json:"stock"`
+	FileImage    string                  `json:"fileImage"`
+	ImageUrl     string                  `json:"imageUrl,omitempty"`
+	Images       []*ProductImageResponse `json:"images"`
+	CreatedAt    string                  `json:"createdAt"`
+	UpdatedAt    string                  `json:"updatedAt"`
+	Status       string                  `json:"status"`
+	PublishAt    string                  `json:"publishAt,omitempty"`
+	UnpublishAt  string                  `json:"unpublishAt,omitempty"`
+	ETag         string                  `json:"-"` // se devuelve en la cabecera ETag
 }
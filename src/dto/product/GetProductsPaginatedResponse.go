@@ -13,6 +13,10 @@ type GetProductsPaginatedResponse struct {
 	Sku         string  `json:"sku"`
 	Stock       int     `json:"stock"`
 	FileImage   string  `json:"fileImage"`
+	ImageUrl    string  `json:"imageUrl,omitempty"`
 	CreatedAt   string  `json:"createdAt"`
 	UpdatedAt   string  `json:"updatedAt"`
+	Status      string  `json:"status"`
+	PublishAt   string  `json:"publishAt,omitempty"`
+	UnpublishAt string  `json:"unpublishAt,omitempty"`
 }
@@ -0,0 +1,17 @@
+package product
+
+// ImageReferenceSyncReport es el resultado de registrar las referencias de los productos a sus archivos de imagen
+type ImageReferenceSyncReport struct {
+	Products int                       `json:"products"` // productos recorridos, también los de la papelera
+	Files    int                       `json:"files"`    // archivos de imagen referenciados por los productos
+	Added    int                       `json:"added"`    // referencias que no estaban registradas
+	Failed   int                       `json:"failed"`
+	Errors   []ImageReferenceSyncError `json:"errors,omitempty"`
+}
+
+// ImageReferenceSyncError describe un archivo cuya referencia no se pudo registrar
+type ImageReferenceSyncError struct {
+	ProductId string `json:"productId"`
+	FileName  string `json:"fileName"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,16 @@
+package product
+
+// ImageUploadResponse es una subida directa pendiente de confirmar. El archivo se envía con Method
+// a Url y exactamente las cabeceras de Headers, que forman parte de la firma: la URL solo admite un
+// archivo de ese tipo y ese tamaño y deja de ser válida en ExpiresAt.
+type ImageUploadResponse struct {
+	UploadId    string            `json:"uploadId"`
+	FileName    string            `json:"fileName"`
+	Url         string            `json:"url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	ContentType string            `json:"contentType"`
+	Size        int64             `json:"size"`
+	MaxSize     int64             `json:"maxSize"`
+	ExpiresAt   string            `json:"expiresAt"`
+}
@@ -0,0 +1,14 @@
+package product
+
+// ImportProductsRequest contiene el archivo y las opciones de una importación de productos.
+// Se construye a partir del formulario multipart de la petición.
+type ImportProductsRequest struct {
+	FileName string
+	Content  []byte
+	// DryRun valida todas las filas e informa de los errores sin escribir nada
+	DryRun bool
+	// Mapping asigna columnas de la hoja (por su cabecera) a campos de producto: sku, name,
+	// description, price, currency, discount, stock, category, image, status, publishAt, unpublishAt.
+	// Las columnas no indicadas se asignan si su cabecera coincide con el nombre de un campo.
+	Mapping map[string]string
+}
@@ -0,0 +1,28 @@
+package product
+
+// ImportProductsResponse es el estado y el informe de una importación de productos
+type ImportProductsResponse struct {
+	JobId           string           `json:"jobId"`
+	Status          string           `json:"status"` // pending, running, completed o failed
+	FileName        string           `json:"fileName"`
+	Format          string           `json:"format"`
+	DryRun          bool             `json:"dryRun"`
+	TotalRows       int              `json:"totalRows"`
+	ProcessedRows   int              `json:"processedRows"`
+	Created         int              `json:"created"`
+	Updated         int              `json:"updated"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errorsTruncated"`
+	Message         string           `json:"message,omitempty"`
+	CreatedAt       string           `json:"createdAt"`
+	FinishedAt      string           `json:"finishedAt,omitempty"`
+}
+
+// ImportRowError describe el error de una fila (las filas empiezan en 1, la cabecera incluida)
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Sku     string `json:"sku,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,27 @@
+package product
+
+// PatchProductDocument es la representación de un producto a la que se aplica un PATCH
+// (RFC 7396 o RFC 6902). Solo contiene los campos modificables y no usa omitempty para
+// que los valores cero explícitos (por ejemplo "discount": 0) se conserven.
+type PatchProductDocument struct {
+	CategoryId  string  `json:"categoryId"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Currency    string  `json:"currency"`
+	Discount    float64 `json:"discount"`
+	Sku         string  `json:"sku"`
+	Stock       int     `json:"stock"`
+	PublishAt   string  `json:"publishAt"`
+	UnpublishAt string  `json:"unpublishAt"`
+}
+
+// PatchType es el formato de un PATCH, identificado por su Content-Type
+type PatchType string
+
+const (
+	// MergePatch es un JSON Merge Patch (RFC 7396)
+	MergePatch PatchType = "application/merge-patch+json"
+	// JSONPatch es un JSON Patch (RFC 6902)
+	JSONPatch PatchType = "application/json-patch+json"
+)
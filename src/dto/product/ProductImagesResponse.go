@@ -0,0 +1,32 @@
+package product
+
+// ProductImageResponse es una imagen de la galería de un producto
+type ProductImageResponse struct {
+	Id         string                             `json:"id"`
+	FileName   string                             `json:"fileName"`
+	Url        string                             `json:"url,omitempty"` // URL completa del archivo
+	Position   int                                `json:"position"`
+	Alt        map[string]string                  `json:"alt,omitempty"`
+	Primary    bool                               `json:"primary"`
+	Width      int                                `json:"width,omitempty"`
+	Height     int                                `json:"height,omitempty"`
+	Renditions map[string]*ImageRenditionResponse `json:"renditions,omitempty"` // variantes en WebP por nombre
+	CreatedAt  string                             `json:"createdAt,omitempty"`
+}
+
+// ImageRenditionResponse es una variante reducida de una imagen
+type ImageRenditionResponse struct {
+	FileName string `json:"fileName"`
+	Url      string `json:"url,omitempty"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// ProductImagesResponse es la galería de un producto después de modificarla
+type ProductImagesResponse struct {
+	ProductId string                  `json:"productId"`
+	FileImage string                  `json:"fileImage"`          // archivo de la imagen principal
+	ImageUrl  string                  `json:"imageUrl,omitempty"` // URL completa de la imagen principal
+	Images    []*ProductImageResponse `json:"images"`
+	ETag      string                  `json:"-"` // se devuelve en la cabecera ETag
+}
@@ -0,0 +1,18 @@
+package product
+
+// PublicProductResponse es la vista pública de un producto para clientes no autenticados.
+// No incluye el autor, el stock exacto ni campos internos.
+type PublicProductResponse struct {
+	Id          string                  `json:"id"`
+	CategoryId  string                  `json:"categoryId"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Price       float64                 `json:"price"`
+	Currency    string                  `json:"currency"`
+	Discount    float64                 `json:"discount"`
+	Sku         string                  `json:"sku"`
+	InStock     bool                    `json:"inStock"`
+	FileImage   string                  `json:"fileImage"`
+	ImageUrl    string                  `json:"imageUrl,omitempty"`
+	Images      []*ProductImageResponse `json:"images"`
+}
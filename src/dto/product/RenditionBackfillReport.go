@@ -0,0 +1,20 @@
+package product
+
+// RenditionBackfillReport es el resultado de generar las variantes de las imágenes existentes
+type RenditionBackfillReport struct {
+	DryRun    bool                     `json:"dryRun"`
+	Products  int                      `json:"products"`  // productos recorridos
+	Images    int                      `json:"images"`    // imágenes recorridas
+	Generated int                      `json:"generated"` // imágenes con variantes nuevas (en dry run, las que se generarían)
+	Skipped   int                      `json:"skipped"`   // imágenes que ya tenían todas las variantes
+	Failed    int                      `json:"failed"`
+	Errors    []RenditionBackfillError `json:"errors,omitempty"`
+}
+
+// RenditionBackfillError describe una imagen cuyas variantes no se pudieron generar o guardar
+type RenditionBackfillError struct {
+	ProductId string `json:"productId"`
+	ImageId   string `json:"imageId,omitempty"`
+	FileName  string `json:"fileName,omitempty"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,6 @@
+package product
+
+// ReorderProductImagesRequest indica el nuevo orden de la galería con los IDs de todas sus imágenes
+type ReorderProductImagesRequest struct {
+	ImageIds []string `json:"imageIds"`
+}
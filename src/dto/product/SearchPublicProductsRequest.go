@@ -0,0 +1,14 @@
+package product
+
+// SearchPublicProductsRequest es la búsqueda del catálogo público. Se pagina con un cursor en
+// lugar de números de página para no leer la colección entera.
+type SearchPublicProductsRequest struct {
+	// Cursor es el nextCursor de la página anterior; vacío para la primera
+	Cursor string `json:"cursor" form:"cursor"`
+	Size   int    `json:"size" form:"size"`
+
+	Query      string  `json:"query" form:"query"`
+	CategoryId string  `json:"categoryId" form:"categoryId"`
+	PriceMin   float64 `json:"priceMin" form:"priceMin"`
+	PriceMax   float64 `json:"priceMax" form:"priceMax"`
+}
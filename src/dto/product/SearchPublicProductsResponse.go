@@ -0,0 +1,8 @@
+package product
+
+// SearchPublicProductsResponse es una página del catálogo público. Si nextCursor no está vacío hay
+// más productos por recorrer; la página puede tener menos de size productos aunque haya más.
+type SearchPublicProductsResponse struct {
+	Data       []*PublicProductResponse `json:"data"`
+	NextCursor string                   `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,15 @@
+package product
+
+// StatusBackfillReport es el resultado de guardar el estado de los productos anteriores al ciclo de vida
+type StatusBackfillReport struct {
+	Products int                   `json:"products"` // productos recorridos
+	Updated  int                   `json:"updated"`  // productos sin estado guardados como published
+	Failed   int                   `json:"failed"`
+	Errors   []StatusBackfillError `json:"errors,omitempty"`
+}
+
+// StatusBackfillError describe un producto cuyo estado no se pudo guardar
+type StatusBackfillError struct {
+	ProductId string `json:"productId"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,39 @@
+package product
+
+// StorageReconcileReport es el resultado de cruzar los archivos del almacenamiento con las imágenes
+// de los productos. Las listas incluyen como máximo los primeros elementos; los contadores son totales.
+type StorageReconcileReport struct {
+	DeleteOrphans       bool                   `json:"deleteOrphans"`
+	GracePeriod         string                 `json:"gracePeriod"`
+	Products            int                    `json:"products"`            // productos recorridos, también los de la papelera
+	Objects             int                    `json:"objects"`             // archivos del almacenamiento
+	Referenced          int                    `json:"referenced"`          // archivos referenciados por algún producto
+	OrphanCount         int                    `json:"orphanCount"`         // archivos que ningún producto referencia
+	OrphanBytes         int64                  `json:"orphanBytes"`         // tamaño total de los huérfanos
+	OrphansDeleted      int                    `json:"orphansDeleted"`      // huérfanos eliminados
+	BrokenReferences    int                    `json:"brokenReferences"`    // referencias a archivos que no existen
+	UndecodableProducts bool                   `json:"undecodableProducts"` // algún producto no se pudo leer: no se elimina ningún huérfano
+	Orphans             []StorageOrphan        `json:"orphans,omitempty"`
+	Broken              []BrokenImageReference `json:"broken,omitempty"`
+}
+
+// StorageOrphan es un archivo del almacenamiento que ningún producto referencia
+type StorageOrphan struct {
+	FileName     string `json:"fileName"`
+	Size         int64  `json:"size"`
+	LastModified string `json:"lastModified"`
+	Deleted      bool   `json:"deleted"`
+	// Reason explica por qué no se eliminó cuando se pidió eliminar los huérfanos
+	Reason string `json:"reason,omitempty"`
+}
+
+// BrokenImageReference es un campo de imagen de un producto que apunta a un archivo que no existe
+type BrokenImageReference struct {
+	ProductId string `json:"productId"`
+	ImageId   string `json:"imageId,omitempty"`
+	// Field es el campo que contiene la referencia: fileImage, images o images.renditions.<variante>
+	Field    string `json:"field"`
+	FileName string `json:"fileName"`
+	// Deleted indica que el producto está en la papelera
+	Deleted bool `json:"deleted"`
+}
@@ -0,0 +1,10 @@
+package product
+
+// StorageReconcileRequest configura la reconciliación del almacenamiento con las imágenes de los productos
+type StorageReconcileRequest struct {
+	// DeleteOrphans elimina los archivos huérfanos más antiguos que el periodo de gracia
+	DeleteOrphans bool `json:"deleteOrphans"`
+	// GracePeriod es la antigüedad mínima de un huérfano para eliminarlo, como duración de Go
+	// ("72h"); por defecto STORAGE_RECONCILE_GRACE_PERIOD
+	GracePeriod string `json:"gracePeriod,omitempty"`
+}
@@ -0,0 +1,7 @@
+package product
+
+// UpdateProductImageRequest modifica los datos de una imagen de la galería
+type UpdateProductImageRequest struct {
+	Alt     map[string]string `json:"alt"`     // reemplaza los textos alternativos; null los conserva
+	Primary bool              `json:"primary"` // true la convierte en la imagen principal
+}
@@ -12,4 +12,6 @@ type UpdateProductRequest struct {
 	Sku         string  `json:"sku"`
 	Stock       int     `json:"stock"`
 	ImageBase64 string  `json:"imageBase64"`
+	PublishAt   string  `json:"publishAt"`   // RFC 3339, publicación programada
+	UnpublishAt string  `json:"unpublishAt"` // RFC 3339, retirada programada
 }
@@ -0,0 +1,12 @@
+package product
+
+import "io"
+
+// UploadProductImageRequest añade a la galería una imagen recibida como archivo multipart.
+// File se lee una sola vez mientras se sube al almacenamiento.
+type UploadProductImageRequest struct {
+	File     io.Reader         `json:"-"`
+	Alt      map[string]string `json:"alt"`
+	Primary  bool              `json:"primary"`
+	Position *int              `json:"position"`
+}
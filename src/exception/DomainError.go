@@ -0,0 +1,137 @@
+package exception
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind clasifica los errores de dominio; cada tipo se corresponde con un código HTTP
+type Kind string
+
+const (
+	KindNotFound      Kind = "NOT_FOUND"
+	KindConflict      Kind = "CONFLICT"
+	KindValidation    Kind = "VALIDATION"
+	KindUnauthorized  Kind = "UNAUTHORIZED"
+	KindForbidden     Kind = "FORBIDDEN"
+	KindUpstream      Kind = "UPSTREAM"
+	KindRateLimited   Kind = "RATE_LIMITED"
+	KindPrecondition  Kind = "PRECONDITION_FAILED"
+	KindUnsupported   Kind = "UNSUPPORTED_MEDIA_TYPE"
+	KindUnprocessable Kind = "UNPROCESSABLE"
+	KindTooLarge      Kind = "PAYLOAD_TOO_LARGE"
+)
+
+// FieldError describe un error de validación de un campo concreto
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// DomainError es el error que devuelven los servicios. Code es un identificador estable
+// para los clientes y Message un texto seguro de mostrar; la causa original (Err) nunca
+// se expone en la respuesta HTTP.
+type DomainError struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *DomainError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+// WithField añade un error de validación de campo
+func (e *DomainError) WithField(field string, message string) *DomainError {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+	return e
+}
+
+// As obtiene el DomainError contenido en la cadena de errores
+func As(err error) (*DomainError, bool) {
+	var domainError *DomainError
+	if errors.As(err, &domainError) {
+		return domainError, true
+	}
+	return nil, false
+}
+
+// IsKind indica si el error es un DomainError del tipo indicado
+func IsKind(err error, kind Kind) bool {
+	domainError, ok := As(err)
+	return ok && domainError.Kind == kind
+}
+
+// NotFound crea un error de recurso no encontrado
+func NotFound(code string, message string) *DomainError {
+	return &DomainError{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// Conflict crea un error de conflicto con el estado actual del recurso
+func Conflict(code string, message string) *DomainError {
+	return &DomainError{Kind: KindConflict, Code: code, Message: message}
+}
+
+// Validation crea un error de validación de la solicitud
+func Validation(code string, message string) *DomainError {
+	return &DomainError{Kind: KindValidation, Code: code, Message: message}
+}
+
+// Unauthorized crea un error de autenticación
+func Unauthorized(code string, message string) *DomainError {
+	return &DomainError{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+// Forbidden crea un error de permisos insuficientes
+func Forbidden(code string, message string) *DomainError {
+	return &DomainError{Kind: KindForbidden, Code: code, Message: message}
+}
+
+// PreconditionFailed crea un error de precondición no cumplida (versión del recurso distinta de If-Match)
+func PreconditionFailed(code string, message string) *DomainError {
+	return &DomainError{Kind: KindPrecondition, Code: code, Message: message}
+}
+
+// UnsupportedMediaType crea un error de tipo de contenido no admitido
+func UnsupportedMediaType(code string, message string) *DomainError {
+	return &DomainError{Kind: KindUnsupported, Code: code, Message: message}
+}
+
+// PayloadTooLarge crea un error de contenido que supera el tamaño máximo permitido
+func PayloadTooLarge(code string, message string) *DomainError {
+	return &DomainError{Kind: KindTooLarge, Code: code, Message: message}
+}
+
+// Unprocessable crea un error de petición bien formada que no se puede procesar
+func Unprocessable(code string, message string) *DomainError {
+	return &DomainError{Kind: KindUnprocessable, Code: code, Message: message}
+}
+
+// RateLimited crea un error de límite de peticiones excedido
+func RateLimited(code string, message string) *DomainError {
+	return &DomainError{Kind: KindRateLimited, Code: code, Message: message}
+}
+
+// Upstream crea un error de una dependencia externa (Firestore, almacenamiento de objetos); la causa queda oculta al cliente
+func Upstream(code string, message string, err error) *DomainError {
+	return &DomainError{Kind: KindUpstream, Code: code, Message: message, Err: err}
+}
+
+// DatabaseError envuelve un error de Firestore
+func DatabaseError(err error) *DomainError {
+	return Upstream(CodeDatabaseError, "The product database is unavailable", err)
+}
+
+// StorageError envuelve un error del almacenamiento de objetos
+func StorageError(err error) *DomainError {
+	return Upstream(CodeStorageError, "The file storage is unavailable", err)
+}
@@ -0,0 +1,39 @@
+package exception
+
+// Códigos de error estables que se devuelven en el campo "code" de las respuestas problem+json
+const (
+	CodeInvalidRequest          = "INVALID_REQUEST"
+	CodeValidationFailed        = "VALIDATION_FAILED"
+	CodeInvalidImage            = "INVALID_IMAGE"
+	CodeProductNotFound         = "PRODUCT_NOT_FOUND"
+	CodeCategoryNotFound        = "CATEGORY_NOT_FOUND"
+	CodeProductNotOwned         = "PRODUCT_NOT_OWNED"
+	CodeInvalidStatusTransition = "INVALID_STATUS_TRANSITION"
+	CodePreconditionFailed      = "PRECONDITION_FAILED"
+	CodeConcurrentModification  = "CONCURRENT_MODIFICATION"
+	CodeUnsupportedMediaType    = "UNSUPPORTED_MEDIA_TYPE"
+	CodeInvalidPatch            = "INVALID_PATCH"
+	CodeIdempotencyKeyReused    = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyKeyInUse     = "IDEMPOTENCY_KEY_IN_USE"
+	CodeIdempotencyResponseLost = "IDEMPOTENCY_RESPONSE_LOST"
+	CodeBulkAborted             = "BULK_ABORTED"
+	CodeInvalidFile             = "INVALID_FILE"
+	CodeFileTooLarge            = "FILE_TOO_LARGE"
+	CodeImportJobNotFound       = "IMPORT_JOB_NOT_FOUND"
+	CodeImportJobsBusy          = "IMPORT_JOBS_BUSY"
+	CodeImageNotFound           = "IMAGE_NOT_FOUND"
+	CodeImageLimitReached       = "IMAGE_LIMIT_REACHED"
+	CodeUploadNotFound          = "UPLOAD_NOT_FOUND"
+	CodeUploadIncomplete        = "UPLOAD_INCOMPLETE"
+	CodeFileNotFound            = "FILE_NOT_FOUND"
+	CodeInvalidSignature        = "INVALID_SIGNATURE"
+	CodeUnauthenticated         = "UNAUTHENTICATED"
+	CodeForbidden               = "FORBIDDEN"
+	CodeDatabaseError           = "DATABASE_ERROR"
+	CodeStorageError            = "STORAGE_ERROR"
+	CodeRouteNotFound           = "ROUTE_NOT_FOUND"
+	CodeRateLimited             = "RATE_LIMITED"
+	CodeTimeout                 = "TIMEOUT"
+	CodeRequestCancelled        = "REQUEST_CANCELLED"
+	CodeInternalError           = "INTERNAL_ERROR"
+)
@@ -0,0 +1,320 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMetadataNotRemovable indica que la imagen tiene metadatos que no se pueden quitar sin volver a
+// codificarla, por ejemplo un AVIF cuyos metadatos están antes de la caja meta
+var ErrMetadataNotRemovable = errors.New("image metadata cannot be removed")
+
+// maxHeifMetaSize limita la caja meta de un AVIF/HEIF, que se lee entera en memoria
+const maxHeifMetaSize = 4 << 20
+
+// heifBrands son las marcas principales de ftyp de los archivos HEIF, entre ellos AVIF
+var heifBrands = map[string]bool{
+	"avif": true, "avis": true, "heic": true, "heix": true, "heim": true,
+	"heis": true, "hevc": true, "hevx": true, "mif1": true, "msf1": true,
+}
+
+// isHeif indica si la cabecera es la de un archivo AVIF o HEIF
+func isHeif(head []byte) bool {
+	return len(head) >= 12 && string(head[4:8]) == "ftyp" && heifBrands[string(head[8:12])]
+}
+
+// byteRange es un intervalo [start, end) de posiciones del archivo
+type byteRange struct {
+	start, end int64
+}
+
+// stripHeifMetadata copia un AVIF/HEIF vaciando con ceros los datos de sus elementos Exif y XMP
+// (elementos mime application/rdf+xml). Los datos se sobrescriben en su sitio, sin cambiar ningún
+// tamaño ni desplazamiento, así que los decodificadores siguen encontrando la imagen. Copia el
+// archivo completo; si algún metadato está antes de la caja meta, y por tanto ya se ha copiado,
+// devuelve ErrMetadataNotRemovable.
+func stripHeifMetadata(dst io.Writer, reader *bufio.Reader) error {
+	writer := &zeroRangeWriter{writer: dst}
+	for {
+		header, err := reader.Peek(8)
+		if err != nil {
+			// Fin del archivo o caja truncada: se copia el resto sin cambios
+			_, err = io.Copy(writer, reader)
+			return err
+		}
+		if string(header[4:8]) != "meta" {
+			size, err := boxSize(reader)
+			if err != nil || size < 8 {
+				// Caja hasta el final del archivo o tamaño no válido: se copia el resto
+				_, err = io.Copy(writer, reader)
+				return err
+			}
+			if _, err := io.CopyN(writer, reader, size); err != nil {
+				return err
+			}
+			continue
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		if size < 12 || size > maxHeifMetaSize {
+			return fmt.Errorf("%w: unsupported meta box size %d", ErrMetadataNotRemovable, size)
+		}
+		meta := make([]byte, size)
+		if _, err := io.ReadFull(reader, meta); err != nil {
+			return err
+		}
+		metaStart := writer.position
+		ranges, err := heifMetadataRanges(meta)
+		if err != nil {
+			return err
+		}
+		for _, r := range ranges {
+			if r.start < metaStart {
+				return fmt.Errorf("%w: metadata before the meta box", ErrMetadataNotRemovable)
+			}
+			writer.ranges = append(writer.ranges, r)
+		}
+		if _, err := writer.Write(meta); err != nil {
+			return err
+		}
+	}
+}
+
+// boxSize lee el tamaño de la caja ISOBMFF siguiente, cabecera incluida, sin consumirla. Devuelve 0
+// si la caja llega hasta el final del archivo.
+func boxSize(reader *bufio.Reader) (int64, error) {
+	header, err := reader.Peek(8)
+	if err != nil {
+		return 0, err
+	}
+	if size := binary.BigEndian.Uint32(header[:4]); size != 1 {
+		return int64(size), nil
+	}
+	header, err = reader.Peek(16)
+	if err != nil {
+		return 0, err
+	}
+	return int64(min(binary.BigEndian.Uint64(header[8:16]), 1<<62)), nil
+}
+
+// heifMetadataRanges analiza la caja meta y devuelve las posiciones en el archivo de los datos de
+// sus elementos Exif y XMP. Los datos guardados dentro de meta, en la caja idat, se vacían
+// directamente en meta.
+func heifMetadataRanges(meta []byte) ([]byteRange, error) {
+	// Cabecera de la caja y versión/indicadores de FullBox
+	children := meta[12:]
+	var metadataItems map[uint32]bool
+	var locations map[uint32][]itemExtent
+	var idat []byte
+	for len(children) >= 8 {
+		size := int(binary.BigEndian.Uint32(children[:4]))
+		if size < 8 || size > len(children) {
+			return nil, fmt.Errorf("%w: malformed meta box", ErrMetadataNotRemovable)
+		}
+		payload := children[8:size]
+		var err error
+		switch string(children[4:8]) {
+		case "iinf":
+			metadataItems, err = parseItemInfo(payload)
+		case "iloc":
+			locations, err = parseItemLocations(payload)
+		case "idat":
+			idat = payload
+		}
+		if err != nil {
+			return nil, err
+		}
+		children = children[size:]
+	}
+
+	var ranges []byteRange
+	for itemId := range metadataItems {
+		for _, extent := range locations[itemId] {
+			if extent.length == 0 {
+				return nil, fmt.Errorf("%w: metadata item without length", ErrMetadataNotRemovable)
+			}
+			switch extent.constructionMethod {
+			case 0:
+				ranges = append(ranges, byteRange{start: int64(extent.offset), end: int64(extent.offset + extent.length)})
+			case 1:
+				// Datos en la caja idat: se vacían directamente en meta
+				if extent.offset > uint64(len(idat)) || extent.length > uint64(len(idat))-extent.offset {
+					return nil, fmt.Errorf("%w: metadata outside the idat box", ErrMetadataNotRemovable)
+				}
+				clear(idat[extent.offset : extent.offset+extent.length])
+			default:
+				return nil, fmt.Errorf("%w: unsupported item construction method", ErrMetadataNotRemovable)
+			}
+		}
+	}
+	return ranges, nil
+}
+
+// parseItemInfo devuelve los IDs de los elementos Exif y XMP de la caja iinf
+func parseItemInfo(payload []byte) (map[uint32]bool, error) {
+	malformed := fmt.Errorf("%w: malformed iinf box", ErrMetadataNotRemovable)
+	// Versión de FullBox y número de entradas, de 16 bits en la versión 0 y de 32 en las demás
+	countSize := 2
+	if len(payload) > 0 && payload[0] != 0 {
+		countSize = 4
+	}
+	if len(payload) < 4+countSize {
+		return nil, malformed
+	}
+	entries := payload[4+countSize:]
+
+	items := map[uint32]bool{}
+	for len(entries) >= 12 {
+		size := int(binary.BigEndian.Uint32(entries[:4]))
+		if size < 12 || size > len(entries) || string(entries[4:8]) != "infe" {
+			return nil, malformed
+		}
+		infe := entries[8:size]
+		entries = entries[size:]
+
+		// Las versiones 0 y 1 no tienen tipo de elemento y no se usan en AVIF
+		version := infe[0]
+		fields := infe[4:]
+		var itemId uint32
+		switch version {
+		case 2:
+			if len(fields) < 8 {
+				return nil, malformed
+			}
+			itemId = uint32(binary.BigEndian.Uint16(fields[:2]))
+			fields = fields[2:]
+		case 3:
+			if len(fields) < 10 {
+				return nil, malformed
+			}
+			itemId = binary.BigEndian.Uint32(fields[:4])
+			fields = fields[4:]
+		default:
+			continue
+		}
+		// item_protection_index, item_type y el nombre terminado en cero
+		itemType := string(fields[2:6])
+		fields = fields[6:]
+		switch itemType {
+		case "Exif":
+			items[itemId] = true
+		case "mime":
+			_, fields, _ = bytes.Cut(fields, []byte{0})
+			contentType, _, _ := bytes.Cut(fields, []byte{0})
+			if string(contentType) == "application/rdf+xml" {
+				items[itemId] = true
+			}
+		}
+	}
+	return items, nil
+}
+
+// itemExtent es un fragmento de los datos de un elemento según la caja iloc
+type itemExtent struct {
+	constructionMethod int
+	offset, length     uint64
+}
+
+// parseItemLocations devuelve los fragmentos de datos de cada elemento de la caja iloc
+func parseItemLocations(payload []byte) (map[uint32][]itemExtent, error) {
+	malformed := fmt.Errorf("%w: malformed iloc box", ErrMetadataNotRemovable)
+	reader := &fieldReader{data: payload}
+	version := reader.uint(1)
+	reader.uint(3)
+	sizes := reader.uint(1)
+	offsetSize, lengthSize := int(sizes>>4), int(sizes&0x0F)
+	sizes = reader.uint(1)
+	baseOffsetSize, indexSize := int(sizes>>4), int(sizes&0x0F)
+	if version == 0 {
+		indexSize = 0
+	}
+	var itemCount uint64
+	if version < 2 {
+		itemCount = reader.uint(2)
+	} else {
+		itemCount = reader.uint(4)
+	}
+
+	locations := map[uint32][]itemExtent{}
+	for i := uint64(0); i < itemCount && !reader.failed; i++ {
+		var itemId uint32
+		if version < 2 {
+			itemId = uint32(reader.uint(2))
+		} else {
+			itemId = uint32(reader.uint(4))
+		}
+		constructionMethod := 0
+		if version > 0 {
+			constructionMethod = int(reader.uint(2) & 0x0F)
+		}
+		// data_reference_index
+		reader.uint(2)
+		baseOffset := reader.uint(baseOffsetSize)
+		extentCount := reader.uint(2)
+		for e := uint64(0); e < extentCount && !reader.failed; e++ {
+			reader.uint(indexSize)
+			offset := reader.uint(offsetSize)
+			length := reader.uint(lengthSize)
+			locations[itemId] = append(locations[itemId], itemExtent{
+				constructionMethod: constructionMethod,
+				offset:             baseOffset + offset,
+				length:             length,
+			})
+		}
+	}
+	if reader.failed {
+		return nil, malformed
+	}
+	return locations, nil
+}
+
+// fieldReader lee enteros big-endian de tamaño variable; si faltan datos marca failed y devuelve 0
+type fieldReader struct {
+	data   []byte
+	failed bool
+}
+
+func (r *fieldReader) uint(size int) uint64 {
+	if size > 8 || size > len(r.data) {
+		r.failed = true
+		return 0
+	}
+	var value uint64
+	for _, b := range r.data[:size] {
+		value = value<<8 | uint64(b)
+	}
+	r.data = r.data[size:]
+	return value
+}
+
+// zeroRangeWriter escribe en writer sustituyendo por ceros los bytes de ranges y cuenta los bytes
+// escritos para conocer la posición en el archivo
+type zeroRangeWriter struct {
+	writer   io.Writer
+	position int64
+	ranges   []byteRange
+}
+
+func (w *zeroRangeWriter) Write(p []byte) (int, error) {
+	var masked []byte
+	for _, r := range w.ranges {
+		start, end := max(r.start, w.position), min(r.end, w.position+int64(len(p)))
+		if start >= end {
+			continue
+		}
+		if masked == nil {
+			masked = bytes.Clone(p)
+		}
+		clear(masked[start-w.position : end-w.position])
+	}
+	if masked != nil {
+		p = masked
+	}
+	n, err := w.writer.Write(p)
+	w.position += int64(n)
+	return n, err
+}
@@ -0,0 +1,268 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// pngSignature es la cabecera de todos los archivos PNG
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// strippedPngChunks son los chunks PNG con metadatos: EXIF y texto, donde se guarda XMP
+var strippedPngChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true}
+
+// StripMetadata copia la imagen de src en dst sin sus metadatos (EXIF con la ubicación GPS, XMP,
+// IPTC y textos), sin volver a codificarla. En JPEG se eliminan los segmentos APP1 y APP13 y solo se
+// conserva la orientación EXIF, para que la imagen se siga viendo derecha; en PNG los chunks eXIf,
+// tEXt, zTXt e iTXt; en WebP los chunks EXIF y XMP se sustituyen por un chunk JUNK del mismo tamaño,
+// y en AVIF y HEIF se vacían los datos de los elementos Exif y XMP. El resto de formatos se copia
+// sin cambios. Devuelve ErrMetadataNotRemovable si los metadatos no se pueden quitar.
+func StripMetadata(dst io.Writer, src io.Reader) error {
+	reader := bufio.NewReader(src)
+	head, err := reader.Peek(12)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("\xFF\xD8")):
+		err = stripJpegMetadata(dst, reader)
+	case bytes.HasPrefix(head, pngSignature):
+		err = stripPngMetadata(dst, reader)
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		err = stripWebpMetadata(dst, reader)
+	case isHeif(head):
+		err = stripHeifMetadata(dst, reader)
+	}
+	// Un archivo truncado se guarda tal como llega, igual que sin quitar los metadatos
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	// Los datos de la imagen y lo que haya después se copian tal cual
+	_, err = io.Copy(dst, reader)
+	return err
+}
+
+// NewMetadataStripper devuelve un lector con la imagen de src sin metadatos (ver StripMetadata).
+// Hay que cerrarlo aunque no se lea hasta el final: Close espera a que se deje de leer src y
+// devuelve ErrMetadataNotRemovable si los metadatos no se pudieron quitar.
+func NewMetadataStripper(src io.Reader) io.ReadCloser {
+	reader, writer := io.Pipe()
+	stripper := &metadataStripper{PipeReader: reader, done: make(chan struct{})}
+	go func() {
+		defer close(stripper.done)
+		stripper.err = StripMetadata(writer, src)
+		_ = writer.CloseWithError(stripper.err)
+	}()
+	return stripper
+}
+
+// metadataStripper es el extremo de lectura de la copia sin metadatos
+type metadataStripper struct {
+	*io.PipeReader
+	done chan struct{}
+	err  error
+}
+
+func (s *metadataStripper) Close() error {
+	err := s.PipeReader.Close()
+	<-s.done
+	if errors.Is(s.err, ErrMetadataNotRemovable) {
+		return s.err
+	}
+	return err
+}
+
+// stripJpegMetadata copia los segmentos de la cabecera del JPEG hasta el inicio de los datos de la
+// imagen (SOS) omitiendo APP1 (EXIF y XMP) y APP13 (IPTC)
+func stripJpegMetadata(dst io.Writer, reader *bufio.Reader) error {
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(reader, soi); err != nil {
+		return err
+	}
+	if _, err := dst.Write(soi); err != nil {
+		return err
+	}
+
+	orientationWritten := false
+	for {
+		header, err := reader.Peek(4)
+		if err != nil || header[0] != 0xFF || header[1] == 0xDA || binary.BigEndian.Uint16(header[2:4]) < 2 {
+			// Datos de la imagen o cabecera no reconocida: se copia el resto sin cambios
+			return nil
+		}
+		header = bytes.Clone(header)
+		if _, err := reader.Discard(4); err != nil {
+			return err
+		}
+		length := int(binary.BigEndian.Uint16(header[2:4]))
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(reader, segment); err != nil {
+			return err
+		}
+
+		switch header[1] {
+		case 0xE1:
+			if orientationWritten || !bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+				continue
+			}
+			orientation := exifOrientation(segment[6:])
+			if orientation == 1 {
+				continue
+			}
+			if _, err := dst.Write(orientationSegment(orientation)); err != nil {
+				return err
+			}
+			orientationWritten = true
+		case 0xED:
+			continue
+		default:
+			if _, err := dst.Write(header); err != nil {
+				return err
+			}
+			if _, err := dst.Write(segment); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// orientationSegment crea un segmento APP1 con un EXIF que solo contiene la etiqueta Orientation
+func orientationSegment(orientation int) []byte {
+	tiff := []byte("MM\x00\x2A\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	// Etiqueta Orientation, tipo SHORT, un valor, sin IFD siguiente
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// stripPngMetadata copia los chunks del PNG hasta IEND omitiendo los de strippedPngChunks
+func stripPngMetadata(dst io.Writer, reader *bufio.Reader) error {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(reader, signature); err != nil {
+		return err
+	}
+	if _, err := dst.Write(signature); err != nil {
+		return err
+	}
+
+	for {
+		header, err := readChunkHeader(reader)
+		if err != nil {
+			return nil
+		}
+		chunkType := string(header[4:8])
+		// Datos, CRC incluido
+		size := int64(binary.BigEndian.Uint32(header[:4])) + 4
+		if strippedPngChunks[chunkType] {
+			if _, err := io.CopyN(io.Discard, reader, size); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := dst.Write(header); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(dst, reader, size); err != nil {
+			return err
+		}
+		if chunkType == "IEND" {
+			return nil
+		}
+	}
+}
+
+// stripWebpMetadata copia los chunks del WebP vaciando los EXIF y XMP. Se conservan como JUNK del
+// mismo tamaño, que los decodificadores ignoran, para no cambiar el tamaño declarado en la cabecera
+// RIFF antes de haber leído todo el archivo; también se quitan sus indicadores del chunk VP8X.
+func stripWebpMetadata(dst io.Writer, reader *bufio.Reader) error {
+	riff := make([]byte, 12)
+	if _, err := io.ReadFull(reader, riff); err != nil {
+		return err
+	}
+	if _, err := dst.Write(riff); err != nil {
+		return err
+	}
+
+	for {
+		header, err := readChunkHeader(reader)
+		if err != nil {
+			return nil
+		}
+		chunkType := string(header[:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		// Los chunks ocupan un número par de bytes
+		size += size & 1
+
+		switch chunkType {
+		case "EXIF", "XMP ":
+			copy(header[:4], "JUNK")
+			if _, err := dst.Write(header); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(io.Discard, reader, size); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(dst, zeroReader{}, size); err != nil {
+				return err
+			}
+			continue
+		case "VP8X":
+			if size > 64 {
+				// VP8X ocupa 10 bytes: un tamaño mayor no es válido y el chunk se copia sin cambios
+				break
+			}
+			payload := make([]byte, size)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return err
+			}
+			if len(payload) > 0 {
+				// Indicadores de EXIF (0x08) y XMP (0x04)
+				payload[0] &^= 0x08 | 0x04
+			}
+			if _, err := dst.Write(header); err != nil {
+				return err
+			}
+			if _, err := dst.Write(payload); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := dst.Write(header); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(dst, reader, size); err != nil {
+			return err
+		}
+	}
+}
+
+// readChunkHeader lee la cabecera de 8 bytes de un chunk PNG o WebP. Si no está completa no la
+// consume, para que se copie tal cual junto con el resto del archivo.
+func readChunkHeader(reader *bufio.Reader) ([]byte, error) {
+	header, err := reader.Peek(8)
+	if err != nil {
+		return nil, err
+	}
+	header = bytes.Clone(header)
+	_, err = reader.Discard(8)
+	return header, err
+}
+
+// zeroReader devuelve ceros indefinidamente
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
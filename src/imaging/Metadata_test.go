@@ -0,0 +1,339 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+)
+
+// secret es el texto de los metadatos de las pruebas; no debe aparecer en la imagen sin metadatos
+const secret = "GPS 40.4168N 3.7038W"
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 3; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 60), G: uint8(y * 80), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func strip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var stripped bytes.Buffer
+	if err := StripMetadata(&stripped, bytes.NewReader(data)); err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	return stripped.Bytes()
+}
+
+// exifSegment crea un segmento APP1 con un EXIF big-endian con la orientación indicada y el texto secret
+func exifSegment(orientation int) []byte {
+	tiff := []byte("MM\x00\x2A\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 2)
+	// Orientation
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0)
+	// ImageDescription, con el texto a continuación del IFD
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x010E)
+	tiff = binary.BigEndian.AppendUint16(tiff, 2)
+	tiff = binary.BigEndian.AppendUint32(tiff, uint32(len(secret)+1))
+	tiff = binary.BigEndian.AppendUint32(tiff, uint32(len(tiff)+8))
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, secret+"\x00"...)
+	return jpegSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withJpegSegments inserta los segmentos después del marcador SOI
+func withJpegSegments(data []byte, segments ...[]byte) []byte {
+	result := append([]byte{}, data[:2]...)
+	for _, segment := range segments {
+		result = append(result, segment...)
+	}
+	return append(result, data[2:]...)
+}
+
+func TestStripJpegMetadata(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	xmp := jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>"+secret+"</x:xmpmeta>"))
+	iptc := jpegSegment(0xED, []byte("Photoshop 3.0\x00"+secret))
+
+	tests := []struct {
+		name            string
+		segments        [][]byte
+		wantOrientation int
+	}{
+		{name: "EXIF, XMP and IPTC", segments: [][]byte{exifSegment(6), xmp, iptc}, wantOrientation: 6},
+		{name: "EXIF without rotation", segments: [][]byte{exifSegment(1), xmp}, wantOrientation: 1},
+		{name: "no metadata", wantOrientation: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stripped := strip(t, withJpegSegments(encoded.Bytes(), test.segments...))
+			if bytes.Contains(stripped, []byte(secret)) {
+				t.Fatal("metadata was not removed")
+			}
+			if orientation := jpegOrientation(stripped); orientation != test.wantOrientation {
+				t.Errorf("orientation = %d, want %d", orientation, test.wantOrientation)
+			}
+			if test.wantOrientation == 1 && !bytes.Equal(stripped, encoded.Bytes()) {
+				t.Error("stripped image differs from the image without metadata")
+			}
+			if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+				t.Errorf("stripped image cannot be decoded: %v", err)
+			}
+		})
+	}
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStripPngMetadata(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	original := encoded.Bytes()
+	// Los chunks de metadatos van después de IHDR (firma de 8 bytes y chunk de 25)
+	ihdrEnd := len(pngSignature) + 25
+	var data []byte
+	data = append(data, original[:ihdrEnd]...)
+	data = append(data, pngChunk("tEXt", []byte("Comment\x00"+secret))...)
+	data = append(data, pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"+secret))...)
+	data = append(data, pngChunk("zTXt", []byte("Comment\x00\x00"+secret))...)
+	data = append(data, pngChunk("eXIf", exifSegment(6)[10:])...)
+	data = append(data, original[ihdrEnd:]...)
+
+	stripped := strip(t, data)
+	if !bytes.Equal(stripped, original) {
+		t.Fatalf("stripped image differs from the image without metadata")
+	}
+	if _, err := png.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("stripped image cannot be decoded: %v", err)
+	}
+}
+
+func riffChunk(chunkType string, data []byte) []byte {
+	chunk := append([]byte(chunkType), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func TestStripWebpMetadata(t *testing.T) {
+	// VP8X con los indicadores de EXIF (0x08) y XMP (0x04) y el de alfa (0x10), que se conserva
+	vp8x := riffChunk("VP8X", []byte{0x08 | 0x04 | 0x10, 0, 0, 0, 3, 0, 0, 2, 0, 0})
+	bitstream := riffChunk("VP8L", []byte("image data"))
+	exif := riffChunk("EXIF", []byte(secret))
+	xmp := riffChunk("XMP ", []byte("<x:xmpmeta>"+secret+"</x:xmpmeta>"))
+	body := bytes.Join([][]byte{[]byte("WEBP"), vp8x, bitstream, exif, xmp}, nil)
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	data = append(data, body...)
+
+	stripped := strip(t, data)
+	if len(stripped) != len(data) {
+		t.Fatalf("len = %d, want %d: the RIFF size must not change", len(stripped), len(data))
+	}
+	if bytes.Contains(stripped, []byte(secret)) || bytes.Contains(stripped, []byte("EXIF")) || bytes.Contains(stripped, []byte("XMP ")) {
+		t.Fatal("metadata was not removed")
+	}
+	if !bytes.Contains(stripped, bitstream) {
+		t.Error("image data changed")
+	}
+	if flags := stripped[20]; flags != 0x10 {
+		t.Errorf("VP8X flags = %#x, want 0x10", flags)
+	}
+	if count := bytes.Count(stripped, []byte("JUNK")); count != 2 {
+		t.Errorf("JUNK chunks = %d, want 2", count)
+	}
+}
+
+func TestStripMetadataCopiesUnknownFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "GIF", data: []byte("GIF89a" + secret)},
+		{name: "truncated PNG chunk", data: append(append([]byte{}, pngSignature...), 0, 0, 0)},
+		{name: "empty", data: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if stripped := strip(t, test.data); !bytes.Equal(stripped, test.data) {
+				t.Errorf("stripped = %q, want the file unchanged", stripped)
+			}
+		})
+	}
+}
+
+// heifBox crea una caja ISOBMFF; fullBox añade la versión y los indicadores
+func heifBox(boxType string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(len(data)+8))
+	box = append(box, boxType...)
+	return append(box, data...)
+}
+
+func fullBoxHeader(version byte) []byte {
+	return []byte{version, 0, 0, 0}
+}
+
+func infe(itemId uint16, itemType string, extra string) []byte {
+	payload := append(fullBoxHeader(2), binary.BigEndian.AppendUint16(nil, itemId)...)
+	payload = append(payload, 0, 0)
+	payload = append(payload, itemType...)
+	payload = append(payload, "\x00"+extra...)
+	return heifBox("infe", payload)
+}
+
+// heifItem es un elemento de la caja iloc: su ID y la posición de sus datos
+type heifItem struct {
+	id             uint16
+	offset, length uint32
+}
+
+// iloc crea una caja iloc versión 1 con desplazamientos y longitudes de 4 bytes
+func iloc(constructionMethod uint16, items ...heifItem) []byte {
+	payload := append(fullBoxHeader(1), 0x44, 0x00)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(len(items)))
+	for _, item := range items {
+		payload = binary.BigEndian.AppendUint16(payload, item.id)
+		payload = binary.BigEndian.AppendUint16(payload, constructionMethod)
+		payload = binary.BigEndian.AppendUint16(payload, 0)
+		payload = binary.BigEndian.AppendUint16(payload, 1)
+		payload = binary.BigEndian.AppendUint32(payload, item.offset)
+		payload = binary.BigEndian.AppendUint32(payload, item.length)
+	}
+	return heifBox("iloc", payload)
+}
+
+// avif crea un AVIF con una imagen (elemento 1), un Exif (2) y un XMP (3). Con inIdat los datos
+// van en la caja idat de meta; si no, en mdat después de meta, o antes si mdatFirst.
+func avif(inIdat bool, mdatFirst bool) (data []byte, imageData []byte) {
+	imageData = []byte("av01 image data")
+	exif := []byte("\x00\x00\x00\x06Exif\x00\x00MM" + secret)
+	xmp := []byte("<x:xmpmeta>" + secret + "</x:xmpmeta>")
+	ftyp := heifBox("ftyp", []byte("avif\x00\x00\x00\x00mif1avif"))
+	iinf := heifBox("iinf", fullBoxHeader(0), []byte{0, 3},
+		infe(1, "av01", ""),
+		infe(2, "Exif", ""),
+		infe(3, "mime", "application/rdf+xml\x00"))
+	hdlr := heifBox("hdlr", fullBoxHeader(0), []byte("\x00\x00\x00\x00pict\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"))
+	lengths := []uint32{uint32(len(imageData)), uint32(len(exif)), uint32(len(xmp))}
+	payload := bytes.Join([][]byte{imageData, exif, xmp}, nil)
+
+	buildMeta := func(base uint32, constructionMethod uint16, idat []byte) []byte {
+		items := make([]heifItem, 3)
+		offset := base
+		for i := range items {
+			items[i] = heifItem{id: uint16(i + 1), offset: offset, length: lengths[i]}
+			offset += lengths[i]
+		}
+		children := [][]byte{fullBoxHeader(0), hdlr, iinf, iloc(constructionMethod, items...)}
+		if idat != nil {
+			children = append(children, heifBox("idat", idat))
+		}
+		return heifBox("meta", children...)
+	}
+
+	switch {
+	case inIdat:
+		return append(ftyp, buildMeta(0, 1, payload)...), imageData
+	case mdatFirst:
+		mdat := heifBox("mdat", payload)
+		return bytes.Join([][]byte{ftyp, mdat, buildMeta(uint32(len(ftyp)+8), 0, nil)}, nil), imageData
+	default:
+		// La caja meta tiene el mismo tamaño con cualquier desplazamiento
+		metaSize := len(buildMeta(0, 0, nil))
+		mdatData := uint32(len(ftyp) + metaSize + 8)
+		return bytes.Join([][]byte{ftyp, buildMeta(mdatData, 0, nil), heifBox("mdat", payload)}, nil), imageData
+	}
+}
+
+func TestStripHeifMetadata(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		inIdat bool
+	}{
+		{name: "items in mdat"},
+		{name: "items in idat", inIdat: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, imageData := avif(test.inIdat, false)
+			stripped := strip(t, data)
+			if len(stripped) != len(data) {
+				t.Fatalf("len = %d, want %d: sizes and offsets must not change", len(stripped), len(data))
+			}
+			if bytes.Contains(stripped, []byte(secret)) {
+				t.Fatal("metadata was not removed")
+			}
+			if !bytes.Contains(stripped, imageData) {
+				t.Error("image data changed")
+			}
+			// Solo cambian los datos de los metadatos
+			for i := range data {
+				if data[i] != stripped[i] && stripped[i] != 0 {
+					t.Fatalf("byte %d changed to %#x", i, stripped[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStripHeifMetadataBeforeMetaBox(t *testing.T) {
+	data, _ := avif(false, true)
+	err := StripMetadata(io.Discard, bytes.NewReader(data))
+	if !errors.Is(err, ErrMetadataNotRemovable) {
+		t.Fatalf("StripMetadata() error = %v, want ErrMetadataNotRemovable", err)
+	}
+
+	stripper := NewMetadataStripper(bytes.NewReader(data))
+	if _, err := io.Copy(io.Discard, stripper); err == nil {
+		t.Error("reading the stripper did not fail")
+	}
+	if err := stripper.Close(); !errors.Is(err, ErrMetadataNotRemovable) {
+		t.Errorf("Close() error = %v, want ErrMetadataNotRemovable", err)
+	}
+}
+
+func TestMetadataStripperStreamsStrippedImage(t *testing.T) {
+	data, _ := avif(false, false)
+	stripper := NewMetadataStripper(bytes.NewReader(data))
+	streamed, err := io.ReadAll(stripper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stripper.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if !bytes.Equal(streamed, strip(t, data)) {
+		t.Error("streamed image differs from StripMetadata")
+	}
+}
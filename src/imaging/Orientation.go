@@ -0,0 +1,108 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// jpegOrientation lee la etiqueta Orientation (0x0112) del bloque EXIF de un JPEG.
+// Devuelve 1 (sin transformación) si la imagen no es JPEG o no tiene la etiqueta.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Se recorren los segmentos hasta el inicio de los datos de la imagen (SOS)
+	offset := 2
+	for offset+4 <= len(data) && data[offset] == 0xFF {
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		offset += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation busca la orientación en el primer IFD de una cabecera TIFF
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// applyOrientation gira o refleja la imagen según la orientación EXIF para que se vea derecha
+// sin los metadatos
+func applyOrientation(source image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return source
+	}
+
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// Las orientaciones 5 a 8 intercambian el ancho y el alto
+	transposed := orientation >= 5
+	destination := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if transposed {
+		destination = image.NewNRGBA(image.Rect(0, 0, height, width))
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // reflejo horizontal
+				dx, dy = width-1-x, y
+			case 3: // giro de 180°
+				dx, dy = width-1-x, height-1-y
+			case 4: // reflejo vertical
+				dx, dy = x, height-1-y
+			case 5: // transposición
+				dx, dy = y, x
+			case 6: // giro de 90° en sentido horario
+				dx, dy = height-1-y, x
+			case 7: // transversal
+				dx, dy = height-1-y, width-1-x
+			case 8: // giro de 90° en sentido antihorario
+				dx, dy = y, width-1-x
+			}
+			destination.Set(dx, dy, source.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return destination
+}
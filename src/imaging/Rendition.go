@@ -0,0 +1,129 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// RenditionContentType es el tipo MIME de todas las variantes generadas
+const RenditionContentType = "image/webp"
+
+var (
+	// ErrUnsupportedImage indica que el formato de la imagen original no se puede decodificar (por ejemplo AVIF)
+	ErrUnsupportedImage = errors.New("unsupported image format")
+	// ErrImageTooLarge indica que la imagen original tiene más píxeles de los permitidos
+	ErrImageTooLarge = errors.New("image has too many pixels")
+)
+
+// Rendition es una variante de una imagen: su nombre (thumbnail, medium, large...) y el tamaño
+// máximo en píxeles de su lado mayor
+type Rendition struct {
+	Name    string
+	MaxSize int
+}
+
+// RenditionImage es una variante generada, codificada en WebP
+type RenditionImage struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// ParseRenditions obtiene las variantes de una lista "nombre=tamaño" separada por comas,
+// por ejemplo "thumbnail=160,medium=640,large=1280"
+func ParseRenditions(spec string) ([]Rendition, error) {
+	var renditions []Rendition
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, size, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		maxSize, err := strconv.Atoi(strings.TrimSpace(size))
+		if !found || name == "" || strings.ContainsAny(name, "/.") || err != nil || maxSize <= 0 {
+			return nil, fmt.Errorf("invalid rendition %q: expected name=size", entry)
+		}
+		renditions = append(renditions, Rendition{Name: name, MaxSize: maxSize})
+	}
+	return renditions, nil
+}
+
+// RenditionKey devuelve la clave en el almacenamiento de una variante de la imagen fileName.
+// Las claves son predecibles: renditions/<imagen sin extensión>/<variante>.webp
+func RenditionKey(fileName string, name string) string {
+	return RenditionDir(fileName) + "/" + name + ".webp"
+}
+
+// RenditionDir devuelve el prefijo de las claves de las variantes de la imagen fileName, sin "/" final
+func RenditionDir(fileName string) string {
+	return "renditions/" + strings.TrimSuffix(fileName, path.Ext(fileName))
+}
+
+// GenerateRenditions decodifica la imagen, la orienta según su EXIF y genera cada variante en WebP
+// sin ampliarla. Las variantes no conservan los metadatos del original (EXIF, GPS, XMP).
+// Se rechazan las imágenes de más de maxPixels píxeles antes de decodificarlas.
+func GenerateRenditions(data []byte, renditions []Rendition, maxPixels int) ([]RenditionImage, error) {
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if imageConfig.Width*imageConfig.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	source = applyOrientation(source, jpegOrientation(data))
+
+	generated := make([]RenditionImage, 0, len(renditions))
+	for _, rendition := range renditions {
+		resized := resize(source, rendition.MaxSize)
+		var buffer bytes.Buffer
+		if err := nativewebp.Encode(&buffer, resized, nil); err != nil {
+			return nil, fmt.Errorf("encoding rendition %s: %w", rendition.Name, err)
+		}
+		generated = append(generated, RenditionImage{
+			Name:   rendition.Name,
+			Data:   buffer.Bytes(),
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+		})
+	}
+	return generated, nil
+}
+
+// resize reduce la imagen para que su lado mayor no supere maxSize, manteniendo la proporción
+func resize(source image.Image, maxSize int) image.Image {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			height = max(1, height*maxSize/width)
+			width = maxSize
+		} else {
+			width = max(1, width*maxSize/height)
+			height = maxSize
+		}
+	}
+
+	// Siempre se copia a un RGBA nuevo: así la variante no depende del formato del original
+	resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), source, bounds, draw.Src, nil)
+	return resized
+}
@@ -0,0 +1,66 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+)
+
+// CatalogMetricsJob recalcula periódicamente las métricas de negocio del catálogo
+type CatalogMetricsJob struct {
+	productRepository repository.ProductRepository
+	interval          time.Duration
+}
+
+// NewCatalogMetricsJob crea una nueva instancia de CatalogMetricsJob
+func NewCatalogMetricsJob(interval time.Duration) *CatalogMetricsJob {
+	return &CatalogMetricsJob{
+		productRepository: impl.NewProductRepositoryImpl(),
+		interval:          interval,
+	}
+}
+
+// Start ejecuta el job en segundo plano hasta que se cancele el contexto
+func (j *CatalogMetricsJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.Run(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run calcula el número de productos y de productos sin stock
+func (j *CatalogMetricsJob) Run(ctx context.Context) {
+	ctx, span := tracing.StartSpan(ctx, "CatalogMetricsJob.Run")
+	defer span.End()
+
+	products, err := j.productRepository.GetProducts(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error refreshing catalog metrics", "error", err)
+		return
+	}
+
+	outOfStock := 0
+	for _, p := range products {
+		if p.Stock <= 0 {
+			outOfStock++
+		}
+	}
+
+	metrics.CatalogProducts.Set(float64(len(products)))
+	metrics.CatalogProductsOutOfStock.Set(float64(outOfStock))
+}
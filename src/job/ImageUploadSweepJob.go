@@ -0,0 +1,61 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+)
+
+// ImageUploadSweepJob elimina las subidas directas de imágenes que vencieron sin confirmarse
+type ImageUploadSweepJob struct {
+	productImageService service.ProductImageService
+	interval            time.Duration
+}
+
+// NewImageUploadSweepJob crea una nueva instancia de ImageUploadSweepJob
+func NewImageUploadSweepJob(interval time.Duration) *ImageUploadSweepJob {
+	return &ImageUploadSweepJob{
+		productImageService: serviceImpl.NewProductImageServiceImpl(),
+		interval:            interval,
+	}
+}
+
+// Start ejecuta el job en segundo plano hasta que se cancele el contexto
+func (j *ImageUploadSweepJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.Run(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run elimina las subidas vencidas y sus objetos
+func (j *ImageUploadSweepJob) Run(ctx context.Context) {
+	ctx, span := tracing.StartSpan(ctx, "ImageUploadSweepJob.Run")
+	defer span.End()
+
+	swept, err := j.productImageService.SweepImageUploads(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sweeping image uploads", "swept", swept, "error", err)
+		return
+	}
+	if swept > 0 {
+		slog.InfoContext(ctx, "Swept unconfirmed image uploads", "swept", swept)
+	}
+}
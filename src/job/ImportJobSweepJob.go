@@ -0,0 +1,63 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+)
+
+// ImportJobSweepJob marca como fallidas las importaciones que quedaron sin terminar porque se
+// detuvo la instancia que las ejecutaba
+type ImportJobSweepJob struct {
+	productImportService service.ProductImportService
+	interval             time.Duration
+}
+
+// NewImportJobSweepJob crea una nueva instancia de ImportJobSweepJob
+func NewImportJobSweepJob(interval time.Duration) *ImportJobSweepJob {
+	return &ImportJobSweepJob{
+		productImportService: serviceImpl.NewProductImportServiceImpl(),
+		interval:             interval,
+	}
+}
+
+// Start ejecuta el job en segundo plano hasta que se cancele el contexto; la primera ejecución, al
+// arrancar, recoge los trabajos interrumpidos por el apagado anterior
+func (j *ImportJobSweepJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.Run(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run marca como fallidos los trabajos abandonados
+func (j *ImportJobSweepJob) Run(ctx context.Context) {
+	ctx, span := tracing.StartSpan(ctx, "ImportJobSweepJob.Run")
+	defer span.End()
+
+	failed, err := j.productImportService.FailStaleImportJobs(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sweeping import jobs", "failed", failed, "error", err)
+		return
+	}
+	if failed > 0 {
+		slog.InfoContext(ctx, "Marked stale import jobs as failed", "failed", failed)
+	}
+}
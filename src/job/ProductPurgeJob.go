@@ -0,0 +1,63 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+)
+
+// ProductPurgeJob elimina definitivamente los productos que llevan en la papelera más que el periodo de retención
+type ProductPurgeJob struct {
+	productService service.ProductService
+	interval       time.Duration
+	retention      time.Duration
+}
+
+// NewProductPurgeJob crea una nueva instancia de ProductPurgeJob
+func NewProductPurgeJob(interval time.Duration, retention time.Duration) *ProductPurgeJob {
+	return &ProductPurgeJob{
+		productService: serviceImpl.NewProductServiceImpl(),
+		interval:       interval,
+		retention:      retention,
+	}
+}
+
+// Start ejecuta el job en segundo plano hasta que se cancele el contexto
+func (j *ProductPurgeJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.Run(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run elimina los productos borrados antes de now - retention y sus imágenes
+func (j *ProductPurgeJob) Run(ctx context.Context) {
+	ctx, span := tracing.StartSpan(ctx, "ProductPurgeJob.Run")
+	defer span.End()
+
+	purged, err := j.productService.PurgeDeletedProducts(ctx, time.Now().Add(-j.retention))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error purging deleted products", "purged", purged, "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.InfoContext(ctx, "Purged deleted products", "purged", purged)
+	}
+}
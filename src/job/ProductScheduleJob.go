@@ -0,0 +1,61 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/service"
+	serviceImpl "github.com/ruiborda/ecommerce-product-service/src/service/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+)
+
+// ProductScheduleJob publica y archiva periódicamente los productos según sus fechas publishAt y unpublishAt
+type ProductScheduleJob struct {
+	productService service.ProductService
+	interval       time.Duration
+}
+
+// NewProductScheduleJob crea una nueva instancia de ProductScheduleJob
+func NewProductScheduleJob(interval time.Duration) *ProductScheduleJob {
+	return &ProductScheduleJob{
+		productService: serviceImpl.NewProductServiceImpl(),
+		interval:       interval,
+	}
+}
+
+// Start ejecuta el job en segundo plano hasta que se cancele el contexto
+func (j *ProductScheduleJob) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		j.Run(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run aplica las publicaciones y retiradas programadas que ya han vencido
+func (j *ProductScheduleJob) Run(ctx context.Context) {
+	ctx, span := tracing.StartSpan(ctx, "ProductScheduleJob.Run")
+	defer span.End()
+
+	changed, err := j.productService.ProcessScheduledStatusChanges(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error processing scheduled product status changes", "changed", changed, "error", err)
+		return
+	}
+	if changed > 0 {
+		slog.InfoContext(ctx, "Processed scheduled product status changes", "changed", changed)
+	}
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey int
+
+const (
+	requestKey contextKey = iota
+	subjectKey
+	productIdKey
+)
+
+// requestInfo contiene los datos de correlación de una petición HTTP
+type requestInfo struct {
+	requestId string
+	route     string
+}
+
+// Init configura el logger por defecto: JSON en modo release y texto en desarrollo,
+// con el nivel indicado en LOG_LEVEL (debug, info, warn, error).
+func Init() {
+	options := &slog.HandlerOptions{Level: parseLevel(config.GetEnv("LOG_LEVEL", "info"))}
+
+	var handler slog.Handler
+	format := config.GetEnv("LOG_FORMAT", "")
+	if format == "json" || (format == "" && gin.Mode() == gin.ReleaseMode) {
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, options)
+	}
+
+	slog.SetDefault(slog.New(&ContextHandler{Handler: handler}))
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequest guarda en el contexto el ID de la petición y la plantilla de ruta
+func WithRequest(ctx context.Context, requestId string, route string) context.Context {
+	return context.WithValue(ctx, requestKey, &requestInfo{
+		requestId: requestId,
+		route:     route,
+	})
+}
+
+// WithSubject guarda en el contexto el subject del usuario autenticado. Es un valor, no una
+// referencia a la petición, así que sigue siendo válido en los trabajos que continúan después
+// de responder.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey, subject)
+}
+
+// WithProductId guarda en el contexto el ID del producto sobre el que se opera
+func WithProductId(ctx context.Context, productId string) context.Context {
+	return context.WithValue(ctx, productIdKey, productId)
+}
+
+// RequestIdFromContext devuelve el ID de la petición guardado en el contexto
+func RequestIdFromContext(ctx context.Context) string {
+	if info, ok := ctx.Value(requestKey).(*requestInfo); ok {
+		return info.requestId
+	}
+	return ""
+}
+
+// ContextHandler añade a cada registro los datos de correlación presentes en el contexto
+type ContextHandler struct {
+	slog.Handler
+}
+
+// Handle añade request_id, route, user_subject, product_id y trace_id antes de delegar el registro
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info, ok := ctx.Value(requestKey).(*requestInfo); ok {
+		record.AddAttrs(
+			slog.String("request_id", info.requestId),
+			slog.String("route", info.route),
+		)
+	}
+	if subject, ok := ctx.Value(subjectKey).(string); ok && subject != "" {
+		record.AddAttrs(slog.String("user_subject", subject))
+	}
+	if productId, ok := ctx.Value(productIdKey).(string); ok && productId != "" {
+		record.AddAttrs(slog.String("product_id", productId))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs mantiene el ContextHandler al derivar loggers con atributos
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup mantiene el ContextHandler al derivar loggers con grupos
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
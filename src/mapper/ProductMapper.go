@@ -2,14 +2,25 @@
 package mapper
 
 import (
+	"context"
 	"time"
 
 	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
 	"github.com/ruiborda/ecommerce-product-service/src/model"
 )
 
-// ProductMapper struct
+// ProductMapper struct. ImageURL construye la URL completa de un archivo de imagen con el contexto
+// de la petición; si es nil o devuelve "", las respuestas solo incluyen el nombre del archivo
 type ProductMapper struct {
+	ImageURL func(ctx context.Context, fileName string) string
+}
+
+// imageURL devuelve la URL completa de fileName, o "" si no hay archivo o no se construyen URLs
+func (m *ProductMapper) imageURL(ctx context.Context, fileName string) string {
+	if fileName == "" || m.ImageURL == nil {
+		return ""
+	}
+	return m.ImageURL(ctx, fileName)
 }
 
 // CreateRequestToProduct convierte un CreateProductRequest a un modelo Product
@@ -49,7 +60,7 @@ func (m *ProductMapper) UpdateRequestToProduct(request *product.UpdateProductReq
 }
 
 // ProductToCreateResponse convierte un modelo Product a un CreateProductResponse
-func (m *ProductMapper) ProductToCreateResponse(model *model.Product) *product.CreateProductResponse {
+func (m *ProductMapper) ProductToCreateResponse(ctx context.Context, model *model.Product) *product.CreateProductResponse {
 	return &product.CreateProductResponse{
 		Id:          model.Id,
 		CategoryId:  model.CategoryId,
@@ -62,13 +73,19 @@ func (m *ProductMapper) ProductToCreateResponse(model *model.Product) *product.C
 		Sku:         model.Sku,
 		Stock:       model.Stock,
 		FileImage:   model.FileImage,
+		ImageUrl:    m.imageURL(ctx, model.FileImage),
+		Images:      m.ImagesToResponse(ctx, model.Gallery()),
 		CreatedAt:   model.CreatedAt,
 		UpdatedAt:   model.UpdatedAt,
+		Status:      string(model.EffectiveStatus()),
+		PublishAt:   model.PublishAt,
+		UnpublishAt: model.UnpublishAt,
+		ETag:        model.ETag(),
 	}
 }
 
 // ProductToGetByIdResponse convierte un modelo Product a un GetProductByIdResponse básico
-func (m *ProductMapper) ProductToGetByIdResponse(model *model.Product) *product.GetProductByIdResponse {
+func (m *ProductMapper) ProductToGetByIdResponse(ctx context.Context, model *model.Product) *product.GetProductByIdResponse {
 	return &product.GetProductByIdResponse{
 		Id:          model.Id,
 		CategoryId:  model.CategoryId,
@@ -81,14 +98,20 @@ func (m *ProductMapper) ProductToGetByIdResponse(model *model.Product) *product.
 		Sku:         model.Sku,
 		Stock:       model.Stock,
 		FileImage:   model.FileImage,
+		ImageUrl:    m.imageURL(ctx, model.FileImage),
+		Images:      m.ImagesToResponse(ctx, model.Gallery()),
 		CreatedAt:   model.CreatedAt,
 		UpdatedAt:   model.UpdatedAt,
+		Status:      string(model.EffectiveStatus()),
+		PublishAt:   model.PublishAt,
+		UnpublishAt: model.UnpublishAt,
+		ETag:        model.ETag(),
 		// CategoryName se agregará en el servicio
 	}
 }
 
 // ProductToUpdateResponse convierte un modelo Product a un UpdateProductResponse
-func (m *ProductMapper) ProductToUpdateResponse(model *model.Product) *product.UpdateProductResponse {
+func (m *ProductMapper) ProductToUpdateResponse(ctx context.Context, model *model.Product) *product.UpdateProductResponse {
 	return &product.UpdateProductResponse{
 		Id:          model.Id,
 		CategoryId:  model.CategoryId,
@@ -101,13 +124,19 @@ func (m *ProductMapper) ProductToUpdateResponse(model *model.Product) *product.U
 		Sku:         model.Sku,
 		Stock:       model.Stock,
 		FileImage:   model.FileImage,
+		ImageUrl:    m.imageURL(ctx, model.FileImage),
+		Images:      m.ImagesToResponse(ctx, model.Gallery()),
 		CreatedAt:   model.CreatedAt,
 		UpdatedAt:   model.UpdatedAt,
+		Status:      string(model.EffectiveStatus()),
+		PublishAt:   model.PublishAt,
+		UnpublishAt: model.UnpublishAt,
+		ETag:        model.ETag(),
 	}
 }
 
 // ProductToGetPaginatedResponse convierte un modelo Product a un GetProductsPaginatedResponse
-func (m *ProductMapper) ProductToGetPaginatedResponse(model *model.Product) *product.GetProductsPaginatedResponse {
+func (m *ProductMapper) ProductToGetPaginatedResponse(ctx context.Context, model *model.Product) *product.GetProductsPaginatedResponse {
 	return &product.GetProductsPaginatedResponse{
 		Id:          model.Id,
 		CategoryId:  model.CategoryId,
@@ -120,13 +149,17 @@ func (m *ProductMapper) ProductToGetPaginatedResponse(model *model.Product) *pro
 		Sku:         model.Sku,
 		Stock:       model.Stock,
 		FileImage:   model.FileImage,
+		ImageUrl:    m.imageURL(ctx, model.FileImage),
 		CreatedAt:   model.CreatedAt,
 		UpdatedAt:   model.UpdatedAt,
+		Status:      string(model.EffectiveStatus()),
+		PublishAt:   model.PublishAt,
+		UnpublishAt: model.UnpublishAt,
 	}
 }
 
 // ProductToSearchResponse convierte un modelo Product a un SearchProductsResponse básico
-func (m *ProductMapper) ProductToSearchResponse(model *model.Product) *product.SearchProductsResponse {
+func (m *ProductMapper) ProductToSearchResponse(ctx context.Context, model *model.Product) *product.SearchProductsResponse {
 	return &product.SearchProductsResponse{
 		Id:          model.Id,
 		CategoryId:  model.CategoryId,
@@ -139,8 +172,139 @@ func (m *ProductMapper) ProductToSearchResponse(model *model.Product) *product.S
 		Sku:         model.Sku,
 		Stock:       model.Stock,
 		FileImage:   model.FileImage,
+		ImageUrl:    m.imageURL(ctx, model.FileImage),
 		CreatedAt:   model.CreatedAt,
 		UpdatedAt:   model.UpdatedAt,
+		Status:      string(model.EffectiveStatus()),
+		PublishAt:   model.PublishAt,
+		UnpublishAt: model.UnpublishAt,
 		// CategoryName y AuthorName se agregarán en el servicio
 	}
 }
+
+// ProductToPublicResponse convierte un modelo Product a su vista pública
+func (m *ProductMapper) ProductToPublicResponse(ctx context.Context, model *model.Product) *product.PublicProductResponse {
+	return &product.PublicProductResponse{
+		Id:          model.Id,
+		CategoryId:  model.CategoryId,
+		Name:        model.Name,
+		Description: model.Description,
+		Price:       model.Price,
+		Currency:    model.Currency,
+		Discount:    model.Discount,
+		Sku:         model.Sku,
+		InStock:     model.Stock > 0,
+		FileImage:   model.FileImage,
+		ImageUrl:    m.imageURL(ctx, model.FileImage),
+		Images:      m.ImagesToResponse(ctx, model.Gallery()),
+	}
+}
+
+// ProductToPatchDocument convierte un modelo Product al documento sobre el que se aplica un PATCH
+func (m *ProductMapper) ProductToPatchDocument(model *model.Product) *product.PatchProductDocument {
+	return &product.PatchProductDocument{
+		CategoryId:  model.CategoryId,
+		Name:        model.Name,
+		Description: model.Description,
+		Price:       model.Price,
+		Currency:    model.Currency,
+		Discount:    model.Discount,
+		Sku:         model.Sku,
+		Stock:       model.Stock,
+		PublishAt:   model.PublishAt,
+		UnpublishAt: model.UnpublishAt,
+	}
+}
+
+// ApplyPatchDocument copia al modelo Product los campos del documento parcheado
+func (m *ProductMapper) ApplyPatchDocument(document *product.PatchProductDocument, model *model.Product) {
+	model.CategoryId = document.CategoryId
+	model.Name = document.Name
+	model.Description = document.Description
+	model.Price = document.Price
+	model.Currency = document.Currency
+	model.Discount = document.Discount
+	model.Sku = document.Sku
+	model.Stock = document.Stock
+	model.PublishAt = document.PublishAt
+	model.UnpublishAt = document.UnpublishAt
+	model.UpdatedAt = time.Now().Format(time.RFC3339)
+}
+
+// ImportJobToResponse convierte un trabajo de importación a un ImportProductsResponse
+func (m *ProductMapper) ImportJobToResponse(job *model.ImportJob) *product.ImportProductsResponse {
+	rowErrors := make([]product.ImportRowError, len(job.Errors))
+	for i, rowError := range job.Errors {
+		rowErrors[i] = product.ImportRowError{
+			Row:     rowError.Row,
+			Sku:     rowError.Sku,
+			Field:   rowError.Field,
+			Message: rowError.Message,
+		}
+	}
+	return &product.ImportProductsResponse{
+		JobId:           job.Id,
+		Status:          string(job.Status),
+		FileName:        job.FileName,
+		Format:          job.Format,
+		DryRun:          job.DryRun,
+		TotalRows:       job.TotalRows,
+		ProcessedRows:   job.ProcessedRows,
+		Created:         job.Created,
+		Updated:         job.Updated,
+		Failed:          job.Failed,
+		Errors:          rowErrors,
+		ErrorsTruncated: job.ErrorsTruncated,
+		Message:         job.Message,
+		CreatedAt:       job.CreatedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+}
+
+// ImagesToResponse convierte las imágenes de la galería a su respuesta; nunca devuelve nil
+func (m *ProductMapper) ImagesToResponse(ctx context.Context, images []model.ProductImage) []*product.ProductImageResponse {
+	responses := make([]*product.ProductImageResponse, 0, len(images))
+	for _, image := range images {
+		responses = append(responses, &product.ProductImageResponse{
+			Id:         image.Id,
+			FileName:   image.FileName,
+			Url:        m.imageURL(ctx, image.FileName),
+			Position:   image.Position,
+			Alt:        image.Alt,
+			Primary:    image.Primary,
+			Width:      image.Width,
+			Height:     image.Height,
+			Renditions: m.renditionsToResponse(ctx, image.Renditions),
+			CreatedAt:  image.CreatedAt,
+		})
+	}
+	return responses
+}
+
+// renditionsToResponse convierte las variantes de una imagen; nil si no tiene
+func (m *ProductMapper) renditionsToResponse(ctx context.Context, renditions map[string]model.ImageRendition) map[string]*product.ImageRenditionResponse {
+	if len(renditions) == 0 {
+		return nil
+	}
+	responses := make(map[string]*product.ImageRenditionResponse, len(renditions))
+	for name, rendition := range renditions {
+		responses[name] = &product.ImageRenditionResponse{
+			FileName: rendition.FileName,
+			Url:      m.imageURL(ctx, rendition.FileName),
+			Width:    rendition.Width,
+			Height:   rendition.Height,
+		}
+	}
+	return responses
+}
+
+// ProductToImagesResponse convierte la galería de un producto a un ProductImagesResponse
+func (m *ProductMapper) ProductToImagesResponse(ctx context.Context, model *model.Product) *product.ProductImagesResponse {
+	return &product.ProductImagesResponse{
+		ProductId: model.Id,
+		FileImage: model.FileImage,
+		ImageUrl:  m.imageURL(ctx, model.FileImage),
+		Images:    m.ImagesToResponse(ctx, model.Gallery()),
+		ETag:      model.ETag(),
+	}
+}
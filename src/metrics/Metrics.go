@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "product_service"
+
+var (
+	// HttpRequestsTotal cuenta las peticiones HTTP por método, plantilla de ruta y código de estado
+	HttpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HttpRequestDuration mide la latencia de las peticiones HTTP
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// FirestoreOperationDuration mide la latencia de las operaciones de Firestore por método de repositorio
+	FirestoreOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "firestore_operation_duration_seconds",
+		Help:      "Firestore operation latency by repository and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"repository", "method"})
+
+	// FirestoreOperationErrors cuenta los errores de Firestore por método de repositorio
+	FirestoreOperationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "firestore_operation_errors_total",
+		Help:      "Total number of failed Firestore operations by repository and method.",
+	}, []string{"repository", "method"})
+
+	// StorageOperationsTotal cuenta las operaciones sobre el almacenamiento de objetos (R2, S3, local o en memoria)
+	StorageOperationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "r2_operations_total",
+		Help:      "Total number of object storage operations by operation and result.",
+	}, []string{"operation", "result"})
+
+	// StorageBytesTotal cuenta los bytes transferidos al almacenamiento de objetos (R2, S3, local o en memoria)
+	StorageBytesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "r2_bytes_total",
+		Help:      "Total number of bytes handled by object storage operations.",
+	}, []string{"operation"})
+
+	// CatalogProducts indica el número de productos en el catálogo
+	CatalogProducts = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "catalog_products",
+		Help:      "Number of products in the catalog.",
+	})
+
+	// CatalogProductsOutOfStock indica el número de productos sin stock
+	CatalogProductsOutOfStock = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "catalog_products_out_of_stock",
+		Help:      "Number of products in the catalog with no stock.",
+	})
+
+	// StockAdjustmentsTotal cuenta los ajustes de stock; su rate() da la tasa de ajustes
+	StockAdjustmentsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stock_adjustments_total",
+		Help:      "Total number of product stock adjustments by direction.",
+	}, []string{"direction"})
+
+	// StockAdjustedUnitsTotal suma las unidades ajustadas en valor absoluto
+	StockAdjustedUnitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stock_adjusted_units_total",
+		Help:      "Total number of stock units adjusted by direction.",
+	}, []string{"direction"})
+)
+
+// TrackFirestore inicia la medición de una operación de Firestore y devuelve la función que la finaliza.
+// Un NotFound no se cuenta como error porque es un resultado esperado de las lecturas.
+func TrackFirestore(repository string, method string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		FirestoreOperationDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+		if err != nil && status.Code(err) != codes.NotFound {
+			FirestoreOperationErrors.WithLabelValues(repository, method).Inc()
+		}
+	}
+}
+
+// ObserveStorageOperation registra una operación del almacenamiento de objetos y los bytes transferidos
+func ObserveStorageOperation(operation string, bytes int64, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	StorageOperationsTotal.WithLabelValues(operation, result).Inc()
+	if err == nil && bytes > 0 {
+		StorageBytesTotal.WithLabelValues(operation).Add(float64(bytes))
+	}
+}
+
+// ObserveStockAdjustment registra un ajuste de stock según su dirección
+func ObserveStockAdjustment(quantity int) {
+	direction := "increase"
+	units := quantity
+	if quantity < 0 {
+		direction = "decrease"
+		units = -quantity
+	}
+	StockAdjustmentsTotal.WithLabelValues(direction).Inc()
+	StockAdjustedUnitsTotal.WithLabelValues(direction).Add(float64(units))
+}
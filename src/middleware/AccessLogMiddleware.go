@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog registra cada petición como log estructurado. Las peticiones con error
+// se registran siempre y las exitosas según la tasa de muestreo (0 a 1).
+func AccessLog(successSampleRate float64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 400 && rand.Float64() >= successSampleRate {
+			return
+		}
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("response_size", c.Writer.Size()),
+		}
+		if productId := c.Param("id"); productId != "" {
+			attrs = append(attrs, slog.String("product_id", productId))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl declara las respuestas como cacheables por navegadores y CDN durante maxAge.
+// Las respuestas de error sustituyen la cabecera por "no-store" en WriteProblem.
+func CacheControl(maxAge time.Duration) gin.HandlerFunc {
+	value := "no-store"
+	if maxAge > 0 {
+		value = fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	}
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", value)
+		c.Next()
+	}
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// problemTypePrefix identifica los tipos de problema de este servicio
+const problemTypePrefix = "urn:ecommerce-product-service:problem:"
+
+// statusClientClosedRequest es el código no estándar usado cuando el cliente cancela la petición
+const statusClientClosedRequest = 499
+
+// ErrorHandler convierte el último error añadido con c.Error en una respuesta application/problem+json
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		WriteProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// NoRoute responde con problem+json a las rutas inexistentes
+func NoRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		WriteProblem(c, exception.NotFound(exception.CodeRouteNotFound, "The requested resource does not exist"))
+	}
+}
+
+// WriteProblem escribe el error como problem+json y aborta la petición
+func WriteProblem(c *gin.Context, err error) {
+	statusCode, problem := toProblem(err)
+	problem.Instance = c.Request.URL.Path
+	problem.RequestId = logging.RequestIdFromContext(c.Request.Context())
+
+	if statusCode >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "Request failed", "status", statusCode, "code", problem.Code, "error", err)
+	}
+
+	// Los errores nunca se guardan en caché aunque la ruta declare Cache-Control
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", dto.ProblemDetailsContentType)
+	c.AbortWithStatusJSON(statusCode, problem)
+}
+
+// ProblemFor convierte un error en el cuerpo problem+json que le corresponde sin escribirlo.
+// Se usa para informar de los errores de cada operación de una petición masiva.
+func ProblemFor(err error) *dto.ProblemDetails {
+	_, problem := toProblem(err)
+	return problem
+}
+
+// toProblem traduce un error al código de estado y cuerpo problem+json correspondientes
+func toProblem(err error) (int, *dto.ProblemDetails) {
+	if domainError, ok := exception.As(err); ok {
+		// Un error de dependencia causado por el vencimiento del plazo se informa como timeout
+		if domainError.Kind != exception.KindUpstream || !isTimeout(err) {
+			statusCode := statusForKind(domainError.Kind)
+			return statusCode, &dto.ProblemDetails{
+				Type:   problemType(domainError.Code),
+				Title:  http.StatusText(statusCode),
+				Status: statusCode,
+				Detail: domainError.Message,
+				Code:   domainError.Code,
+				Errors: domainError.Fields,
+			}
+		}
+	}
+
+	switch {
+	case isTimeout(err):
+		return newProblem(http.StatusGatewayTimeout, exception.CodeTimeout, "The request timed out")
+	case errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled:
+		return newProblem(statusClientClosedRequest, exception.CodeRequestCancelled, "The request was cancelled by the client")
+	default:
+		return newProblem(http.StatusInternalServerError, exception.CodeInternalError, "An unexpected error occurred")
+	}
+}
+
+func newProblem(statusCode int, code string, detail string) (int, *dto.ProblemDetails) {
+	title := http.StatusText(statusCode)
+	if title == "" {
+		title = "Client Closed Request"
+	}
+	return statusCode, &dto.ProblemDetails{
+		Type:   problemType(code),
+		Title:  title,
+		Status: statusCode,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func statusForKind(kind exception.Kind) int {
+	switch kind {
+	case exception.KindNotFound:
+		return http.StatusNotFound
+	case exception.KindConflict:
+		return http.StatusConflict
+	case exception.KindValidation:
+		return http.StatusBadRequest
+	case exception.KindUnauthorized:
+		return http.StatusUnauthorized
+	case exception.KindForbidden:
+		return http.StatusForbidden
+	case exception.KindPrecondition:
+		return http.StatusPreconditionFailed
+	case exception.KindUnsupported:
+		return http.StatusUnsupportedMediaType
+	case exception.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case exception.KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	case exception.KindRateLimited:
+		return http.StatusTooManyRequests
+	case exception.KindUpstream:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func problemType(code string) string {
+	return problemTypePrefix + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
+
+// isTimeout indica si el error se debe a que venció el plazo de la petición
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+const (
+	// IdempotencyKeyHeader es la cabecera con la que el cliente identifica una petición que puede reintentar
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marca las respuestas repetidas a partir de una petición anterior
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength limita el tamaño de la clave enviada por el cliente
+	maxIdempotencyKeyLength = 255
+	// idempotencyStoreTimeout es el plazo para guardar la respuesta aunque la petición se haya cancelado
+	idempotencyStoreTimeout = 5 * time.Second
+	// idempotencySaveAttempts limita los intentos de guardar la respuesta de una petición que terminó con éxito
+	idempotencySaveAttempts = 3
+	// maxFingerprintedBodySize son los bytes iniciales del cuerpo que identifican la petición; es
+	// mayor que el límite de cualquier ruta, así que en la práctica se resume el cuerpo entero
+	maxFingerprintedBodySize = 64 << 20
+)
+
+// replayedHeaders son las cabeceras de la respuesta original que se repiten
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyConfig define cuánto se conserva una respuesta (TTL) y cuánto puede
+// permanecer reservada una clave cuya petición no ha terminado (LockTimeout)
+type IdempotencyConfig struct {
+	TTL         time.Duration
+	LockTimeout time.Duration
+}
+
+// LoadIdempotencyConfig carga la configuración desde IDEMPOTENCY_TTL e IDEMPOTENCY_LOCK_TIMEOUT
+func LoadIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL:         config.GetEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		LockTimeout: config.GetEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute),
+	}
+}
+
+// Idempotency hace seguros los reintentos de las peticiones con cabecera Idempotency-Key.
+// La primera respuesta correcta se guarda en el store y se repite ante cualquier reintento
+// con la misma clave y el mismo cuerpo; reutilizar la clave con otra petición responde 422.
+// Las peticiones que terminan con error liberan la clave para que el cliente pueda reintentar.
+// El cuerpo no se carga en memoria: se resume a medida que lo lee el controlador, de modo que las
+// subidas siguen llegando en streaming y los límites de tamaño de cada ruta se aplican igual.
+// Debe ir después de Authorize: las claves son independientes para cada usuario.
+func Idempotency(store repository.IdempotencyRepository, idempotencyConfig IdempotencyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			WriteProblem(c, exception.Validation(exception.CodeInvalidRequest, "The Idempotency-Key header must not exceed 255 characters"))
+			return
+		}
+
+		subject := ""
+		if principal := GetPrincipal(c); principal != nil {
+			subject = principal.Subject
+		}
+
+		ctx := c.Request.Context()
+		recordKey := idempotencyRecordKey(subject, idempotencyKey)
+
+		record, err := store.GetRecord(ctx, recordKey)
+		if err != nil {
+			WriteProblem(c, exception.DatabaseError(err))
+			return
+		}
+		if record != nil {
+			replayIdempotentResponse(c, record)
+			return
+		}
+
+		// Reservar la clave para que un reintento simultáneo no ejecute la petición dos veces. La
+		// huella se guarda al terminar, cuando el controlador ya ha leído el cuerpo.
+		now := time.Now()
+		record = &model.IdempotencyRecord{
+			Key:       recordKey,
+			CreatedAt: now,
+			ExpiresAt: now.Add(idempotencyConfig.LockTimeout),
+		}
+		if err := store.ReserveRecord(ctx, record); err != nil {
+			if errors.Is(err, repository.ErrIdempotencyKeyExists) {
+				WriteProblem(c, idempotencyKeyInUse())
+				return
+			}
+			WriteProblem(c, exception.DatabaseError(err))
+			return
+		}
+
+		fingerprint := newRequestFingerprint(c.Request.Method, c.Request.URL.Path)
+		body := fingerprint.wrap(c.Request.Body)
+		c.Request.Body = body
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+		defer cancel()
+
+		statusCode := recorder.Status()
+		if len(c.Errors) > 0 || statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+			if err := store.DeleteRecord(storeCtx, recordKey); err != nil {
+				slog.WarnContext(ctx, "Error releasing idempotency key", "error", err)
+			}
+			return
+		}
+
+		// El controlador puede no leer el final del cuerpo (espacios tras el JSON); se resume igual
+		// que en los reintentos, que lo leen entero
+		if err := fingerprint.drain(body); err != nil {
+			slog.WarnContext(ctx, "Error reading the rest of the request body", "error", err)
+		}
+		record.Fingerprint = fingerprint.sum()
+		record.StatusCode = statusCode
+		record.Body = recorder.body.Bytes()
+		record.Headers = map[string]string{}
+		for _, header := range replayedHeaders {
+			if value := recorder.Header().Get(header); value != "" {
+				record.Headers[header] = value
+			}
+		}
+		record.ExpiresAt = time.Now().Add(idempotencyConfig.TTL)
+		saveIdempotentResponse(storeCtx, store, record)
+	}
+}
+
+// saveIdempotentResponse guarda la respuesta de una petición que ya modificó los datos. Si no se
+// puede guardar (por ejemplo porque el cuerpo no cabe en el documento) se intenta guardar al menos
+// el registro sin la respuesta, para que los reintentos respondan 409 en lugar de repetir la
+// operación cuando caduque la reserva.
+func saveIdempotentResponse(ctx context.Context, store repository.IdempotencyRepository, record *model.IdempotencyRecord) {
+	var err error
+	for attempt := 1; attempt <= idempotencySaveAttempts; attempt++ {
+		if err = store.SaveRecord(ctx, record); err == nil {
+			return
+		}
+		if attempt < idempotencySaveAttempts {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+			}
+		}
+	}
+	slog.WarnContext(ctx, "Error saving idempotent response, saving it without the response", "error", err)
+
+	record.ResponseUnknown = true
+	record.Headers = nil
+	record.Body = nil
+	if err := store.SaveRecord(ctx, record); err != nil {
+		slog.ErrorContext(ctx, "Error saving idempotency record, retries with the same key will run the request again once the reservation expires", "error", err)
+	}
+}
+
+// replayIdempotentResponse repite la respuesta guardada o rechaza la petición si la original
+// todavía no ha terminado o la clave se usó con otra petición. El cuerpo solo se lee, sin
+// guardarlo, para comparar su huella.
+func replayIdempotentResponse(c *gin.Context, record *model.IdempotencyRecord) {
+	if !record.IsCompleted() {
+		WriteProblem(c, idempotencyKeyInUse())
+		return
+	}
+
+	fingerprint := newRequestFingerprint(c.Request.Method, c.Request.URL.Path)
+	if err := fingerprint.drain(fingerprint.wrap(c.Request.Body)); err != nil {
+		WriteProblem(c, exception.Validation(exception.CodeInvalidRequest, "The request body could not be read"))
+		return
+	}
+	if record.Fingerprint != fingerprint.sum() {
+		WriteProblem(c, exception.Unprocessable(exception.CodeIdempotencyKeyReused, "The Idempotency-Key was already used with a different request"))
+		return
+	}
+	if record.ResponseUnknown {
+		WriteProblem(c, exception.Conflict(exception.CodeIdempotencyResponseLost, "The request with this Idempotency-Key already succeeded but its response is not available; fetch the current state instead of retrying"))
+		return
+	}
+
+	for header, value := range record.Headers {
+		c.Header(header, value)
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(record.StatusCode)
+	_, _ = c.Writer.Write(record.Body)
+	c.Abort()
+}
+
+func idempotencyKeyInUse() error {
+	return exception.Conflict(exception.CodeIdempotencyKeyInUse, "A request with this Idempotency-Key is still being processed")
+}
+
+// idempotencyRecordKey aísla las claves de cada usuario y las convierte en un ID de documento válido
+func idempotencyRecordKey(subject string, idempotencyKey string) string {
+	sum := sha256.Sum256([]byte(subject + "\x00" + idempotencyKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint identifica la petición (método, ruta y cuerpo) asociada a una clave. Resume
+// los primeros maxFingerprintedBodySize bytes del cuerpo a medida que se leen.
+type requestFingerprint struct {
+	hash      hash.Hash
+	remaining int64
+}
+
+func newRequestFingerprint(method string, path string) *requestFingerprint {
+	fingerprint := &requestFingerprint{hash: sha256.New(), remaining: maxFingerprintedBodySize}
+	fingerprint.hash.Write([]byte(method + " " + path + "\n"))
+	return fingerprint
+}
+
+func (f *requestFingerprint) Write(data []byte) (int, error) {
+	if f.remaining > 0 {
+		f.hash.Write(data[:min(int64(len(data)), f.remaining)])
+		f.remaining -= int64(len(data))
+	}
+	return len(data), nil
+}
+
+// wrap devuelve el cuerpo de la petición que resume lo que se lee de body
+func (f *requestFingerprint) wrap(body io.ReadCloser) io.ReadCloser {
+	return fingerprintedBody{Reader: io.TeeReader(body, f), Closer: body}
+}
+
+// drain resume lo que queda del cuerpo sin leer más allá de lo que forma parte de la huella
+func (f *requestFingerprint) drain(body io.Reader) error {
+	if f.remaining <= 0 {
+		return nil
+	}
+	_, err := io.Copy(io.Discard, io.LimitReader(body, f.remaining))
+	return err
+}
+
+func (f *requestFingerprint) sum() string {
+	return hex.EncodeToString(f.hash.Sum(nil))
+}
+
+// fingerprintedBody es el cuerpo de la petición leído a través de requestFingerprint
+type fingerprintedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// responseRecorder copia el cuerpo de la respuesta mientras se envía al cliente
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
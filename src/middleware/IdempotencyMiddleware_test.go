@@ -0,0 +1,238 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	repositoryImpl "github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+)
+
+const idempotentPath = "/api/v1/products"
+
+// idempotentRouter registra una ruta con Idempotency cuyo controlador cuenta las ejecuciones y
+// responde 201 con el número de ejecución, o 502 si el cuerpo es "error". La cabecera X-Subject
+// hace de usuario autenticado. Si entered no es nil, las peticiones con cuerpo "slow" avisan por
+// entered y esperan a que se cierre release.
+func idempotentRouter(store repository.IdempotencyRepository, executions *atomic.Int32, entered chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST(idempotentPath,
+		func(c *gin.Context) {
+			c.Set(middleware.PrincipalKey, &auth.Principal{Subject: c.GetHeader("X-Subject")})
+		},
+		middleware.Idempotency(store, middleware.IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute}),
+		func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			execution := executions.Add(1)
+			switch string(body) {
+			case "error":
+				middleware.WriteProblem(c, exception.DatabaseError(errors.New("write failed")))
+				return
+			case "slow":
+				if entered != nil {
+					entered <- struct{}{}
+					<-release
+				}
+			}
+			c.Header("ETag", `"v1"`)
+			c.JSON(http.StatusCreated, gin.H{"execution": execution})
+		},
+	)
+	return router
+}
+
+// idempotentRequest es una petición de las pruebas y la respuesta esperada
+type idempotentRequest struct {
+	subject      string
+	key          string
+	body         string
+	wantStatus   int
+	wantCode     string
+	wantReplayed bool
+}
+
+func sendIdempotent(router *gin.Engine, request idempotentRequest) *httptest.ResponseRecorder {
+	httpRequest := httptest.NewRequest(http.MethodPost, idempotentPath, strings.NewReader(request.body))
+	httpRequest.Header.Set("X-Subject", request.subject)
+	if request.key != "" {
+		httpRequest.Header.Set(middleware.IdempotencyKeyHeader, request.key)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httpRequest)
+	return recorder
+}
+
+// assertIdempotentResponse comprueba el código, el problem+json y la cabecera Idempotent-Replayed
+func assertIdempotentResponse(t *testing.T, recorder *httptest.ResponseRecorder, request idempotentRequest) {
+	t.Helper()
+	if recorder.Code != request.wantStatus {
+		t.Fatalf("got %d (%s), want %d", recorder.Code, recorder.Body, request.wantStatus)
+	}
+	if request.wantCode != "" {
+		var problem dto.ProblemDetails
+		if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil || problem.Code != request.wantCode {
+			t.Fatalf("problem code = %q (%s), want %q", problem.Code, recorder.Body, request.wantCode)
+		}
+	}
+	if replayed := recorder.Header().Get(middleware.IdempotentReplayedHeader) == "true"; replayed != request.wantReplayed {
+		t.Errorf("replayed = %v, want %v", replayed, request.wantReplayed)
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name           string
+		requests       []idempotentRequest
+		wantExecutions int32
+	}{
+		{
+			name: "replays the response to the same key and body",
+			requests: []idempotentRequest{
+				{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+				{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated, wantReplayed: true},
+				{subject: "user-1", key: " k1 ", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantExecutions: 1,
+		},
+		{
+			name: "rejects the same key with a different body",
+			requests: []idempotentRequest{
+				{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+				{subject: "user-1", key: "k1", body: `{"name":"Cup"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: exception.CodeIdempotencyKeyReused},
+			},
+			wantExecutions: 1,
+		},
+		{
+			name: "deletes the record after an error",
+			requests: []idempotentRequest{
+				{subject: "user-1", key: "k1", body: "error", wantStatus: http.StatusBadGateway, wantCode: exception.CodeDatabaseError},
+				{subject: "user-1", key: "k1", body: "error", wantStatus: http.StatusBadGateway, wantCode: exception.CodeDatabaseError},
+				{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+			},
+			wantExecutions: 3,
+		},
+		{
+			name: "scopes keys per subject",
+			requests: []idempotentRequest{
+				{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+				{subject: "user-2", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+				{subject: "user-2", key: "k1", body: `{"name":"Cup"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: exception.CodeIdempotencyKeyReused},
+				{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantExecutions: 2,
+		},
+		{
+			name: "runs every request without a key",
+			requests: []idempotentRequest{
+				{subject: "user-1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+				{subject: "user-1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+			},
+			wantExecutions: 2,
+		},
+		{
+			name: "rejects keys longer than 255 characters",
+			requests: []idempotentRequest{
+				{subject: "user-1", key: strings.Repeat("k", 256), body: `{"name":"Mug"}`, wantStatus: http.StatusBadRequest, wantCode: exception.CodeInvalidRequest},
+			},
+			wantExecutions: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var executions atomic.Int32
+			router := idempotentRouter(repositoryImpl.NewInMemoryIdempotencyRepository(), &executions, nil, nil)
+
+			var first *httptest.ResponseRecorder
+			for _, request := range tt.requests {
+				recorder := sendIdempotent(router, request)
+				assertIdempotentResponse(t, recorder, request)
+				if first == nil && recorder.Code == http.StatusCreated {
+					first = recorder
+				}
+				if request.wantReplayed {
+					if recorder.Body.String() != first.Body.String() || recorder.Header().Get("ETag") != first.Header().Get("ETag") {
+						t.Errorf("replayed %s %q, want %s %q", recorder.Body, recorder.Header().Get("ETag"), first.Body, first.Header().Get("ETag"))
+					}
+				}
+			}
+			if got := executions.Load(); got != tt.wantExecutions {
+				t.Errorf("executions = %d, want %d", got, tt.wantExecutions)
+			}
+		})
+	}
+}
+
+func TestIdempotencyRejectsRequestsInFlight(t *testing.T) {
+	var executions atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := idempotentRouter(repositoryImpl.NewInMemoryIdempotencyRepository(), &executions, entered, release)
+
+	original := idempotentRequest{subject: "user-1", key: "k1", body: "slow", wantStatus: http.StatusCreated}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- sendIdempotent(router, original) }()
+	<-entered
+
+	retries := []idempotentRequest{
+		{subject: "user-1", key: "k1", body: "slow", wantStatus: http.StatusConflict, wantCode: exception.CodeIdempotencyKeyInUse},
+		// Mientras no termina la original no se conoce su huella, así que tampoco se compara
+		{subject: "user-1", key: "k1", body: `{"name":"Cup"}`, wantStatus: http.StatusConflict, wantCode: exception.CodeIdempotencyKeyInUse},
+	}
+	for _, retry := range retries {
+		assertIdempotentResponse(t, sendIdempotent(router, retry), retry)
+	}
+
+	close(release)
+	assertIdempotentResponse(t, <-done, original)
+	replay := idempotentRequest{subject: "user-1", key: "k1", body: "slow", wantStatus: http.StatusCreated, wantReplayed: true}
+	assertIdempotentResponse(t, sendIdempotent(router, replay), replay)
+	if got := executions.Load(); got != 1 {
+		t.Errorf("executions = %d, want 1", got)
+	}
+}
+
+// responseLostStore no puede guardar respuestas, solo los registros marcados con ResponseUnknown
+type responseLostStore struct {
+	*repositoryImpl.InMemoryIdempotencyRepository
+}
+
+func (s responseLostStore) SaveRecord(ctx context.Context, record *model.IdempotencyRecord) error {
+	if !record.ResponseUnknown {
+		return errors.New("document too large")
+	}
+	return s.InMemoryIdempotencyRepository.SaveRecord(ctx, record)
+}
+
+func TestIdempotencyRejectsRetriesWhenTheResponseIsUnknown(t *testing.T) {
+	var executions atomic.Int32
+	router := idempotentRouter(responseLostStore{repositoryImpl.NewInMemoryIdempotencyRepository()}, &executions, nil, nil)
+
+	requests := []idempotentRequest{
+		// El cliente recibe la respuesta aunque no se haya podido guardar
+		{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusCreated},
+		{subject: "user-1", key: "k1", body: `{"name":"Mug"}`, wantStatus: http.StatusConflict, wantCode: exception.CodeIdempotencyResponseLost},
+		{subject: "user-1", key: "k1", body: `{"name":"Cup"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: exception.CodeIdempotencyKeyReused},
+	}
+	for _, request := range requests {
+		assertIdempotentResponse(t, sendIdempotent(router, request), request)
+	}
+	if got := executions.Load(); got != 1 {
+		t.Errorf("executions = %d, want 1", got)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/go-jwt/src/domain/entity"
+)
+
+// JwtClaimsKey es la clave con la que el middleware RequireJWT guarda los claims en el contexto de gin
+const JwtClaimsKey = "jwtClaims"
+
+// GetJwtClaims obtiene los claims del token JWT validado por RequireJWT
+func GetJwtClaims(c *gin.Context) (*entity.JWTClaims[*auth.JwtPrivateClaims], bool) {
+	claimsValue, exists := c.Get(JwtClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := claimsValue.(*entity.JWTClaims[*auth.JwtPrivateClaims])
+	if !ok || claims == nil {
+		return nil, false
+	}
+	return claims, true
+}
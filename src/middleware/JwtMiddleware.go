@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/go-jwt/src/application/ports/input"
+	"github.com/ruiborda/go-jwt/src/domain/entity"
+	inputAdapter "github.com/ruiborda/go-jwt/src/infrastructure/adapters/input"
+)
+
+// RequireJWT verifica el token Bearer de la cabecera Authorization con JWT_SECRET, igual que el
+// middleware del servicio de usuarios, y guarda sus claims en JwtClaimsKey. A diferencia de aquel,
+// los errores se responden en problem+json: 401 UNAUTHENTICATED si falta el token o no es válido.
+func RequireJWT() gin.HandlerFunc {
+	jwtSecret := []byte(config.GetEnv("JWT_SECRET", ""))
+
+	return func(c *gin.Context) {
+		if len(jwtSecret) == 0 {
+			WriteProblem(c, errors.New("JWT_SECRET environment variable is not set"))
+			return
+		}
+
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			WriteProblem(c, exception.Unauthorized(exception.CodeUnauthenticated, "The Authorization header is required"))
+			return
+		}
+		scheme, token, found := strings.Cut(authHeader, " ")
+		if !found || scheme != "Bearer" || token == "" {
+			WriteProblem(c, exception.Unauthorized(exception.CodeUnauthenticated, "The Authorization header must be a Bearer token"))
+			return
+		}
+
+		// El verificador guarda el token que comprueba: no se puede compartir entre peticiones
+		verifier := inputAdapter.NewJwtInputAdapter[*auth.JwtPrivateClaims](input.NewJWTHS256InputPort[*auth.JwtPrivateClaims](jwtSecret))
+		if err := verifier.VerifyToken(token); err != nil {
+			WriteProblem(c, exception.Unauthorized(exception.CodeUnauthenticated, "The access token is invalid or expired"))
+			return
+		}
+		jwt := entity.NewJwtFromToken[*auth.JwtPrivateClaims](token)
+		if jwt == nil {
+			WriteProblem(c, exception.Unauthorized(exception.CodeUnauthenticated, "The access token is invalid or expired"))
+			return
+		}
+
+		c.Set(JwtClaimsKey, jwt.Claims)
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+)
+
+// Metrics registra el número de peticiones y su latencia por plantilla de ruta y código de estado
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// Usar la plantilla de la ruta (/api/v1/products/:id) para evitar cardinalidad ilimitada
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		statusCode := strconv.Itoa(c.Writer.Status())
+
+		metrics.HttpRequestsTotal.WithLabelValues(c.Request.Method, route, statusCode).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(c.Request.Method, route, statusCode).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/logging"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+// PrincipalKey es la clave con la que Authorize guarda el usuario autenticado en el contexto de gin
+const PrincipalKey = "principal"
+
+// defaultRoutePermissions es el permiso requerido por defecto en cada ruta ("METHOD /plantilla/de/ruta")
+var defaultRoutePermissions = map[string]model.Permission{
+	"POST /api/v1/products":                                      model.ProductWrite,
+	"POST /api/v1/products/bulk":                                 model.ProductWrite,
+	"POST /api/v1/products/import":                               model.ProductWrite,
+	"GET /api/v1/products/import/jobs/:id":                       model.ProductWrite,
+	"GET /api/v1/products/export":                                model.CatalogRead,
+	"GET /api/v1/products/:id":                                   model.CatalogRead,
+	"PUT /api/v1/products/:id":                                   model.ProductWrite,
+	"PATCH /api/v1/products/:id":                                 model.ProductWrite,
+	"DELETE /api/v1/products/:id":                                model.ProductDelete,
+	"POST /api/v1/products/:id/restore":                          model.ProductDelete,
+	"GET /api/v1/products/pages":                                 model.CatalogRead,
+	"GET /api/v1/products/mine":                                  model.ProductWrite,
+	"PUT /api/v1/products/:id/stock":                             model.StockAdjust,
+	"PUT /api/v1/products/:id/status":                            model.ProductWrite,
+	"GET /api/v1/products/:id/images":                            model.CatalogRead,
+	"POST /api/v1/products/:id/images":                           model.ProductWrite,
+	"PUT /api/v1/products/:id/images":                            model.ProductWrite,
+	"PATCH /api/v1/products/:id/images/:imageId":                 model.ProductWrite,
+	"DELETE /api/v1/products/:id/images/:imageId":                model.ProductWrite,
+	"POST /api/v1/products/:id/images/uploads":                   model.ProductWrite,
+	"POST /api/v1/products/:id/images/uploads/:uploadId/confirm": model.ProductWrite,
+	"POST /api/v1/admin/storage/reconcile":                       model.ProductDelete,
+	"GET /api/v1/products/search":                                model.CatalogRead,
+	"POST /api/v1/categories":                                    model.CategoryManage,
+	"PUT /api/v1/categories":                                     model.CategoryManage,
+	"GET /api/v1/categories":                                     model.CatalogRead,
+}
+
+// PermissionConfig define qué IDs del claim permissionIds conceden cada permiso,
+// qué permiso requiere cada ruta y qué roles del claim roles son de administrador
+type PermissionConfig struct {
+	Ids        map[model.Permission][]int
+	Routes     map[string]model.Permission
+	AdminRoles []string
+}
+
+// LoadPermissionConfig carga la configuración de permisos desde PERMISSION_IDS y ROUTE_PERMISSIONS.
+// PERMISSION_IDS tiene el formato "catalog.read=602|605,product.write=601" y sustituye los IDs
+// por defecto de los permisos indicados. ROUTE_PERMISSIONS tiene el formato
+// "GET /api/v1/products/:id=catalog.read" y sustituye el permiso por defecto de las rutas indicadas.
+// ADMIN_ROLES es la lista de roles de administrador separados por comas.
+func LoadPermissionConfig() PermissionConfig {
+	permissionConfig := PermissionConfig{
+		Ids:        map[model.Permission][]int{},
+		Routes:     map[string]model.Permission{},
+		AdminRoles: splitEntries(config.GetEnv("ADMIN_ROLES", "ADMIN")),
+	}
+	for permission, ids := range model.DefaultPermissionIds {
+		permissionConfig.Ids[permission] = ids
+	}
+	for route, permission := range defaultRoutePermissions {
+		permissionConfig.Routes[route] = permission
+	}
+
+	for _, entry := range splitEntries(config.GetEnv("PERMISSION_IDS", "")) {
+		name, value, found := strings.Cut(entry, "=")
+		permission := model.Permission(strings.TrimSpace(name))
+		if !found || !permission.IsValid() {
+			slog.Warn("Invalid PERMISSION_IDS entry, ignoring", "entry", entry)
+			continue
+		}
+		var ids []int
+		for _, rawId := range strings.Split(value, "|") {
+			id, err := strconv.Atoi(strings.TrimSpace(rawId))
+			if err != nil {
+				slog.Warn("Invalid PERMISSION_IDS id, ignoring", "entry", entry)
+				continue
+			}
+			ids = append(ids, id)
+		}
+		permissionConfig.Ids[permission] = ids
+	}
+
+	for _, entry := range splitEntries(config.GetEnv("ROUTE_PERMISSIONS", "")) {
+		route, name, found := strings.Cut(entry, "=")
+		permission := model.Permission(strings.TrimSpace(name))
+		if !found || !permission.IsValid() {
+			slog.Warn("Invalid ROUTE_PERMISSIONS entry, ignoring", "entry", entry)
+			continue
+		}
+		permissionConfig.Routes[strings.Join(strings.Fields(route), " ")] = permission
+	}
+
+	return permissionConfig
+}
+
+// For devuelve el permiso requerido para el método y la plantilla de ruta indicados
+func (pc PermissionConfig) For(method string, route string) (model.Permission, bool) {
+	permission, ok := pc.Routes[method+" "+route]
+	return permission, ok
+}
+
+// PermissionsFor traduce los IDs del claim permissionIds a los permisos que conceden
+func (pc PermissionConfig) PermissionsFor(permissionIds []int) []model.Permission {
+	granted := map[int]bool{}
+	for _, id := range permissionIds {
+		granted[id] = true
+	}
+
+	var permissions []model.Permission
+	for _, permission := range model.Permissions {
+		for _, id := range pc.Ids[permission] {
+			if granted[id] {
+				permissions = append(permissions, permission)
+				break
+			}
+		}
+	}
+	return permissions
+}
+
+// IsAdmin indica si alguno de los roles es un rol de administrador
+func (pc PermissionConfig) IsAdmin(roles []string) bool {
+	for _, role := range roles {
+		for _, adminRole := range pc.AdminRoles {
+			if strings.EqualFold(role, adminRole) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize comprueba que el usuario autenticado por RequireJWT tiene el permiso configurado
+// para la ruta y guarda su Principal en el contexto. Las rutas sin permiso configurado se deniegan.
+func Authorize(permissionConfig PermissionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetJwtClaims(c)
+		if !ok || claims.RegisteredClaims == nil || claims.RegisteredClaims.Subject == "" {
+			WriteProblem(c, exception.Unauthorized(exception.CodeUnauthenticated, "A valid access token is required"))
+			return
+		}
+
+		principal := &auth.Principal{Subject: claims.RegisteredClaims.Subject}
+		if claims.PrivateClaims != nil {
+			principal.Email = claims.PrivateClaims.Email
+			principal.Roles = claims.PrivateClaims.Roles
+			principal.Permissions = permissionConfig.PermissionsFor(claims.PrivateClaims.PermissionIds)
+			principal.Admin = permissionConfig.IsAdmin(claims.PrivateClaims.Roles)
+		}
+		c.Set(PrincipalKey, principal)
+		c.Request = c.Request.WithContext(logging.WithSubject(c.Request.Context(), principal.Subject))
+
+		required, ok := permissionConfig.For(c.Request.Method, c.FullPath())
+		if !ok {
+			slog.WarnContext(c.Request.Context(), "Access denied: route has no permission configured", "method", c.Request.Method)
+			WriteProblem(c, exception.Forbidden(exception.CodeForbidden, "You don't have permission to access this resource"))
+			return
+		}
+		if !principal.HasPermission(required) {
+			slog.InfoContext(c.Request.Context(), "Access denied: missing required permission", "required_permission", string(required))
+			WriteProblem(c, exception.Forbidden(exception.CodeForbidden, "You don't have permission to access this resource"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetPrincipal obtiene el usuario autenticado guardado por Authorize
+func GetPrincipal(c *gin.Context) *auth.Principal {
+	principal, _ := c.Get(PrincipalKey)
+	if p, ok := principal.(*auth.Principal); ok {
+		return p
+	}
+	return nil
+}
+
+func splitEntries(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
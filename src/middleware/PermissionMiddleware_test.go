@@ -0,0 +1,293 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/database"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/route"
+	userModel "github.com/ruiborda/ecommerce-user-service/src/model"
+	"github.com/ruiborda/go-jwt/src/domain/entity"
+)
+
+// apiRoutes devuelve las rutas que registra ApiRouter. Los repositorios solo crean referencias a
+// las colecciones al construirse, así que basta un cliente de Firestore contra un emulador inexistente.
+func apiRoutes(t *testing.T) gin.RoutesInfo {
+	t.Helper()
+	t.Setenv("FIRESTORE_EMULATOR_HOST", "127.0.0.1:1")
+	t.Setenv("STORAGE_BACKEND", "memory")
+	t.Setenv("IDEMPOTENCY_STORE", "memory")
+	client, err := firestore.NewClient(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Client = client
+	t.Cleanup(func() {
+		_ = client.Close()
+		database.Client = nil
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	route.ApiRouter(router)
+	return router.Routes()
+}
+
+// authorizeRoute ejecuta Authorize en la ruta con los claims indicados (nil sin token) y devuelve
+// el código de respuesta y el Principal guardado; 204 indica que se permitió el acceso
+func authorizeRoute(permissionConfig middleware.PermissionConfig, method string, path string, claims *entity.JWTClaims[*auth.JwtPrivateClaims]) (int, *auth.Principal) {
+	var principal *auth.Principal
+	router := gin.New()
+	router.Handle(method, path,
+		func(c *gin.Context) {
+			if claims != nil {
+				c.Set(middleware.JwtClaimsKey, claims)
+			}
+		},
+		middleware.Authorize(permissionConfig),
+		func(c *gin.Context) {
+			principal = middleware.GetPrincipal(c)
+			c.Status(http.StatusNoContent)
+		},
+	)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder.Code, principal
+}
+
+func tokenClaims(roles []string, permissionIds ...int) *entity.JWTClaims[*auth.JwtPrivateClaims] {
+	return &entity.JWTClaims[*auth.JwtPrivateClaims]{
+		RegisteredClaims: &entity.RegisteredClaims{Subject: "user-1"},
+		PrivateClaims:    &auth.JwtPrivateClaims{Email: "user@example.com", Roles: roles, PermissionIds: permissionIds},
+	}
+}
+
+func TestAuthorizeEveryApiRoute(t *testing.T) {
+	routes := apiRoutes(t)
+	if len(routes) == 0 {
+		t.Fatal("ApiRouter registered no routes")
+	}
+	permissionConfig := middleware.LoadPermissionConfig()
+
+	for _, routeInfo := range routes {
+		required, ok := permissionConfig.For(routeInfo.Method, routeInfo.Path)
+		if !ok {
+			t.Errorf("%s %s has no permission configured and would always be denied", routeInfo.Method, routeInfo.Path)
+			continue
+		}
+
+		for _, permission := range model.Permissions {
+			t.Run(routeInfo.Method+" "+routeInfo.Path+" with "+string(permission), func(t *testing.T) {
+				for _, id := range model.DefaultPermissionIds[permission] {
+					// Algunos IDs conceden varios permisos (price.manage comparte los de product.write)
+					want := http.StatusForbidden
+					if slices.Contains(permissionConfig.PermissionsFor([]int{id}), required) {
+						want = http.StatusNoContent
+					}
+					if permission == required && want != http.StatusNoContent {
+						t.Fatalf("permission id %d does not grant %s", id, permission)
+					}
+					if code, _ := authorizeRoute(permissionConfig, routeInfo.Method, routeInfo.Path, tokenClaims(nil, id)); code != want {
+						t.Errorf("permission id %d: got %d, want %d", id, code, want)
+					}
+				}
+			})
+		}
+
+		t.Run(routeInfo.Method+" "+routeInfo.Path+" without permissions", func(t *testing.T) {
+			if code, _ := authorizeRoute(permissionConfig, routeInfo.Method, routeInfo.Path, tokenClaims(nil)); code != http.StatusForbidden {
+				t.Errorf("got %d, want %d", code, http.StatusForbidden)
+			}
+		})
+
+		t.Run(routeInfo.Method+" "+routeInfo.Path+" without token", func(t *testing.T) {
+			if code, _ := authorizeRoute(permissionConfig, routeInfo.Method, routeInfo.Path, nil); code != http.StatusUnauthorized {
+				t.Errorf("got %d, want %d", code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+// Los permisos sin equivalente en el servicio de usuarios se conceden por defecto a los tokens que
+// ya tenían acceso antes de existir, para que el despliegue no retire permisos
+func TestDefaultPermissionIdsKeepExistingTokens(t *testing.T) {
+	tests := []struct {
+		name          string
+		permissionIds string
+		tokenIds      []int
+		permission    model.Permission
+		want          bool
+	}{
+		{name: "CreateProduct grants price.manage", tokenIds: []int{userModel.CreateProduct}, permission: model.PriceManage, want: true},
+		{name: "UpdateProduct grants price.manage", tokenIds: []int{userModel.UpdateProduct}, permission: model.PriceManage, want: true},
+		{name: "CreateUser grants category.manage", tokenIds: []int{userModel.CreateUser}, permission: model.CategoryManage, want: true},
+		{name: "product ids do not grant category.manage", tokenIds: model.DefaultPermissionIds[model.ProductWrite], permission: model.CategoryManage, want: false},
+		{name: "catalog ids do not grant price.manage", tokenIds: model.DefaultPermissionIds[model.CatalogRead], permission: model.PriceManage, want: false},
+		{name: "PERMISSION_IDS narrows price.manage", permissionIds: "price.manage=900", tokenIds: model.DefaultPermissionIds[model.ProductWrite], permission: model.PriceManage, want: false},
+		{name: "PERMISSION_IDS grants the narrowed price.manage", permissionIds: "price.manage=900", tokenIds: []int{900}, permission: model.PriceManage, want: true},
+		{name: "PERMISSION_IDS narrows category.manage", permissionIds: "category.manage=901", tokenIds: []int{userModel.CreateUser}, permission: model.CategoryManage, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PERMISSION_IDS", tt.permissionIds)
+			permissionConfig := middleware.LoadPermissionConfig()
+			if got := slices.Contains(permissionConfig.PermissionsFor(tt.tokenIds), tt.permission); got != tt.want {
+				t.Errorf("grants %s: got %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeDeniesRoutesWithoutPermission(t *testing.T) {
+	permissionConfig := middleware.LoadPermissionConfig()
+	allIds := []int{}
+	for _, ids := range model.DefaultPermissionIds {
+		allIds = append(allIds, ids...)
+	}
+
+	code, _ := authorizeRoute(permissionConfig, http.MethodGet, "/api/v1/unmapped", tokenClaims([]string{"ADMIN"}, allIds...))
+	if code != http.StatusForbidden {
+		t.Errorf("got %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestLoadPermissionConfigOverrides(t *testing.T) {
+	tests := []struct {
+		name             string
+		permissionIds    string
+		routePermissions string
+		method           string
+		path             string
+		tokenIds         []int
+		want             int
+	}{
+		{
+			name:          "PERMISSION_IDS replaces the default ids",
+			permissionIds: "catalog.read=900",
+			method:        http.MethodGet,
+			path:          "/api/v1/categories",
+			tokenIds:      []int{900},
+			want:          http.StatusNoContent,
+		},
+		{
+			name:          "default ids no longer grant an overridden permission",
+			permissionIds: "catalog.read=900",
+			method:        http.MethodGet,
+			path:          "/api/v1/categories",
+			tokenIds:      model.DefaultPermissionIds[model.CatalogRead],
+			want:          http.StatusForbidden,
+		},
+		{
+			name:          "PERMISSION_IDS accepts several ids per permission",
+			permissionIds: "catalog.read=900|901, product.write=902",
+			method:        http.MethodGet,
+			path:          "/api/v1/categories",
+			tokenIds:      []int{901},
+			want:          http.StatusNoContent,
+		},
+		{
+			name:          "invalid PERMISSION_IDS entries are ignored",
+			permissionIds: "unknown.permission=900,catalog.read",
+			method:        http.MethodGet,
+			path:          "/api/v1/categories",
+			tokenIds:      model.DefaultPermissionIds[model.CatalogRead],
+			want:          http.StatusNoContent,
+		},
+		{
+			name:             "ROUTE_PERMISSIONS replaces the permission of a route",
+			routePermissions: "GET /api/v1/categories=category.manage",
+			method:           http.MethodGet,
+			path:             "/api/v1/categories",
+			tokenIds:         model.DefaultPermissionIds[model.CatalogRead],
+			want:             http.StatusForbidden,
+		},
+		{
+			name:             "ROUTE_PERMISSIONS grants the new permission",
+			routePermissions: "GET  /api/v1/categories = category.manage",
+			method:           http.MethodGet,
+			path:             "/api/v1/categories",
+			tokenIds:         model.DefaultPermissionIds[model.CategoryManage],
+			want:             http.StatusNoContent,
+		},
+		{
+			name:             "ROUTE_PERMISSIONS maps a route without default permission",
+			routePermissions: "GET /api/v1/unmapped=catalog.read",
+			method:           http.MethodGet,
+			path:             "/api/v1/unmapped",
+			tokenIds:         model.DefaultPermissionIds[model.CatalogRead],
+			want:             http.StatusNoContent,
+		},
+		{
+			name:             "invalid ROUTE_PERMISSIONS entries are ignored",
+			routePermissions: "GET /api/v1/categories=unknown.permission",
+			method:           http.MethodGet,
+			path:             "/api/v1/categories",
+			tokenIds:         model.DefaultPermissionIds[model.CatalogRead],
+			want:             http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PERMISSION_IDS", tt.permissionIds)
+			t.Setenv("ROUTE_PERMISSIONS", tt.routePermissions)
+			permissionConfig := middleware.LoadPermissionConfig()
+
+			if code, _ := authorizeRoute(permissionConfig, tt.method, tt.path, tokenClaims(nil, tt.tokenIds...)); code != tt.want {
+				t.Errorf("got %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeAdminRoles(t *testing.T) {
+	productWrite := model.DefaultPermissionIds[model.ProductWrite]
+	tests := []struct {
+		name       string
+		adminRoles string
+		roles      []string
+		tokenIds   []int
+		wantCode   int
+		wantAdmin  bool
+	}{
+		{name: "default ADMIN role", roles: []string{"admin"}, tokenIds: productWrite, wantCode: http.StatusNoContent, wantAdmin: true},
+		{name: "role that is not an administrator", roles: []string{"SELLER"}, tokenIds: productWrite, wantCode: http.StatusNoContent},
+		{name: "ADMIN_ROLES replaces the default roles", adminRoles: "OWNER, SUPPORT", roles: []string{"SUPPORT"}, tokenIds: productWrite, wantCode: http.StatusNoContent, wantAdmin: true},
+		{name: "ADMIN is not an administrator when ADMIN_ROLES changes", adminRoles: "OWNER", roles: []string{"ADMIN"}, tokenIds: productWrite, wantCode: http.StatusNoContent},
+		// El rol de administrador permite gestionar productos de otros autores, no sustituye al permiso de la ruta
+		{name: "administrator without the route permission", roles: []string{"ADMIN"}, wantCode: http.StatusForbidden, wantAdmin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.adminRoles != "" {
+				t.Setenv("ADMIN_ROLES", tt.adminRoles)
+			}
+			permissionConfig := middleware.LoadPermissionConfig()
+			if admin := permissionConfig.IsAdmin(tt.roles); admin != tt.wantAdmin {
+				t.Errorf("IsAdmin: got %v, want %v", admin, tt.wantAdmin)
+			}
+
+			code, principal := authorizeRoute(permissionConfig, http.MethodPut, "/api/v1/products/:id", tokenClaims(tt.roles, tt.tokenIds...))
+			if code != tt.wantCode {
+				t.Fatalf("got %d, want %d", code, tt.wantCode)
+			}
+			if principal != nil && principal.Admin != tt.wantAdmin {
+				t.Errorf("Principal.Admin: got %v, want %v", principal.Admin, tt.wantAdmin)
+			}
+			if principal != nil && principal.CanManage("another-user") != tt.wantAdmin {
+				t.Errorf("CanManage another author: got %v, want %v", principal.CanManage("another-user"), tt.wantAdmin)
+			}
+		})
+	}
+}
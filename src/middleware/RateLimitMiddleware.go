@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig define el ritmo sostenido (peticiones por segundo) y la ráfaga permitidos por
+// cliente, y cuántos clientes se siguen a la vez (MaxClients)
+type RateLimitConfig struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxClients        int
+}
+
+// clientLimiter guarda el limitador de un cliente y cuándo se usó por última vez
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter mantiene un limitador token bucket por IP de cliente. Cuando ya sigue a maxClients
+// clientes, los nuevos comparten el limitador overflow hasta que se descartan los inactivos.
+type rateLimiter struct {
+	mu         sync.Mutex
+	limit      rate.Limit
+	burst      int
+	maxClients int
+	clients    map[string]*clientLimiter
+	overflow   *rate.Limiter
+	lastSweep  time.Time
+}
+
+// idleClientTTL es el tiempo tras el que se descarta el limitador de un cliente inactivo
+const idleClientTTL = 10 * time.Minute
+
+func (rl *rateLimiter) get(clientIp string, now time.Time) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Descartar periódicamente los clientes inactivos
+	if now.Sub(rl.lastSweep) > idleClientTTL {
+		rl.sweep(now)
+	}
+
+	client, ok := rl.clients[clientIp]
+	if !ok {
+		// El mapa no crece más allá de maxClients aunque lleguen muchas IPs distintas
+		if len(rl.clients) >= rl.maxClients && now.Sub(rl.lastSweep) > time.Second {
+			rl.sweep(now)
+		}
+		if len(rl.clients) >= rl.maxClients {
+			return rl.overflow
+		}
+		client = &clientLimiter{limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.clients[clientIp] = client
+	}
+	client.lastSeen = now
+	return client.limiter
+}
+
+// sweep descarta los limitadores de los clientes inactivos durante más de idleClientTTL
+func (rl *rateLimiter) sweep(now time.Time) {
+	for ip, client := range rl.clients {
+		if now.Sub(client.lastSeen) > idleClientTTL {
+			delete(rl.clients, ip)
+		}
+	}
+	rl.lastSweep = now
+}
+
+// RateLimit limita las peticiones por IP de cliente (gin ClientIP, que depende de los proxies
+// configurados con ConfigureTrustedProxies). Al superar el límite responde 429 Too Many Requests
+// con la cabecera Retry-After.
+func RateLimit(rateLimitConfig RateLimitConfig) gin.HandlerFunc {
+	if rateLimitConfig.RequestsPerSecond <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limit := rate.Limit(rateLimitConfig.RequestsPerSecond)
+	burst := max(rateLimitConfig.Burst, 1)
+	limiter := &rateLimiter{
+		limit:      limit,
+		burst:      burst,
+		maxClients: max(rateLimitConfig.MaxClients, 1),
+		clients:    map[string]*clientLimiter{},
+		overflow:   rate.NewLimiter(limit, burst),
+		lastSweep:  time.Now(),
+	}
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		reservation := limiter.get(c.ClientIP(), now).ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			WriteProblem(c, exception.RateLimited(exception.CodeRateLimited, "Too many requests, please retry later"))
+			return
+		}
+		c.Next()
+	}
+}
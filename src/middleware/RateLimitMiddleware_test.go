@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/middleware"
+)
+
+// rateLimitedRequest es una petición desde clientIp y el código esperado
+type rateLimitedRequest struct {
+	clientIp   string
+	wantStatus int
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   middleware.RateLimitConfig
+		requests []rateLimitedRequest
+	}{
+		{
+			name:   "rejects requests beyond the burst of each client",
+			config: middleware.RateLimitConfig{RequestsPerSecond: 0.5, Burst: 2, MaxClients: 10},
+			requests: []rateLimitedRequest{
+				{"192.0.2.1", http.StatusOK},
+				{"192.0.2.1", http.StatusOK},
+				{"192.0.2.1", http.StatusTooManyRequests},
+				{"192.0.2.2", http.StatusOK},
+				{"192.0.2.1", http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:   "new clients share a limiter beyond the maximum number of clients",
+			config: middleware.RateLimitConfig{RequestsPerSecond: 0.5, Burst: 1, MaxClients: 1},
+			requests: []rateLimitedRequest{
+				{"192.0.2.1", http.StatusOK},
+				{"192.0.2.2", http.StatusOK},
+				{"192.0.2.3", http.StatusTooManyRequests},
+				{"192.0.2.1", http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:   "a zero rate disables the limit",
+			config: middleware.RateLimitConfig{Burst: 1, MaxClients: 1},
+			requests: []rateLimitedRequest{
+				{"192.0.2.1", http.StatusOK},
+				{"192.0.2.1", http.StatusOK},
+				{"192.0.2.1", http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/api/v1/public/products", middleware.RateLimit(tt.config), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			for i, request := range tt.requests {
+				httpRequest := httptest.NewRequest(http.MethodGet, "/api/v1/public/products", nil)
+				httpRequest.RemoteAddr = request.clientIp + ":1234"
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, httpRequest)
+
+				if recorder.Code != request.wantStatus {
+					t.Fatalf("request %d from %s: got %d, want %d", i, request.clientIp, recorder.Code, request.wantStatus)
+				}
+				if request.wantStatus != http.StatusTooManyRequests {
+					continue
+				}
+				// Con 0.5 peticiones por segundo el siguiente token llega en 2 segundos
+				if retryAfter := recorder.Header().Get("Retry-After"); retryAfter != "2" {
+					t.Errorf("request %d: Retry-After = %q, want 2", i, retryAfter)
+				}
+				var problem dto.ProblemDetails
+				if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil || problem.Code != exception.CodeRateLimited {
+					t.Errorf("request %d: problem = %s, want code %s", i, recorder.Body, exception.CodeRateLimited)
+				}
+			}
+		})
+	}
+}
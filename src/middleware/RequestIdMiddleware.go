@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIdHeader es la cabecera con la que se propaga el ID de correlación
+const RequestIdHeader = "X-Request-ID"
+
+// validRequestId limita los IDs recibidos para evitar inyección en logs y cabeceras
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestId asigna o propaga el X-Request-ID y lo guarda en el contexto de la petición
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIdHeader)
+		if !validRequestId.MatchString(requestId) {
+			requestId = uuid.New().String()
+		}
+
+		c.Header(RequestIdHeader, requestId)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("http.request_id", requestId))
+
+		// El subject lo añade Authorize, que se ejecuta después de RequireJWT
+		ctx := logging.WithRequest(c.Request.Context(), requestId, c.FullPath())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
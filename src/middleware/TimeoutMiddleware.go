@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+)
+
+// TimeoutConfig define el plazo por defecto y los plazos por ruta ("METHOD /plantilla/de/ruta")
+type TimeoutConfig struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// defaultRouteTimeouts son los plazos por defecto de las rutas que necesitan más tiempo que REQUEST_TIMEOUT
+var defaultRouteTimeouts = map[string]time.Duration{
+	// La exportación recorre todo el catálogo mientras envía el archivo
+	"GET /api/v1/products/export": 10 * time.Minute,
+	// La reconciliación recorre todos los productos y todos los archivos del almacenamiento
+	"POST /api/v1/admin/storage/reconcile": 10 * time.Minute,
+}
+
+// LoadTimeoutConfig carga la configuración de plazos desde REQUEST_TIMEOUT y REQUEST_TIMEOUT_ROUTES.
+// REQUEST_TIMEOUT_ROUTES tiene el formato "GET /api/v1/products/search=10s,POST /api/v1/products=60s".
+func LoadTimeoutConfig() TimeoutConfig {
+	timeoutConfig := TimeoutConfig{
+		Default: config.GetEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+		Routes:  map[string]time.Duration{},
+	}
+	for route, duration := range defaultRouteTimeouts {
+		timeoutConfig.Routes[route] = duration
+	}
+
+	for _, entry := range strings.Split(config.GetEnv("REQUEST_TIMEOUT_ROUTES", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, found := strings.Cut(entry, "=")
+		if !found {
+			slog.Warn("Invalid REQUEST_TIMEOUT_ROUTES entry, ignoring", "entry", entry)
+			continue
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			slog.Warn("Invalid REQUEST_TIMEOUT_ROUTES duration, ignoring", "entry", entry)
+			continue
+		}
+		timeoutConfig.Routes[strings.Join(strings.Fields(route), " ")] = duration
+	}
+
+	return timeoutConfig
+}
+
+// For devuelve el plazo configurado para el método y la plantilla de ruta indicados
+func (tc TimeoutConfig) For(method string, route string) time.Duration {
+	if duration, ok := tc.Routes[method+" "+route]; ok {
+		return duration
+	}
+	return tc.Default
+}
+
+// Timeout aplica un plazo al contexto de cada petición. Si el plazo vence y el handler
+// no ha escrito respuesta, se responde 504 Gateway Timeout.
+func Timeout(timeoutConfig TimeoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		duration := timeoutConfig.For(c.Request.Method, c.FullPath())
+		if duration <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), duration)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			WriteProblem(c, ctx.Err())
+		}
+	}
+}
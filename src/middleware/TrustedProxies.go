@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+)
+
+// trustedPlatforms son las plataformas conocidas de TRUSTED_PLATFORM y la cabecera con la IP del cliente
+var trustedPlatforms = map[string]string{
+	"cloudflare":        gin.PlatformCloudflare,
+	"google-app-engine": gin.PlatformGoogleAppEngine,
+	"flyio":             gin.PlatformFlyIO,
+}
+
+// ConfigureTrustedProxies indica a gin de qué proxies acepta X-Forwarded-For al calcular la IP del
+// cliente (ClientIP), que usan el límite de peticiones y los logs. TRUSTED_PROXIES es la lista de
+// IPs o rangos CIDR separados por comas; vacía no confía en ningún proxy y se usa la dirección de
+// la conexión. TRUSTED_PLATFORM toma la IP de la cabecera de la plataforma (cloudflare,
+// google-app-engine, flyio o el nombre de una cabecera), que solo es fiable si la plataforma es la
+// única vía de acceso al servicio.
+func ConfigureTrustedProxies(router *gin.Engine) error {
+	if err := router.SetTrustedProxies(splitEntries(config.GetEnv("TRUSTED_PROXIES", ""))); err != nil {
+		return err
+	}
+	if platform := strings.TrimSpace(config.GetEnv("TRUSTED_PLATFORM", "")); platform != "" {
+		if header, ok := trustedPlatforms[strings.ToLower(platform)]; ok {
+			platform = header
+		}
+		router.TrustedPlatform = platform
+	}
+	return nil
+}
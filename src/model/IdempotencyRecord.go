@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// IdempotencyRecord guarda la respuesta de una petición con cabecera Idempotency-Key para
+// poder repetirla cuando el cliente reintenta. Un registro sin StatusCode indica que la
+// petición original todavía se está procesando; con ResponseUnknown, que terminó con éxito
+// pero su respuesta no se pudo guardar.
+type IdempotencyRecord struct {
+	Key             string            `json:"key"             firestore:"key"`
+	Fingerprint     string            `json:"fingerprint"     firestore:"fingerprint"`
+	StatusCode      int               `json:"statusCode"      firestore:"statusCode"`
+	Headers         map[string]string `json:"headers"         firestore:"headers,omitempty"`
+	Body            []byte            `json:"body"            firestore:"body,omitempty"`
+	ResponseUnknown bool              `json:"responseUnknown" firestore:"responseUnknown,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"       firestore:"createdAt"`
+	ExpiresAt       time.Time         `json:"expiresAt"       firestore:"expiresAt"`
+}
+
+// IsCompleted indica si la petición original ya terminó y su respuesta está guardada
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
+
+// IsExpired indica si el registro ha superado su TTL
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
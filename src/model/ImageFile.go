@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// ImageFile cuenta las referencias a un archivo de imagen del almacenamiento. Como las claves se
+// derivan del contenido, varios productos pueden compartir el mismo archivo: ProductIds son los
+// productos (también los de la papelera) cuya galería lo contiene. Cuando deja de estar referenciado
+// se marca con DeletingAt mientras se eliminan el objeto y sus variantes.
+type ImageFile struct {
+	FileName   string    `json:"fileName"             firestore:"fileName"`
+	ProductIds []string  `json:"productIds"           firestore:"productIds"`
+	References int       `json:"references"           firestore:"references"`
+	DeletingAt time.Time `json:"deletingAt,omitempty" firestore:"deletingAt,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"            firestore:"updatedAt"`
+}
+
+// IsDeleting indica si el archivo está marcado para eliminación
+func (f *ImageFile) IsDeleting() bool {
+	return !f.DeletingAt.IsZero()
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ImageUpload es una subida directa al bucket pendiente de confirmar. FileName es la clave del
+// objeto a la que está ligada la URL firmada; la subida se confirma para añadir la imagen a la
+// galería o se elimina, junto con el objeto, cuando vence ExpiresAt.
+type ImageUpload struct {
+	Id          string    `json:"id"          firestore:"id"`
+	ProductId   string    `json:"productId"   firestore:"productId"`
+	AuthorId    string    `json:"authorId"    firestore:"authorId"`
+	FileName    string    `json:"fileName"    firestore:"fileName"`
+	ContentType string    `json:"contentType" firestore:"contentType"`
+	Size        int64     `json:"size"        firestore:"size"`
+	CreatedAt   string    `json:"createdAt"   firestore:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"   firestore:"expiresAt"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// ImportJobStatus es el estado de un trabajo de importación de productos
+type ImportJobStatus string
+
+const (
+	ImportJobPending   ImportJobStatus = "pending"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+)
+
+// ImportRowError describe el error de una fila de la hoja importada (las filas empiezan en 1, la cabecera incluida)
+type ImportRowError struct {
+	Row     int    `json:"row"               firestore:"row"`
+	Sku     string `json:"sku,omitempty"     firestore:"sku,omitempty"`
+	Field   string `json:"field,omitempty"   firestore:"field,omitempty"`
+	Message string `json:"message"           firestore:"message"`
+}
+
+// ImportJob es el resultado de una importación de productos desde CSV o XLSX. Las importaciones
+// grandes se ejecutan en segundo plano y el cliente consulta su estado con el ID del trabajo.
+type ImportJob struct {
+	Id              string           `json:"id"              firestore:"id"`
+	AuthorId        string           `json:"authorId"        firestore:"authorId"`
+	FileName        string           `json:"fileName"        firestore:"fileName"`
+	Format          string           `json:"format"          firestore:"format"`
+	DryRun          bool             `json:"dryRun"          firestore:"dryRun"`
+	Status          ImportJobStatus  `json:"status"          firestore:"status"`
+	TotalRows       int              `json:"totalRows"       firestore:"totalRows"`
+	ProcessedRows   int              `json:"processedRows"   firestore:"processedRows"`
+	Created         int              `json:"created"         firestore:"created"`
+	Updated         int              `json:"updated"         firestore:"updated"`
+	Failed          int              `json:"failed"          firestore:"failed"`
+	Errors          []ImportRowError `json:"errors"          firestore:"errors"`
+	ErrorsTruncated bool             `json:"errorsTruncated" firestore:"errorsTruncated"`
+	Message         string           `json:"message"         firestore:"message,omitempty"`
+	CreatedAt       string           `json:"createdAt"       firestore:"createdAt"`
+	FinishedAt      string           `json:"finishedAt"      firestore:"finishedAt,omitempty"`
+	// ExpiresAt permite eliminar los trabajos antiguos con una política TTL de Firestore
+	ExpiresAt time.Time `json:"-" firestore:"expiresAt"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import userModel "github.com/ruiborda/ecommerce-user-service/src/model"
+
+// Permission es un permiso del servicio de productos
+type Permission string
+
+const (
+	// CatalogRead permite consultar productos y categorías
+	CatalogRead Permission = "catalog.read"
+	// ProductWrite permite crear y modificar productos
+	ProductWrite Permission = "product.write"
+	// ProductDelete permite eliminar productos
+	ProductDelete Permission = "product.delete"
+	// StockAdjust permite ajustar el stock de los productos
+	StockAdjust Permission = "stock.adjust"
+	// CategoryManage permite crear y modificar categorías
+	CategoryManage Permission = "category.manage"
+	// PriceManage permite cambiar el precio, la moneda y el descuento de productos existentes
+	PriceManage Permission = "price.manage"
+)
+
+// Permissions contiene todos los permisos del servicio de productos
+var Permissions = []Permission{
+	CatalogRead,
+	ProductWrite,
+	ProductDelete,
+	StockAdjust,
+	CategoryManage,
+	PriceManage,
+}
+
+// DefaultPermissionIds son los IDs numéricos (claim permissionIds del JWT) asignados por defecto a
+// cada permiso, todos ya emitidos por el servicio de usuarios para que los tokens existentes sigan
+// funcionando. category.manage y price.manage no tienen un permiso propio allí: category.manage usa
+// el permiso de administración CreateUser, que era el que exigían las rutas de categorías, y
+// price.manage los mismos IDs que product.write. PERMISSION_IDS permite restringirlos.
+var DefaultPermissionIds = map[Permission][]int{
+	CatalogRead:    {userModel.GetProductById, userModel.GetProductsPaginated, userModel.SearchProducts},
+	ProductWrite:   {userModel.CreateProduct, userModel.UpdateProduct},
+	ProductDelete:  {userModel.DeleteProduct},
+	StockAdjust:    {userModel.AdjustProductStock},
+	CategoryManage: {userModel.CreateUser},
+	PriceManage:    {userModel.CreateProduct, userModel.UpdateProduct},
+}
+
+// IsValid indica si el permiso es uno de los permisos conocidos
+func (p Permission) IsValid() bool {
+	for _, permission := range Permissions {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
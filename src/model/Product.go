@@ -1,17 +1,76 @@
 package model
 
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Product struct {
-	Id          string  `json:"id,omitempty"          firestore:"id,omitempty"`
-	CategoryId  string  `json:"categoryId,omitempty"  firestore:"categoryId,omitempty"`
-	AuthorId    string  `json:"authorId,omitempty"    firestore:"authorId,omitempty"`
-	Name        string  `json:"name,omitempty"        firestore:"name,omitempty"`
-	Description string  `json:"description,omitempty" firestore:"description,omitempty"`
-	Price       float64 `json:"price,omitempty"       firestore:"price,omitempty"`
-	Currency    string  `json:"currency,omitempty"    firestore:"currency,omitempty"`
-	Discount    float64 `json:"discount,omitempty"    firestore:"discount,omitempty"`
-	Sku         string  `json:"sku,omitempty"         firestore:"sku,omitempty"`
-	Stock       int     `json:"stock,omitempty"       firestore:"stock,omitempty"`
-	FileImage   string  `json:"fileImage,omitempty"   firestore:"fileImage,omitempty"`
-	CreatedAt   string  `json:"createdAt,omitempty"   firestore:"createdAt,omitempty"`
-	UpdatedAt   string  `json:"updatedAt,omitempty"   firestore:"updatedAt,omitempty"`
+	Id          string         `json:"id,omitempty"          firestore:"id,omitempty"`
+	CategoryId  string         `json:"categoryId,omitempty"  firestore:"categoryId,omitempty"`
+	AuthorId    string         `json:"authorId,omitempty"    firestore:"authorId,omitempty"`
+	Name        string         `json:"name,omitempty"        firestore:"name,omitempty"`
+	Description string         `json:"description,omitempty" firestore:"description,omitempty"`
+	Price       float64        `json:"price,omitempty"       firestore:"price"`
+	Currency    string         `json:"currency,omitempty"    firestore:"currency,omitempty"`
+	Discount    float64        `json:"discount,omitempty"    firestore:"discount"`
+	Sku         string         `json:"sku,omitempty"         firestore:"sku,omitempty"`
+	Stock       int            `json:"stock,omitempty"       firestore:"stock"`
+	FileImage   string         `json:"fileImage,omitempty"   firestore:"fileImage,omitempty"`
+	Images      []ProductImage `json:"images,omitempty"      firestore:"images,omitempty"`
+	CreatedAt   string         `json:"createdAt,omitempty"   firestore:"createdAt,omitempty"`
+	UpdatedAt   string         `json:"updatedAt,omitempty"   firestore:"updatedAt,omitempty"`
+	Status      ProductStatus  `json:"status,omitempty"      firestore:"status,omitempty"`
+	PublishAt   string         `json:"publishAt,omitempty"   firestore:"publishAt,omitempty"`
+	UnpublishAt string         `json:"unpublishAt,omitempty" firestore:"unpublishAt,omitempty"`
+	DeletedAt   string         `json:"deletedAt,omitempty"   firestore:"deletedAt,omitempty"`
+	DeletedBy   string         `json:"deletedBy,omitempty"   firestore:"deletedBy,omitempty"`
+	UpdateTime  time.Time      `json:"-"                     firestore:"-"`
+}
+
+// ETag devuelve la versión del producto como ETag fuerte, derivada de la hora de
+// actualización de Firestore; vacío si el producto aún no se ha leído ni escrito
+func (p *Product) ETag() string {
+	if p.UpdateTime.IsZero() {
+		return ""
+	}
+	return `"` + strconv.FormatInt(p.UpdateTime.UnixNano(), 36) + `"`
+}
+
+// MatchesIfMatch indica si la cabecera If-Match (RFC 9110) admite la versión actual del producto.
+// Una cabecera vacía no impone condición; "*" admite cualquier versión; los ETag débiles nunca coinciden.
+func (p *Product) MatchesIfMatch(ifMatch string) bool {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	current := p.ETag()
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if current != "" && strings.TrimSpace(candidate) == current {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDeleted indica si el producto está en la papelera (borrado lógico)
+func (p *Product) IsDeleted() bool {
+	return p.DeletedAt != ""
+}
+
+// EffectiveStatus devuelve el estado del producto; los productos creados antes de
+// existir el ciclo de vida no tienen estado y se consideran publicados
+func (p *Product) EffectiveStatus() ProductStatus {
+	if p.Status == "" {
+		return ProductStatusPublished
+	}
+	return p.Status
+}
+
+// FinalPrice devuelve el precio con el descuento aplicado (el descuento es un importe en la
+// moneda del producto), redondeado a céntimos y nunca negativo
+func (p *Product) FinalPrice() float64 {
+	return math.Round(math.Max(p.Price-p.Discount, 0)*100) / 100
 }
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// ProductImage es una imagen de la galería de un producto. Position es su orden en la galería
+// (empezando en 0) y Alt el texto alternativo por idioma (etiqueta BCP 47, por ejemplo "es" o "en-US").
+// La imagen principal también se guarda en Product.FileImage para los clientes anteriores a la galería.
+// Renditions son las variantes reducidas de la imagen por nombre (thumbnail, medium, large...).
+type ProductImage struct {
+	Id         string                    `json:"id"                   firestore:"id"`
+	FileName   string                    `json:"fileName"             firestore:"fileName"`
+	Position   int                       `json:"position"             firestore:"position"`
+	Alt        map[string]string         `json:"alt,omitempty"        firestore:"alt,omitempty"`
+	Primary    bool                      `json:"primary"              firestore:"primary"`
+	Width      int                       `json:"width,omitempty"      firestore:"width,omitempty"`
+	Height     int                       `json:"height,omitempty"     firestore:"height,omitempty"`
+	Renditions map[string]ImageRendition `json:"renditions,omitempty" firestore:"renditions,omitempty"`
+	CreatedAt  string                    `json:"createdAt,omitempty"  firestore:"createdAt,omitempty"`
+}
+
+// ImageRendition es una variante en WebP de una imagen de la galería
+type ImageRendition struct {
+	FileName string `json:"fileName" firestore:"fileName"`
+	Width    int    `json:"width"    firestore:"width"`
+	Height   int    `json:"height"   firestore:"height"`
+}
+
+// Files devuelve el archivo de la imagen y los de sus variantes
+func (i ProductImage) Files() []string {
+	files := []string{i.FileName}
+	for _, rendition := range i.Renditions {
+		files = append(files, rendition.FileName)
+	}
+	return files
+}
+
+// Gallery devuelve las imágenes del producto ordenadas por posición. Los productos anteriores a la
+// galería solo tienen FileImage, que se devuelve como su única imagen principal.
+func (p *Product) Gallery() []ProductImage {
+	if len(p.Images) == 0 {
+		if p.FileImage == "" {
+			return nil
+		}
+		return []ProductImage{{
+			Id:       strings.TrimSuffix(p.FileImage, path.Ext(p.FileImage)),
+			FileName: p.FileImage,
+			Primary:  true,
+		}}
+	}
+
+	gallery := slices.Clone(p.Images)
+	slices.SortStableFunc(gallery, func(a, b ProductImage) int { return a.Position - b.Position })
+	return gallery
+}
+
+// FindImage devuelve el índice de la imagen en Images, o -1 si no existe
+func (p *Product) FindImage(id string) int {
+	return slices.IndexFunc(p.Images, func(image ProductImage) bool { return image.Id == id })
+}
+
+// SetImages reemplaza la galería por las imágenes indicadas en ese orden: renumera las posiciones,
+// deja una única imagen principal (la primera marcada o, si no hay ninguna, la primera de la lista)
+// y copia su archivo en FileImage para los clientes que solo conocen la imagen principal
+func (p *Product) SetImages(images []ProductImage) {
+	primary := slices.IndexFunc(images, func(image ProductImage) bool { return image.Primary })
+	if primary < 0 {
+		primary = 0
+	}
+
+	p.Images = images
+	p.FileImage = ""
+	for i := range p.Images {
+		p.Images[i].Position = i
+		p.Images[i].Primary = i == primary
+		if i == primary {
+			p.FileImage = p.Images[i].FileName
+		}
+	}
+	if len(p.Images) == 0 {
+		p.Images = nil
+	}
+}
+
+// SetPrimaryImage reemplaza el archivo de la imagen principal, conservando su posición y su texto
+// alternativo, o la añade si el producto no tiene imágenes. Si la galería ya contiene el archivo, esa
+// imagen pasa a ser la principal. Devuelve la imagen cuyo archivo se reemplazó.
+func (p *Product) SetPrimaryImage(image ProductImage) (replaced []ProductImage) {
+	gallery := p.Gallery()
+	if existing := slices.IndexFunc(gallery, func(i ProductImage) bool { return i.FileName == image.FileName }); existing >= 0 {
+		for i := range gallery {
+			gallery[i].Primary = i == existing
+		}
+		p.SetImages(gallery)
+		return nil
+	}
+
+	primary := slices.IndexFunc(gallery, func(i ProductImage) bool { return i.Primary })
+	if primary < 0 {
+		image.Primary = true
+		p.SetImages(append([]ProductImage{image}, gallery...))
+		return nil
+	}
+
+	replaced = []ProductImage{gallery[primary]}
+	gallery[primary].FileName = image.FileName
+	gallery[primary].Width = image.Width
+	gallery[primary].Height = image.Height
+	gallery[primary].Renditions = image.Renditions
+	p.SetImages(gallery)
+	return replaced
+}
+
+// MediaFiles devuelve los archivos de todas las imágenes del producto, variantes incluidas
+func (p *Product) MediaFiles() []string {
+	var files []string
+	for _, image := range p.Gallery() {
+		files = append(files, image.Files()...)
+	}
+	if p.FileImage != "" && !slices.Contains(files, p.FileImage) {
+		files = append(files, p.FileImage)
+	}
+	return files
+}
@@ -0,0 +1,36 @@
+package model
+
+// ProductStatus es el estado del ciclo de vida de un producto
+type ProductStatus string
+
+const (
+	// ProductStatusDraft es un producto en preparación, no visible en el catálogo público
+	ProductStatusDraft ProductStatus = "draft"
+	// ProductStatusPublished es un producto visible en el catálogo público y en las búsquedas
+	ProductStatusPublished ProductStatus = "published"
+	// ProductStatusArchived es un producto retirado del catálogo que puede volver a publicarse
+	ProductStatusArchived ProductStatus = "archived"
+)
+
+// productStatusTransitions contiene las transiciones permitidas desde cada estado
+var productStatusTransitions = map[ProductStatus][]ProductStatus{
+	ProductStatusDraft:     {ProductStatusPublished},
+	ProductStatusPublished: {ProductStatusArchived},
+	ProductStatusArchived:  {ProductStatusPublished},
+}
+
+// IsValid indica si el estado es uno de los estados conocidos
+func (s ProductStatus) IsValid() bool {
+	_, ok := productStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo indica si se permite pasar del estado actual al indicado
+func (s ProductStatus) CanTransitionTo(target ProductStatus) bool {
+	for _, allowed := range productStatusTransitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
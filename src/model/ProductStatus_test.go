@@ -0,0 +1,58 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+func TestProductStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from model.ProductStatus
+		to   model.ProductStatus
+		want bool
+	}{
+		{from: model.ProductStatusDraft, to: model.ProductStatusPublished, want: true},
+		{from: model.ProductStatusDraft, to: model.ProductStatusArchived, want: false},
+		{from: model.ProductStatusDraft, to: model.ProductStatusDraft, want: false},
+		{from: model.ProductStatusPublished, to: model.ProductStatusArchived, want: true},
+		{from: model.ProductStatusPublished, to: model.ProductStatusDraft, want: false},
+		{from: model.ProductStatusPublished, to: model.ProductStatusPublished, want: false},
+		{from: model.ProductStatusArchived, to: model.ProductStatusPublished, want: true},
+		{from: model.ProductStatusArchived, to: model.ProductStatusDraft, want: false},
+		{from: model.ProductStatusArchived, to: model.ProductStatusArchived, want: false},
+		{from: "deleted", to: model.ProductStatusPublished, want: false},
+		{from: model.ProductStatusDraft, to: "deleted", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.from)+" to "+string(test.to), func(t *testing.T) {
+			if got := test.from.CanTransitionTo(test.to); got != test.want {
+				t.Errorf("CanTransitionTo() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProductStatusIsValid(t *testing.T) {
+	for _, status := range []model.ProductStatus{model.ProductStatusDraft, model.ProductStatusPublished, model.ProductStatusArchived} {
+		if !status.IsValid() {
+			t.Errorf("%s is not valid", status)
+		}
+	}
+	for _, status := range []model.ProductStatus{"", "deleted", "Published"} {
+		if status.IsValid() {
+			t.Errorf("%q is valid", status)
+		}
+	}
+}
+
+// Los productos anteriores al ciclo de vida no tienen estado y se consideran publicados
+func TestProductEffectiveStatus(t *testing.T) {
+	if status := (&model.Product{}).EffectiveStatus(); status != model.ProductStatusPublished {
+		t.Errorf("EffectiveStatus() without status = %s, want published", status)
+	}
+	if status := (&model.Product{Status: model.ProductStatusDraft}).EffectiveStatus(); status != model.ProductStatusDraft {
+		t.Errorf("EffectiveStatus() = %s, want draft", status)
+	}
+}
@@ -0,0 +1,60 @@
+package model_test
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+func TestProductETag(t *testing.T) {
+	if etag := (&model.Product{}).ETag(); etag != "" {
+		t.Errorf("ETag() without UpdateTime = %q, want empty", etag)
+	}
+
+	updateTime := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	p := &model.Product{UpdateTime: updateTime}
+	etag := p.ETag()
+	if !regexp.MustCompile(`^"[0-9a-z]+"$`).MatchString(etag) {
+		t.Fatalf("ETag() = %s, want a strong quoted ETag", etag)
+	}
+	if want := `"` + strconv.FormatInt(updateTime.UnixNano(), 36) + `"`; etag != want {
+		t.Errorf("ETag() = %s, want %s", etag, want)
+	}
+	if next := (&model.Product{UpdateTime: updateTime.Add(time.Nanosecond)}).ETag(); next == etag {
+		t.Errorf("ETag() did not change with UpdateTime")
+	}
+}
+
+func TestProductMatchesIfMatch(t *testing.T) {
+	p := &model.Product{UpdateTime: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)}
+	current := p.ETag()
+
+	tests := []struct {
+		name    string
+		product *model.Product
+		ifMatch string
+		want    bool
+	}{
+		{name: "no header", product: p, ifMatch: "", want: true},
+		{name: "blank header", product: p, ifMatch: "  ", want: true},
+		{name: "any version", product: p, ifMatch: "*", want: true},
+		{name: "current ETag", product: p, ifMatch: current, want: true},
+		{name: "stale ETag", product: p, ifMatch: `"stale"`, want: false},
+		{name: "list containing current", product: p, ifMatch: `"stale", ` + current, want: true},
+		{name: "list without current", product: p, ifMatch: `"a", "b"`, want: false},
+		{name: "weak ETag never matches", product: p, ifMatch: "W/" + current, want: false},
+		{name: "unquoted ETag", product: p, ifMatch: current[1 : len(current)-1], want: false},
+		{name: "product never read", product: &model.Product{}, ifMatch: `""`, want: false},
+		{name: "any version of product never read", product: &model.Product{}, ifMatch: "*", want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.product.MatchesIfMatch(test.ifMatch); got != test.want {
+				t.Errorf("MatchesIfMatch(%q) = %v, want %v", test.ifMatch, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+// ErrIdempotencyKeyExists indica que ya existe un registro para la clave de idempotencia
+var ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+
+// IdempotencyRepository almacena las respuestas de las peticiones con Idempotency-Key
+type IdempotencyRepository interface {
+	// GetRecord obtiene el registro de una clave; devuelve nil si no existe o ha caducado
+	GetRecord(ctx context.Context, key string) (*model.IdempotencyRecord, error)
+
+	// ReserveRecord crea el registro de una petición en curso. Devuelve ErrIdempotencyKeyExists
+	// si otra petición ya reservó la clave.
+	ReserveRecord(ctx context.Context, record *model.IdempotencyRecord) error
+
+	// SaveRecord guarda la respuesta de una petición reservada
+	SaveRecord(ctx context.Context, record *model.IdempotencyRecord) error
+
+	// DeleteRecord libera una clave cuya petición no terminó con éxito
+	DeleteRecord(ctx context.Context, key string) error
+}
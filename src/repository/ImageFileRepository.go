@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+// ErrImageFileDeleting indica que el archivo se está eliminando porque dejó de estar referenciado;
+// se puede volver a referenciar cuando termine la eliminación
+var ErrImageFileDeleting = errors.New("image file is being deleted")
+
+// ImageFileRepository cuenta las referencias de los productos a los archivos de imagen para
+// eliminar un archivo compartido solo cuando ningún producto lo usa
+type ImageFileRepository interface {
+	// AcquireImageFile añade productId a las referencias del archivo, creando el registro si no
+	// existe. Devuelve added=false si el producto ya lo referenciaba, y ErrImageFileDeleting si el
+	// archivo está marcado para eliminación.
+	AcquireImageFile(ctx context.Context, fileName string, productId string) (added bool, err error)
+
+	// ReleaseImageFile quita productId de las referencias del archivo. Devuelve unreferenced=true si
+	// ya no quedan referencias, y en ese caso marca el registro para eliminación; los archivos sin
+	// registro (anteriores al recuento) se consideran referenciados solo por el producto.
+	ReleaseImageFile(ctx context.Context, fileName string, productId string) (unreferenced bool, err error)
+
+	// MarkImageFileDeleting marca para eliminación un archivo que ningún producto referencia, creando
+	// el registro si no existe. Devuelve marked=false si algún producto lo referencia o si otra
+	// eliminación está en curso.
+	MarkImageFileDeleting(ctx context.Context, fileName string) (marked bool, err error)
+
+	// GetImageFile obtiene el registro del archivo; devuelve nil si no existe
+	GetImageFile(ctx context.Context, fileName string) (*model.ImageFile, error)
+
+	// DeleteImageFile elimina el registro de un archivo marcado para eliminación que nadie volvió a referenciar
+	DeleteImageFile(ctx context.Context, fileName string) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+// ImageUploadRepository almacena las subidas directas de imágenes pendientes de confirmar
+type ImageUploadRepository interface {
+	// SaveImageUpload crea o reemplaza una subida pendiente
+	SaveImageUpload(ctx context.Context, upload *model.ImageUpload) error
+
+	// GetImageUploadById obtiene una subida por su ID; devuelve nil si no existe
+	GetImageUploadById(ctx context.Context, id string) (*model.ImageUpload, error)
+
+	// DeleteImageUpload elimina el registro de una subida (no el objeto subido)
+	DeleteImageUpload(ctx context.Context, id string) error
+
+	// GetImageUploadsExpiredBefore devuelve como máximo limit subidas que vencieron antes de la fecha indicada
+	GetImageUploadsExpiredBefore(ctx context.Context, before time.Time, limit int) ([]*model.ImageUpload, error)
+}
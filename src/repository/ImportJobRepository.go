@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+// ImportJobRepository almacena el estado de los trabajos de importación de productos
+type ImportJobRepository interface {
+	// SaveImportJob crea o reemplaza un trabajo de importación
+	SaveImportJob(ctx context.Context, job *model.ImportJob) error
+
+	// GetImportJobById obtiene un trabajo por su ID; devuelve nil si no existe
+	GetImportJobById(ctx context.Context, id string) (*model.ImportJob, error)
+
+	// GetUnfinishedImportJobs obtiene los trabajos pendientes o en curso
+	GetUnfinishedImportJobs(ctx context.Context) ([]*model.ImportJob, error)
+}
@@ -2,13 +2,58 @@
 package repository
 
 import (
+	"context"
+	"errors"
+
 	"github.com/ruiborda/ecommerce-product-service/src/model"
 )
 
+// ErrVersionConflict indica que el documento cambió desde que se leyó (falló la precondición UpdateTime)
+var ErrVersionConflict = errors.New("version conflict")
+
+// ErrUndecodableProducts indica que algún documento de producto no se pudo leer y se omitió
+var ErrUndecodableProducts = errors.New("some products could not be decoded")
+
+// MaxBatchWrites es el número máximo de escrituras de un lote de Firestore
+const MaxBatchWrites = 500
+
+// MaxSkusPerQuery es el número máximo de SKUs de GetProductsBySkus (límite del filtro "in" de Firestore)
+const MaxSkusPerQuery = 30
+
 type ProductRepository interface {
-	CreateProduct(product *model.Product) (*model.Product, error)
-	GetProductById(id string) (*model.Product, error)
-	UpdateProduct(product *model.Product) (*model.Product, error)
-	DeleteProductById(id string) error
-	GetProducts() ([]*model.Product, error)
+	CreateProduct(ctx context.Context, product *model.Product) (*model.Product, error)
+	// GetProductById devuelve nil si el producto no existe o está borrado lógicamente
+	GetProductById(ctx context.Context, id string) (*model.Product, error)
+	// GetDeletedProductById devuelve nil si el producto no existe o no está borrado lógicamente
+	GetDeletedProductById(ctx context.Context, id string) (*model.Product, error)
+	// UpdateProduct reemplaza el documento. Si el producto tiene UpdateTime (se leyó del repositorio),
+	// la escritura solo se aplica si el documento no ha cambiado desde entonces; si cambió devuelve ErrVersionConflict.
+	UpdateProduct(ctx context.Context, product *model.Product) (*model.Product, error)
+	// GetProductsByIds obtiene varios productos en una sola lectura; los que no existen o están
+	// borrados lógicamente no aparecen en el resultado
+	GetProductsByIds(ctx context.Context, ids []string) (map[string]*model.Product, error)
+	// WriteProducts guarda hasta MaxBatchWrites productos en un único lote atómico: los productos sin
+	// UpdateTime se crean y el resto se actualizan con la misma precondición que UpdateProduct.
+	// Si alguna precondición falla no se escribe ninguno y devuelve ErrVersionConflict.
+	WriteProducts(ctx context.Context, products []*model.Product) error
+	// DeleteProductById elimina el documento definitivamente
+	DeleteProductById(ctx context.Context, id string) error
+	GetProducts(ctx context.Context) ([]*model.Product, error)
+	// GetProductsBySkus obtiene los productos cuyo SKU está en skus (hasta MaxSkusPerQuery),
+	// incluidos los borrados lógicamente
+	GetProductsBySkus(ctx context.Context, skus []string) ([]*model.Product, error)
+	// ForEachProductChunk recorre los productos no borrados en orden de ID, leyendo de Firestore
+	// páginas de chunkSize documentos y llamando a fn con cada una; si categoryId no está vacío
+	// solo recorre los productos de esa categoría. Se detiene en el primer error de fn. Los
+	// documentos que no se pueden leer se omiten y, al terminar, devuelve ErrUndecodableProducts.
+	ForEachProductChunk(ctx context.Context, categoryId string, chunkSize int, fn func([]*model.Product) error) error
+	// GetPublishedProducts lee hasta limit documentos con estado published en orden de ID, a partir
+	// del siguiente a startAfterId (desde el principio si está vacío); si categoryId no está vacío solo
+	// los de esa categoría. Devuelve los no borrados y el ID del último documento leído, vacío si no
+	// quedan más. Los productos sin el campo status no se incluyen.
+	GetPublishedProducts(ctx context.Context, categoryId string, startAfterId string, limit int) ([]*model.Product, string, error)
+	GetProductsByAuthorId(ctx context.Context, authorId string) ([]*model.Product, error)
+	GetProductsScheduledToPublish(ctx context.Context, before string) ([]*model.Product, error)
+	GetProductsScheduledToUnpublish(ctx context.Context, before string) ([]*model.Product, error)
+	GetProductsDeletedBefore(ctx context.Context, before string) ([]*model.Product, error)
 }
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+)
+
+// ErrInvalidFile indica que el contenido recibido no es un archivo válido (nombre o contenido no admitido)
+var ErrInvalidFile = errors.New("invalid file")
+
+// ErrFileNotFound indica que el archivo no existe en el almacenamiento
+var ErrFileNotFound = errors.New("file not found")
+
+// ErrInvalidSignature indica que una URL firmada no es válida o ha caducado
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// HeadObject son los metadatos de un archivo. ListFiles no incluye ContentType
+type HeadObject struct {
+	FileName      string
+	ContentLength int64
+	ContentType   string
+	LastModified  int64
+}
+
+// PresignedUpload es una URL firmada para subir un archivo directamente al bucket. El cliente debe
+// enviar exactamente las cabeceras de Headers: forman parte de la firma.
+type PresignedUpload struct {
+	URL       string
+	Method    string
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// StorageRepository es el almacenamiento de objetos de las imágenes. Los nombres de archivo son
+// claves relativas que pueden contener "/" (por ejemplo "renditions/<id>/thumbnail.webp").
+type StorageRepository interface {
+	// UploadStream sube el contenido de body sin cargarlo entero en memoria, con un nombre nuevo
+	// terminado en extension, y devuelve el nombre del archivo y los bytes subidos
+	UploadStream(ctx context.Context, body io.Reader, contentType string, extension string) (fileName string, size int64, err error)
+	// PutFile sube data con el nombre indicado, reemplazando el archivo si ya existe
+	PutFile(ctx context.Context, fileName string, data []byte, contentType string) error
+	// MoveFile mueve el archivo source a destination, reemplazándolo si ya existe
+	MoveFile(ctx context.Context, source string, destination string) error
+	// OpenFile abre el contenido de un archivo; el llamador debe cerrarlo
+	OpenFile(ctx context.Context, fileName string) (io.ReadCloser, error)
+	// HeadObject devuelve los metadatos de un archivo, o ErrFileNotFound si no existe
+	HeadObject(ctx context.Context, fileName string) (*HeadObject, error)
+	// PresignUpload firma una petición PUT de fileName válida durante expires y ligada al tipo y
+	// al tamaño indicados: el almacenamiento rechaza la subida si no coinciden
+	PresignUpload(ctx context.Context, fileName string, contentType string, size int64, expires time.Duration) (*PresignedUpload, error)
+	// PresignDownload firma una petición GET de fileName válida durante expires, para buckets privados
+	PresignDownload(ctx context.Context, fileName string, expires time.Duration) (string, error)
+	DeleteFile(ctx context.Context, fileName string) (err error)
+	// ListFiles recorre todos los archivos del almacenamiento por páginas y llama a fn con cada una;
+	// se detiene en el primer error de fn
+	ListFiles(ctx context.Context, fn func([]HeadObject) error) error
+}
+
+// ServedStorageRepository es un almacenamiento cuyos archivos sirve el propio servicio en una ruta
+// estática (backends local y en memoria) en lugar de un bucket
+type ServedStorageRepository interface {
+	StorageRepository
+	// BaseURL es la URL pública de la ruta estática; termina sin "/"
+	BaseURL() string
+	// VerifyUpload comprueba que query contiene una firma vigente de PresignUpload para el archivo,
+	// el tipo y el tamaño recibidos
+	VerifyUpload(fileName string, query url.Values, contentType string, size int64) error
+}
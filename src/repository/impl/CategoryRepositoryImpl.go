@@ -7,8 +7,11 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"github.com/ruiborda/ecommerce-product-service/src/database"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
 	"github.com/ruiborda/ecommerce-product-service/src/model"
 	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/iterator"
 )
 
@@ -22,7 +25,7 @@ type CategoryRepositoryImpl struct {
 func NewCategoryRepositoryImpl() repository.CategoryRepository {
 	// Obtener la conexión de Firestore
 	firestoreClient := database.GetFirestoreClient()
-	
+
 	// Crear y devolver la instancia del repositorio
 	return &CategoryRepositoryImpl{
 		firestoreClient: firestoreClient,
@@ -31,122 +34,156 @@ func NewCategoryRepositoryImpl() repository.CategoryRepository {
 }
 
 // CreateCategory crea una nueva categoría en la base de datos
-func (r *CategoryRepositoryImpl) CreateCategory(category *model.Category) (*model.Category, error) {
-	ctx := context.Background()
-	
+func (r *CategoryRepositoryImpl) CreateCategory(ctx context.Context, category *model.Category) (*model.Category, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "CategoryRepository.CreateCategory", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("category.id", category.Id))
+
 	// Crear referencia al documento con el ID generado
 	docRef := r.firestoreClient.Collection(r.collectionName).Doc(category.Id)
-	
+
 	// Escribir el documento en Firestore
+	done := metrics.TrackFirestore("CategoryRepository", "CreateCategory")
 	_, err := docRef.Set(ctx, category)
+	done(err)
 	if err != nil {
-		slog.Error("Error creating category", "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error creating category", "error", err)
 		return nil, err
 	}
-	
+
 	return category, nil
 }
 
 // GetCategoryById obtiene una categoría por su ID
-func (r *CategoryRepositoryImpl) GetCategoryById(id string) (*model.Category, error) {
-	ctx := context.Background()
-	
+func (r *CategoryRepositoryImpl) GetCategoryById(ctx context.Context, id string) (*model.Category, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "CategoryRepository.GetCategoryById", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("category.id", id))
+
 	// Obtener referencia al documento
 	docRef := r.firestoreClient.Collection(r.collectionName).Doc(id)
+	done := metrics.TrackFirestore("CategoryRepository", "GetCategoryById")
 	docSnap, err := docRef.Get(ctx)
-	
+	done(err)
+
 	if err != nil {
 		if docSnap == nil || !docSnap.Exists() {
 			return nil, nil // No existe
 		}
-		slog.Error("Error fetching category", "id", id, "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error fetching category", "id", id, "error", err)
 		return nil, err
 	}
-	
+
 	// Mapear documento a modelo
 	var category model.Category
 	if err := docSnap.DataTo(&category); err != nil {
-		slog.Error("Error mapping category data", "id", id, "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error mapping category data", "id", id, "error", err)
 		return nil, err
 	}
-	
+
 	return &category, nil
 }
 
 // UpdateCategory actualiza una categoría existente
-func (r *CategoryRepositoryImpl) UpdateCategory(category *model.Category) (*model.Category, error) {
-	ctx := context.Background()
-	
+func (r *CategoryRepositoryImpl) UpdateCategory(ctx context.Context, category *model.Category) (*model.Category, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "CategoryRepository.UpdateCategory", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("category.id", category.Id))
+
 	// Obtener referencia al documento
 	docRef := r.firestoreClient.Collection(r.collectionName).Doc(category.Id)
-	
+
 	// Verificar si el documento existe
+	done := metrics.TrackFirestore("CategoryRepository", "UpdateCategory")
 	docSnap, err := docRef.Get(ctx)
 	if err != nil || !docSnap.Exists() {
+		done(err)
 		return nil, errors.New("category not found")
 	}
-	
+
 	// Actualizar el documento
 	_, err = docRef.Set(ctx, category)
+	done(err)
 	if err != nil {
-		slog.Error("Error updating category", "id", category.Id, "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error updating category", "id", category.Id, "error", err)
 		return nil, err
 	}
-	
+
 	return category, nil
 }
 
 // DeleteCategoryById elimina una categoría por su ID
-func (r *CategoryRepositoryImpl) DeleteCategoryById(id string) error {
-	ctx := context.Background()
-	
+func (r *CategoryRepositoryImpl) DeleteCategoryById(ctx context.Context, id string) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "CategoryRepository.DeleteCategoryById", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("category.id", id))
+
 	// Obtener referencia al documento
 	docRef := r.firestoreClient.Collection(r.collectionName).Doc(id)
-	
+
 	// Verificar si el documento existe
+	done := metrics.TrackFirestore("CategoryRepository", "DeleteCategoryById")
 	docSnap, err := docRef.Get(ctx)
 	if err != nil || !docSnap.Exists() {
+		done(err)
 		return errors.New("category not found")
 	}
-	
+
 	// Eliminar el documento
 	_, err = docRef.Delete(ctx)
+	done(err)
 	if err != nil {
-		slog.Error("Error deleting category", "id", id, "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error deleting category", "id", id, "error", err)
 		return err
 	}
-	
+
 	return nil
 }
 
 // GetCategories obtiene todas las categorías
-func (r *CategoryRepositoryImpl) GetCategories() ([]*model.Category, error) {
-	ctx := context.Background()
-	
+func (r *CategoryRepositoryImpl) GetCategories(ctx context.Context) ([]*model.Category, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "CategoryRepository.GetCategories", r.collectionName)
+	defer span.End()
+
 	// Crear un slice para almacenar los resultados
 	var categories []*model.Category
-	
+
 	// Obtener todos los documentos de la colección
+	done := metrics.TrackFirestore("CategoryRepository", "GetCategories")
 	iter := r.firestoreClient.Collection(r.collectionName).Documents(ctx)
+	defer iter.Stop()
 	for {
+		if err := ctx.Err(); err != nil {
+			done(err)
+			tracing.RecordError(span, err)
+			return nil, err
+		}
 		doc, err := iter.Next()
 		if err == iterator.Done {
+			done(nil)
 			break
 		}
 		if err != nil {
-			slog.Error("Error iterating categories", "error", err)
+			done(err)
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error iterating categories", "error", err)
 			return nil, err
 		}
-		
+
 		// Mapear documento a modelo
 		var category model.Category
 		if err := doc.DataTo(&category); err != nil {
-			slog.Error("Error mapping category data", "id", doc.Ref.ID, "error", err)
+			slog.ErrorContext(ctx, "Error mapping category data", "id", doc.Ref.ID, "error", err)
 			continue
 		}
-		
+
 		categories = append(categories, &category)
 	}
-	
+
 	return categories, nil
-}
\ No newline at end of file
+}
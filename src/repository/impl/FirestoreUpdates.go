@@ -0,0 +1,41 @@
+package impl
+
+import (
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+)
+
+// firestoreUpdates convierte un struct con etiquetas firestore en la lista de campos de un Update
+// equivalente a reemplazar el documento con Set: los campos omitempty con valor cero se eliminan
+// del documento en lugar de escribirse. Permite reemplazar un documento con precondiciones,
+// que Set no admite.
+func firestoreUpdates(value interface{}) []firestore.Update {
+	structValue := reflect.Indirect(reflect.ValueOf(value))
+	structType := structValue.Type()
+
+	var updates []firestore.Update
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("firestore"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldValue := structValue.Field(i)
+		if strings.Contains(options, "omitempty") && fieldValue.IsZero() {
+			updates = append(updates, firestore.Update{Path: name, Value: firestore.Delete})
+			continue
+		}
+		updates = append(updates, firestore.Update{Path: name, Value: fieldValue.Interface()})
+	}
+	return updates
+}
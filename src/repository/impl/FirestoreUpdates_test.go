@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+func TestFirestoreUpdates(t *testing.T) {
+	p := &model.Product{
+		Id:         "p1",
+		Name:       "Mug",
+		Price:      0,
+		Stock:      0,
+		Discount:   2.5,
+		UpdateTime: time.Now(),
+	}
+	updates := map[string]interface{}{}
+	for _, update := range firestoreUpdates(p) {
+		if _, duplicated := updates[update.Path]; duplicated {
+			t.Fatalf("path %s appears twice", update.Path)
+		}
+		updates[update.Path] = update.Value
+	}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		// Campos con valor
+		{path: "id", want: "p1"},
+		{path: "name", want: "Mug"},
+		{path: "discount", want: 2.5},
+		// Sin omitempty, el cero se escribe
+		{path: "price", want: float64(0)},
+		{path: "stock", want: 0},
+		// Con omitempty, el cero elimina el campo, como al reemplazar el documento con Set
+		{path: "description", want: firestore.Delete},
+		{path: "images", want: firestore.Delete},
+		{path: "deletedAt", want: firestore.Delete},
+	}
+	for _, test := range tests {
+		got, ok := updates[test.path]
+		if !ok {
+			t.Errorf("path %s missing", test.path)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s = %#v, want %#v", test.path, got, test.want)
+		}
+	}
+
+	// Los campos firestore:"-" no se escriben
+	for _, path := range []string{"-", "UpdateTime"} {
+		if _, ok := updates[path]; ok {
+			t.Errorf("path %s should be skipped", path)
+		}
+	}
+	if len(updates) != 19 {
+		t.Errorf("len(updates) = %d, want one per stored field (19)", len(updates))
+	}
+}
@@ -0,0 +1,122 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/ruiborda/ecommerce-product-service/src/database"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IdempotencyRepositoryImpl guarda las claves de idempotencia en Firestore. Los registros
+// caducados se ignoran al leerlos; para eliminarlos se puede activar una política TTL de
+// Firestore sobre el campo expiresAt de la colección.
+type IdempotencyRepositoryImpl struct {
+	collectionName string
+}
+
+func NewIdempotencyRepositoryImpl() *IdempotencyRepositoryImpl {
+	return &IdempotencyRepositoryImpl{
+		collectionName: "idempotency_keys",
+	}
+}
+
+func (r *IdempotencyRepositoryImpl) GetRecord(ctx context.Context, key string) (*model.IdempotencyRecord, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "IdempotencyRepository.GetRecord", r.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("IdempotencyRepository", "GetRecord")
+	docSnapshot, err := firestoreClient.Collection(r.collectionName).Doc(key).Get(ctx)
+	done(err)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting idempotency record", "error", err)
+		return nil, err
+	}
+
+	var record model.IdempotencyRecord
+	if err := docSnapshot.DataTo(&record); err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error mapping idempotency record", "error", err)
+		return nil, err
+	}
+
+	if record.IsExpired(time.Now()) {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *IdempotencyRepositoryImpl) ReserveRecord(ctx context.Context, record *model.IdempotencyRecord) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "IdempotencyRepository.ReserveRecord", r.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+	docRef := firestoreClient.Collection(r.collectionName).Doc(record.Key)
+
+	// Un registro caducado que Firestore aún no ha eliminado no bloquea la clave
+	done := metrics.TrackFirestore("IdempotencyRepository", "ReserveRecord")
+	err := firestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnapshot, err := tx.Get(docRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			var existing model.IdempotencyRecord
+			if err := docSnapshot.DataTo(&existing); err != nil {
+				return err
+			}
+			if !existing.IsExpired(time.Now()) {
+				return repository.ErrIdempotencyKeyExists
+			}
+		}
+		return tx.Set(docRef, record)
+	})
+	done(err)
+	if err != nil && !errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error reserving idempotency record", "error", err)
+	}
+	return err
+}
+
+func (r *IdempotencyRepositoryImpl) SaveRecord(ctx context.Context, record *model.IdempotencyRecord) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "IdempotencyRepository.SaveRecord", r.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("IdempotencyRepository", "SaveRecord")
+	_, err := firestoreClient.Collection(r.collectionName).Doc(record.Key).Set(ctx, record)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error saving idempotency record", "error", err)
+	}
+	return err
+}
+
+func (r *IdempotencyRepositoryImpl) DeleteRecord(ctx context.Context, key string) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "IdempotencyRepository.DeleteRecord", r.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("IdempotencyRepository", "DeleteRecord")
+	_, err := firestoreClient.Collection(r.collectionName).Doc(key).Delete(ctx)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error deleting idempotency record", "error", err)
+	}
+	return err
+}
@@ -0,0 +1,216 @@
+package impl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/ruiborda/ecommerce-product-service/src/database"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// imageFileDeletingTimeout es el tiempo tras el que una eliminación que no terminó (por ejemplo
+// porque el proceso se detuvo) deja de bloquear el archivo
+const imageFileDeletingTimeout = 5 * time.Minute
+
+type ImageFileRepositoryImpl struct {
+	collectionName string
+}
+
+func NewImageFileRepositoryImpl() *ImageFileRepositoryImpl {
+	return &ImageFileRepositoryImpl{
+		collectionName: "image_files",
+	}
+}
+
+// docRef devuelve el documento del archivo. El ID es el hash del nombre porque las claves del
+// almacenamiento pueden contener "/", que no se admite en los IDs de Firestore
+func (r *ImageFileRepositoryImpl) docRef(fileName string) *firestore.DocumentRef {
+	id := sha256.Sum256([]byte(fileName))
+	return database.GetFirestoreClient().Collection(r.collectionName).Doc(hex.EncodeToString(id[:]))
+}
+
+// getImageFile lee el registro del archivo dentro de la transacción; devuelve nil si no existe
+func getImageFile(tx *firestore.Transaction, docRef *firestore.DocumentRef) (*model.ImageFile, error) {
+	docSnapshot, err := tx.Get(docRef)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var imageFile model.ImageFile
+	if err := docSnapshot.DataTo(&imageFile); err != nil {
+		return nil, err
+	}
+	return &imageFile, nil
+}
+
+func (r *ImageFileRepositoryImpl) AcquireImageFile(ctx context.Context, fileName string, productId string) (bool, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageFileRepository.AcquireImageFile", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("file.name", fileName), attribute.String("product.id", productId))
+	docRef := r.docRef(fileName)
+
+	var added bool
+	done := metrics.TrackFirestore("ImageFileRepository", "AcquireImageFile")
+	err := database.GetFirestoreClient().RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		added = false
+		imageFile, err := getImageFile(tx, docRef)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if imageFile == nil {
+			imageFile = &model.ImageFile{FileName: fileName}
+		}
+		if imageFile.IsDeleting() {
+			if now.Sub(imageFile.DeletingAt) < imageFileDeletingTimeout {
+				return repository.ErrImageFileDeleting
+			}
+			imageFile.DeletingAt = time.Time{}
+		}
+		if slices.Contains(imageFile.ProductIds, productId) {
+			return nil
+		}
+		added = true
+		imageFile.ProductIds = append(imageFile.ProductIds, productId)
+		imageFile.References = len(imageFile.ProductIds)
+		imageFile.UpdatedAt = now
+		return tx.Set(docRef, imageFile)
+	})
+	done(err)
+	if err != nil && !errors.Is(err, repository.ErrImageFileDeleting) {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error acquiring image file", "fileName", fileName, "error", err)
+	}
+	return added, err
+}
+
+func (r *ImageFileRepositoryImpl) ReleaseImageFile(ctx context.Context, fileName string, productId string) (bool, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageFileRepository.ReleaseImageFile", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("file.name", fileName), attribute.String("product.id", productId))
+	docRef := r.docRef(fileName)
+
+	var unreferenced bool
+	done := metrics.TrackFirestore("ImageFileRepository", "ReleaseImageFile")
+	err := database.GetFirestoreClient().RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		imageFile, err := getImageFile(tx, docRef)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if imageFile == nil {
+			imageFile = &model.ImageFile{FileName: fileName}
+		}
+		imageFile.ProductIds = slices.DeleteFunc(imageFile.ProductIds, func(id string) bool { return id == productId })
+		imageFile.References = len(imageFile.ProductIds)
+		unreferenced = imageFile.References == 0
+		// Una eliminación repetida (por ejemplo al reintentar la purga) vuelve a marcar el archivo
+		if unreferenced {
+			imageFile.DeletingAt = now
+		}
+		imageFile.UpdatedAt = now
+		return tx.Set(docRef, imageFile)
+	})
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error releasing image file", "fileName", fileName, "error", err)
+	}
+	return unreferenced, err
+}
+
+func (r *ImageFileRepositoryImpl) MarkImageFileDeleting(ctx context.Context, fileName string) (bool, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageFileRepository.MarkImageFileDeleting", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("file.name", fileName))
+	docRef := r.docRef(fileName)
+
+	var marked bool
+	done := metrics.TrackFirestore("ImageFileRepository", "MarkImageFileDeleting")
+	err := database.GetFirestoreClient().RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		marked = false
+		imageFile, err := getImageFile(tx, docRef)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if imageFile == nil {
+			imageFile = &model.ImageFile{FileName: fileName}
+		}
+		if imageFile.References > 0 || (imageFile.IsDeleting() && now.Sub(imageFile.DeletingAt) < imageFileDeletingTimeout) {
+			return nil
+		}
+		marked = true
+		imageFile.DeletingAt = now
+		imageFile.UpdatedAt = now
+		return tx.Set(docRef, imageFile)
+	})
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error marking image file for deletion", "fileName", fileName, "error", err)
+	}
+	return marked, err
+}
+
+func (r *ImageFileRepositoryImpl) GetImageFile(ctx context.Context, fileName string) (*model.ImageFile, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageFileRepository.GetImageFile", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("file.name", fileName))
+
+	done := metrics.TrackFirestore("ImageFileRepository", "GetImageFile")
+	docSnapshot, err := r.docRef(fileName).Get(ctx)
+	done(err)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting image file", "fileName", fileName, "error", err)
+		return nil, err
+	}
+	var imageFile model.ImageFile
+	if err := docSnapshot.DataTo(&imageFile); err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error mapping image file data", "fileName", fileName, "error", err)
+		return nil, err
+	}
+	return &imageFile, nil
+}
+
+func (r *ImageFileRepositoryImpl) DeleteImageFile(ctx context.Context, fileName string) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageFileRepository.DeleteImageFile", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("file.name", fileName))
+	docRef := r.docRef(fileName)
+
+	// Si alguien referenció el archivo después de que caducara la marca, el registro se conserva
+	done := metrics.TrackFirestore("ImageFileRepository", "DeleteImageFile")
+	err := database.GetFirestoreClient().RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		imageFile, err := getImageFile(tx, docRef)
+		if err != nil || imageFile == nil || !imageFile.IsDeleting() || imageFile.References > 0 {
+			return err
+		}
+		return tx.Delete(docRef)
+	})
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error deleting image file", "fileName", fileName, "error", err)
+	}
+	return err
+}
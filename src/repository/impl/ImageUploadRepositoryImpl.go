@@ -0,0 +1,122 @@
+package impl
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/database"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ImageUploadRepositoryImpl struct {
+	collectionName string
+}
+
+func NewImageUploadRepositoryImpl() *ImageUploadRepositoryImpl {
+	return &ImageUploadRepositoryImpl{
+		collectionName: "image_uploads",
+	}
+}
+
+func (r *ImageUploadRepositoryImpl) SaveImageUpload(ctx context.Context, upload *model.ImageUpload) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageUploadRepository.SaveImageUpload", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("upload.id", upload.Id))
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ImageUploadRepository", "SaveImageUpload")
+	_, err := firestoreClient.Collection(r.collectionName).Doc(upload.Id).Set(ctx, upload)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error saving image upload", "id", upload.Id, "error", err)
+	}
+	return err
+}
+
+func (r *ImageUploadRepositoryImpl) GetImageUploadById(ctx context.Context, id string) (*model.ImageUpload, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageUploadRepository.GetImageUploadById", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("upload.id", id))
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ImageUploadRepository", "GetImageUploadById")
+	docSnapshot, err := firestoreClient.Collection(r.collectionName).Doc(id).Get(ctx)
+	done(err)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting image upload", "id", id, "error", err)
+		return nil, err
+	}
+
+	var upload model.ImageUpload
+	if err := docSnapshot.DataTo(&upload); err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error mapping image upload data", "id", id, "error", err)
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *ImageUploadRepositoryImpl) DeleteImageUpload(ctx context.Context, id string) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageUploadRepository.DeleteImageUpload", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("upload.id", id))
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ImageUploadRepository", "DeleteImageUpload")
+	_, err := firestoreClient.Collection(r.collectionName).Doc(id).Delete(ctx)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error deleting image upload", "id", id, "error", err)
+	}
+	return err
+}
+
+func (r *ImageUploadRepositoryImpl) GetImageUploadsExpiredBefore(ctx context.Context, before time.Time, limit int) ([]*model.ImageUpload, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImageUploadRepository.GetImageUploadsExpiredBefore", r.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ImageUploadRepository", "GetImageUploadsExpiredBefore")
+	documents := firestoreClient.Collection(r.collectionName).Where("expiresAt", "<", before).Limit(limit).Documents(ctx)
+	defer documents.Stop()
+
+	var uploads []*model.ImageUpload
+	for {
+		docSnapshot, err := documents.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			done(err)
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error getting expired image uploads", "error", err)
+			return nil, err
+		}
+
+		var upload model.ImageUpload
+		if err := docSnapshot.DataTo(&upload); err != nil {
+			done(err)
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error mapping image upload data", "id", docSnapshot.Ref.ID, "error", err)
+			return nil, err
+		}
+		uploads = append(uploads, &upload)
+	}
+	done(nil)
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(uploads)))
+
+	return uploads, nil
+}
@@ -0,0 +1,106 @@
+package impl
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ruiborda/ecommerce-product-service/src/database"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ImportJobRepositoryImpl struct {
+	collectionName string
+}
+
+func NewImportJobRepositoryImpl() *ImportJobRepositoryImpl {
+	return &ImportJobRepositoryImpl{
+		collectionName: "import_jobs",
+	}
+}
+
+func (r *ImportJobRepositoryImpl) SaveImportJob(ctx context.Context, job *model.ImportJob) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImportJobRepository.SaveImportJob", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("import.job_id", job.Id))
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ImportJobRepository", "SaveImportJob")
+	_, err := firestoreClient.Collection(r.collectionName).Doc(job.Id).Set(ctx, job)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error saving import job", "id", job.Id, "error", err)
+	}
+	return err
+}
+
+func (r *ImportJobRepositoryImpl) GetImportJobById(ctx context.Context, id string) (*model.ImportJob, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImportJobRepository.GetImportJobById", r.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("import.job_id", id))
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ImportJobRepository", "GetImportJobById")
+	docSnapshot, err := firestoreClient.Collection(r.collectionName).Doc(id).Get(ctx)
+	done(err)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting import job", "id", id, "error", err)
+		return nil, err
+	}
+
+	var job model.ImportJob
+	if err := docSnapshot.DataTo(&job); err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error mapping import job data", "id", id, "error", err)
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *ImportJobRepositoryImpl) GetUnfinishedImportJobs(ctx context.Context) ([]*model.ImportJob, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ImportJobRepository.GetUnfinishedImportJobs", r.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ImportJobRepository", "GetUnfinishedImportJobs")
+	unfinished := []string{string(model.ImportJobPending), string(model.ImportJobRunning)}
+	documents := firestoreClient.Collection(r.collectionName).Where("status", "in", unfinished).Documents(ctx)
+	defer documents.Stop()
+
+	var jobs []*model.ImportJob
+	for {
+		docSnapshot, err := documents.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			done(err)
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error getting unfinished import jobs", "error", err)
+			return nil, err
+		}
+
+		var job model.ImportJob
+		if err := docSnapshot.DataTo(&job); err != nil {
+			done(err)
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error mapping import job data", "id", docSnapshot.Ref.ID, "error", err)
+			return nil, err
+		}
+		jobs = append(jobs, &job)
+	}
+	done(nil)
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(jobs)))
+
+	return jobs, nil
+}
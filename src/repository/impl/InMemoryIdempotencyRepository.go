@@ -0,0 +1,86 @@
+package impl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+// idempotencySweepInterval es cada cuánto se descartan los registros caducados en memoria
+const idempotencySweepInterval = time.Minute
+
+// InMemoryIdempotencyRepository guarda las claves de idempotencia en memoria. Solo es válido
+// con una única instancia del servicio y los registros se pierden al reiniciar.
+type InMemoryIdempotencyRepository struct {
+	mu        sync.Mutex
+	records   map[string]model.IdempotencyRecord
+	lastSweep time.Time
+}
+
+func NewInMemoryIdempotencyRepository() *InMemoryIdempotencyRepository {
+	return &InMemoryIdempotencyRepository{
+		records:   map[string]model.IdempotencyRecord{},
+		lastSweep: time.Now(),
+	}
+}
+
+// sweep descarta periódicamente los registros caducados para que el mapa no crezca sin límite.
+// Debe llamarse con el mutex tomado.
+func (r *InMemoryIdempotencyRepository) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < idempotencySweepInterval {
+		return
+	}
+	for key, record := range r.records {
+		if record.IsExpired(now) {
+			delete(r.records, key)
+		}
+	}
+	r.lastSweep = now
+}
+
+func (r *InMemoryIdempotencyRepository) GetRecord(_ context.Context, key string) (*model.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sweep(now)
+
+	record, ok := r.records[key]
+	if !ok || record.IsExpired(now) {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *InMemoryIdempotencyRepository) ReserveRecord(_ context.Context, record *model.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sweep(now)
+
+	if existing, ok := r.records[record.Key]; ok && !existing.IsExpired(now) {
+		return repository.ErrIdempotencyKeyExists
+	}
+	r.records[record.Key] = *record
+	return nil
+}
+
+func (r *InMemoryIdempotencyRepository) SaveRecord(_ context.Context, record *model.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[record.Key] = *record
+	return nil
+}
+
+func (r *InMemoryIdempotencyRepository) DeleteRecord(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key)
+	return nil
+}
@@ -0,0 +1,163 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+// InMemoryStorageRepository guarda los archivos en memoria y los sirve en la ruta estática del
+// servicio. Pensado para pruebas y desarrollo: solo es válido con una única instancia del servicio
+// y los archivos se pierden al reiniciar.
+type InMemoryStorageRepository struct {
+	servedStorage
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+// memoryFile es un archivo guardado en memoria
+type memoryFile struct {
+	data         []byte
+	contentType  string
+	lastModified time.Time
+}
+
+func NewInMemoryStorageRepository(served servedStorage) *InMemoryStorageRepository {
+	return &InMemoryStorageRepository{
+		servedStorage: served,
+		files:         map[string]memoryFile{},
+	}
+}
+
+func (r *InMemoryStorageRepository) UploadStream(ctx context.Context, body io.Reader, contentType string, extension string) (string, int64, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		metrics.ObserveStorageOperation("upload", int64(len(data)), err)
+		return "", int64(len(data)), err
+	}
+	fileName := uuid.New().String() + extension
+	return fileName, int64(len(data)), r.PutFile(ctx, fileName, data, contentType)
+}
+
+func (r *InMemoryStorageRepository) PutFile(_ context.Context, fileName string, data []byte, contentType string) error {
+	if !validFileName(fileName) {
+		return fmt.Errorf("%w: invalid file name %q", repository.ErrInvalidFile, fileName)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files[fileName] = memoryFile{
+		data:         bytes.Clone(data),
+		contentType:  contentType,
+		lastModified: time.Now(),
+	}
+	metrics.ObserveStorageOperation("upload", int64(len(data)), nil)
+	return nil
+}
+
+func (r *InMemoryStorageRepository) MoveFile(_ context.Context, source string, destination string) error {
+	if !validFileName(destination) {
+		return fmt.Errorf("%w: invalid file name %q", repository.ErrInvalidFile, destination)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, ok := r.files[source]
+	if !ok {
+		err := fmt.Errorf("%w: %s", repository.ErrFileNotFound, source)
+		metrics.ObserveStorageOperation("move", 0, err)
+		return err
+	}
+	delete(r.files, source)
+	r.files[destination] = file
+	metrics.ObserveStorageOperation("move", 0, nil)
+	return nil
+}
+
+func (r *InMemoryStorageRepository) OpenFile(_ context.Context, fileName string) (io.ReadCloser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	file, ok := r.files[fileName]
+	if !ok {
+		err := fmt.Errorf("%w: %s", repository.ErrFileNotFound, fileName)
+		metrics.ObserveStorageOperation("download", 0, err)
+		return nil, err
+	}
+	metrics.ObserveStorageOperation("download", int64(len(file.data)), nil)
+	return memoryFileReader{bytes.NewReader(file.data)}, nil
+}
+
+// memoryFileReader permite leer un archivo en memoria con Seek, para servir peticiones Range
+type memoryFileReader struct {
+	*bytes.Reader
+}
+
+func (memoryFileReader) Close() error {
+	return nil
+}
+
+func (r *InMemoryStorageRepository) HeadObject(_ context.Context, fileName string) (*repository.HeadObject, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	file, ok := r.files[fileName]
+	if !ok {
+		err := fmt.Errorf("%w: %s", repository.ErrFileNotFound, fileName)
+		metrics.ObserveStorageOperation("head", 0, err)
+		return nil, err
+	}
+	metrics.ObserveStorageOperation("head", 0, nil)
+	return &repository.HeadObject{
+		FileName:      fileName,
+		ContentLength: int64(len(file.data)),
+		ContentType:   file.contentType,
+		LastModified:  file.lastModified.Unix(),
+	}, nil
+}
+
+func (r *InMemoryStorageRepository) PresignUpload(_ context.Context, fileName string, contentType string, size int64, expires time.Duration) (*repository.PresignedUpload, error) {
+	return r.presignUpload(fileName, contentType, size, expires)
+}
+
+// PresignDownload devuelve la URL pública del archivo: la ruta estática no exige firma para leer
+func (r *InMemoryStorageRepository) PresignDownload(_ context.Context, fileName string, _ time.Duration) (string, error) {
+	return r.fileURL(fileName)
+}
+
+func (r *InMemoryStorageRepository) DeleteFile(_ context.Context, fileName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.files, fileName)
+	metrics.ObserveStorageOperation("delete", 0, nil)
+	return nil
+}
+
+// ListFiles devuelve todos los archivos en una sola página, ordenados por nombre
+func (r *InMemoryStorageRepository) ListFiles(_ context.Context, fn func([]repository.HeadObject) error) error {
+	r.mu.RLock()
+	objects := make([]repository.HeadObject, 0, len(r.files))
+	for fileName, file := range r.files {
+		objects = append(objects, repository.HeadObject{
+			FileName:      fileName,
+			ContentLength: int64(len(file.data)),
+			ContentType:   file.contentType,
+			LastModified:  file.lastModified.Unix(),
+		})
+	}
+	r.mu.RUnlock()
+
+	metrics.ObserveStorageOperation("list", 0, nil)
+	slices.SortFunc(objects, func(a, b repository.HeadObject) int { return strings.Compare(a.FileName, b.FileName) })
+	return fn(objects)
+}
@@ -0,0 +1,251 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// localListPageSize es el número de archivos de cada página de ListFiles
+const localListPageSize = 1000
+
+// LocalStorageRepository guarda los archivos en un directorio local y los sirve en la ruta estática
+// del servicio, para desarrollar sin conexión. El tipo de cada archivo se deduce de su extensión.
+type LocalStorageRepository struct {
+	servedStorage
+	dir string
+}
+
+func NewLocalStorageRepository(dir string, served servedStorage) *LocalStorageRepository {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		slog.Error("Error creating local storage directory", "dir", dir, "error", err)
+	}
+	return &LocalStorageRepository{
+		servedStorage: served,
+		dir:           dir,
+	}
+}
+
+// filePath devuelve la ruta en disco de fileName, o un error si la clave sale del directorio
+func (r *LocalStorageRepository) filePath(fileName string) (string, error) {
+	if !validFileName(fileName) {
+		return "", fmt.Errorf("%w: invalid file name %q", repository.ErrInvalidFile, fileName)
+	}
+	return filepath.Join(r.dir, filepath.FromSlash(fileName)), nil
+}
+
+func (r *LocalStorageRepository) UploadStream(ctx context.Context, body io.Reader, contentType string, extension string) (string, int64, error) {
+	fileName := uuid.New().String() + extension
+	size, err := r.writeFile(ctx, "StorageRepository.UploadStream", fileName, body)
+	return fileName, size, err
+}
+
+func (r *LocalStorageRepository) PutFile(ctx context.Context, fileName string, data []byte, _ string) error {
+	_, err := r.writeFile(ctx, "StorageRepository.PutFile", fileName, bytes.NewReader(data))
+	return err
+}
+
+// writeFile escribe el contenido en un archivo temporal del mismo directorio y lo renombra al
+// terminar, de modo que nunca se sirve un archivo a medio escribir
+func (r *LocalStorageRepository) writeFile(ctx context.Context, spanName string, fileName string, body io.Reader) (size int64, err error) {
+	ctx, span := tracing.StartSpan(ctx, spanName, attribute.String("file.name", fileName))
+	defer span.End()
+	defer func() {
+		metrics.ObserveStorageOperation("upload", size, err)
+		span.SetAttributes(attribute.Int64("file.size", size))
+		if err != nil {
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error writing local file", "fileName", fileName, "error", err)
+		}
+	}()
+
+	target, err := r.filePath(fileName)
+	if err != nil {
+		return 0, err
+	}
+	if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(temp.Name())
+		}
+	}()
+
+	size, err = io.Copy(temp, body)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return size, err
+	}
+	err = os.Rename(temp.Name(), target)
+	return size, err
+}
+
+// MoveFile renombra el archivo, creando el directorio de destino si hace falta
+func (r *LocalStorageRepository) MoveFile(ctx context.Context, source string, destination string) error {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.MoveFile", attribute.String("file.name", destination))
+	defer span.End()
+
+	sourcePath, err := r.filePath(source)
+	if err != nil {
+		return err
+	}
+	destinationPath, err := r.filePath(destination)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(destinationPath), 0o755); err == nil {
+		err = os.Rename(sourcePath, destinationPath)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		err = fmt.Errorf("%w: %s", repository.ErrFileNotFound, source)
+	}
+	metrics.ObserveStorageOperation("move", 0, err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error moving local file", "source", source, "destination", destination, "error", err)
+	}
+	return err
+}
+
+func (r *LocalStorageRepository) OpenFile(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	_, span := tracing.StartSpan(ctx, "StorageRepository.OpenFile", attribute.String("file.name", fileName))
+	defer span.End()
+
+	filePath, err := r.filePath(fileName)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = fmt.Errorf("%w: %s", repository.ErrFileNotFound, fileName)
+	}
+	size := int64(0)
+	if err == nil {
+		if info, statErr := file.Stat(); statErr == nil {
+			size = info.Size()
+		}
+	}
+	metrics.ObserveStorageOperation("download", size, err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	return file, nil
+}
+
+func (r *LocalStorageRepository) HeadObject(ctx context.Context, fileName string) (*repository.HeadObject, error) {
+	_, span := tracing.StartSpan(ctx, "StorageRepository.HeadObject", attribute.String("file.name", fileName))
+	defer span.End()
+
+	filePath, err := r.filePath(fileName)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filePath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && !info.Mode().IsRegular()) {
+		err = fmt.Errorf("%w: %s", repository.ErrFileNotFound, fileName)
+	}
+	metrics.ObserveStorageOperation("head", 0, err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(fileName))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &repository.HeadObject{
+		FileName:      fileName,
+		ContentLength: info.Size(),
+		ContentType:   contentType,
+		LastModified:  info.ModTime().Unix(),
+	}, nil
+}
+
+func (r *LocalStorageRepository) PresignUpload(_ context.Context, fileName string, contentType string, size int64, expires time.Duration) (*repository.PresignedUpload, error) {
+	return r.presignUpload(fileName, contentType, size, expires)
+}
+
+// PresignDownload devuelve la URL pública del archivo: la ruta estática no exige firma para leer
+func (r *LocalStorageRepository) PresignDownload(_ context.Context, fileName string, _ time.Duration) (string, error) {
+	return r.fileURL(fileName)
+}
+
+func (r *LocalStorageRepository) DeleteFile(ctx context.Context, fileName string) error {
+	_, span := tracing.StartSpan(ctx, "StorageRepository.DeleteFile", attribute.String("file.name", fileName))
+	defer span.End()
+
+	filePath, err := r.filePath(fileName)
+	if err != nil {
+		return err
+	}
+	// Como en S3, borrar un archivo que no existe no es un error
+	err = os.Remove(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	metrics.ObserveStorageOperation("delete", 0, err)
+	tracing.RecordError(span, err)
+	return err
+}
+
+// ListFiles recorre el directorio y llama a fn con páginas de localListPageSize archivos
+func (r *LocalStorageRepository) ListFiles(ctx context.Context, fn func([]repository.HeadObject) error) error {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.ListFiles")
+	defer span.End()
+
+	page := make([]repository.HeadObject, 0, localListPageSize)
+	err := filepath.WalkDir(r.dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(r.dir, filePath)
+		if err != nil {
+			return err
+		}
+		page = append(page, repository.HeadObject{
+			FileName:      filepath.ToSlash(relative),
+			ContentLength: info.Size(),
+			LastModified:  info.ModTime().Unix(),
+		})
+		if len(page) < localListPageSize {
+			return nil
+		}
+		err = fn(page)
+		page = page[:0]
+		return err
+	})
+	if err == nil && len(page) > 0 {
+		err = fn(page)
+	}
+	metrics.ObserveStorageOperation("list", 0, err)
+	tracing.RecordError(span, err)
+	return err
+}
@@ -2,9 +2,16 @@
 package impl
 
 import (
+	"cloud.google.com/go/firestore"
 	"context"
+	"fmt"
 	"github.com/ruiborda/ecommerce-product-service/src/database"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
 	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"log/slog"
@@ -20,97 +27,501 @@ func NewProductRepositoryImpl() *ProductRepositoryImpl {
 	}
 }
 
-func (p *ProductRepositoryImpl) CreateProduct(product *model.Product) (*model.Product, error) {
-	ctx := context.Background()
+func (p *ProductRepositoryImpl) CreateProduct(ctx context.Context, product *model.Product) (*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.CreateProduct", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("product.id", product.Id))
 	firestoreClient := database.GetFirestoreClient()
 
 	// Creamos una referencia a la colección de productos
 	collection := firestoreClient.Collection(p.collectionName)
 
 	// Insertamos el documento con el ID generado previamente
-	_, err := collection.Doc(product.Id).Set(ctx, product)
+	done := metrics.TrackFirestore("ProductRepository", "CreateProduct")
+	result, err := collection.Doc(product.Id).Set(ctx, product)
+	done(err)
 	if err != nil {
-		slog.Error("Error creating product", "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error creating product", "error", err)
 		return nil, err
 	}
+	product.UpdateTime = result.UpdateTime
 
 	return product, nil
 }
 
-func (p *ProductRepositoryImpl) GetProductById(id string) (*model.Product, error) {
-	ctx := context.Background()
+func (p *ProductRepositoryImpl) GetProductById(ctx context.Context, id string) (*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetProductById", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("product.id", id))
 	firestoreClient := database.GetFirestoreClient()
 
 	// Obtenemos una referencia al documento del producto
 	docRef := firestoreClient.Collection(p.collectionName).Doc(id)
 
 	// Obtenemos el documento
+	done := metrics.TrackFirestore("ProductRepository", "GetProductById")
 	docSnapshot, err := docRef.Get(ctx)
+	done(err)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
-			slog.Info("Product not found", "id", id)
+			slog.InfoContext(ctx, "Product not found", "id", id)
 			return nil, nil
 		}
-		slog.Error("Error getting product", "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting product", "error", err)
 		return nil, err
 	}
 
 	var product model.Product
 	err = docSnapshot.DataTo(&product)
 	if err != nil {
-		slog.Error("Error mapping product data", "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error mapping product data", "error", err)
 		return nil, err
 	}
+	product.UpdateTime = docSnapshot.UpdateTime
+
+	// Los productos borrados lógicamente no existen para las lecturas
+	if product.IsDeleted() {
+		return nil, nil
+	}
+
+	return &product, nil
+}
+
+func (p *ProductRepositoryImpl) GetDeletedProductById(ctx context.Context, id string) (*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetDeletedProductById", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("product.id", id))
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ProductRepository", "GetDeletedProductById")
+	docSnapshot, err := firestoreClient.Collection(p.collectionName).Doc(id).Get(ctx)
+	done(err)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting deleted product", "error", err)
+		return nil, err
+	}
+
+	var product model.Product
+	if err := docSnapshot.DataTo(&product); err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error mapping product data", "error", err)
+		return nil, err
+	}
+	product.UpdateTime = docSnapshot.UpdateTime
+
+	// Solo se devuelven productos que están en la papelera
+	if !product.IsDeleted() {
+		return nil, nil
+	}
 
 	return &product, nil
 }
 
-func (p *ProductRepositoryImpl) UpdateProduct(product *model.Product) (*model.Product, error) {
-	ctx := context.Background()
+func (p *ProductRepositoryImpl) GetProductsDeletedBefore(ctx context.Context, before string) ([]*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetProductsDeletedBefore", p.collectionName)
+	defer span.End()
 	firestoreClient := database.GetFirestoreClient()
 
-	// Actualizamos el documento del producto
-	_, err := firestoreClient.Collection(p.collectionName).Doc(product.Id).Set(ctx, product)
+	done := metrics.TrackFirestore("ProductRepository", "GetProductsDeletedBefore")
+	products, err := readProducts(ctx, firestoreClient.Collection(p.collectionName).Where("deletedAt", "<=", before).Documents(ctx), true)
+	done(err)
 	if err != nil {
-		slog.Error("Error updating product", "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting deleted products", "error", err)
 		return nil, err
 	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(products)))
+
+	return products, nil
+}
+
+func (p *ProductRepositoryImpl) GetProductsByIds(ctx context.Context, ids []string) (map[string]*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetProductsByIds", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.Int("product.count", len(ids)))
+	firestoreClient := database.GetFirestoreClient()
+
+	docRefs := make([]*firestore.DocumentRef, 0, len(ids))
+	for _, id := range ids {
+		docRefs = append(docRefs, firestoreClient.Collection(p.collectionName).Doc(id))
+	}
+
+	done := metrics.TrackFirestore("ProductRepository", "GetProductsByIds")
+	docSnapshots, err := firestoreClient.GetAll(ctx, docRefs)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting products by ids", "error", err)
+		return nil, err
+	}
+
+	products := make(map[string]*model.Product, len(docSnapshots))
+	for _, docSnapshot := range docSnapshots {
+		if !docSnapshot.Exists() {
+			continue
+		}
+		var product model.Product
+		if err := docSnapshot.DataTo(&product); err != nil {
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error mapping product data", "id", docSnapshot.Ref.ID, "error", err)
+			return nil, err
+		}
+		product.UpdateTime = docSnapshot.UpdateTime
+		if !product.IsDeleted() {
+			products[docSnapshot.Ref.ID] = &product
+		}
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(products)))
+
+	return products, nil
+}
+
+func (p *ProductRepositoryImpl) WriteProducts(ctx context.Context, products []*model.Product) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.WriteProducts", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.Int("product.count", len(products)))
+	if len(products) > repository.MaxBatchWrites {
+		return fmt.Errorf("a batch cannot contain more than %d writes", repository.MaxBatchWrites)
+	}
+	firestoreClient := database.GetFirestoreClient()
+
+	// Se usa WriteBatch (en lugar de una transacción) porque devuelve el UpdateTime
+	// de cada escritura, necesario para el ETag de los productos escritos
+	batch := firestoreClient.Batch()
+	for _, product := range products {
+		docRef := firestoreClient.Collection(p.collectionName).Doc(product.Id)
+		if product.UpdateTime.IsZero() {
+			batch.Create(docRef, product)
+		} else {
+			batch.Update(docRef, firestoreUpdates(product), firestore.LastUpdateTime(product.UpdateTime))
+		}
+	}
+
+	done := metrics.TrackFirestore("ProductRepository", "WriteProducts")
+	results, err := batch.Commit(ctx)
+	if status.Code(err) == codes.FailedPrecondition {
+		done(nil)
+		slog.InfoContext(ctx, "Products were modified concurrently", "count", len(products))
+		return repository.ErrVersionConflict
+	}
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error writing products batch", "error", err)
+		return err
+	}
+	for i, result := range results {
+		products[i].UpdateTime = result.UpdateTime
+	}
+
+	return nil
+}
+
+func (p *ProductRepositoryImpl) UpdateProduct(ctx context.Context, product *model.Product) (*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.UpdateProduct", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("product.id", product.Id))
+	firestoreClient := database.GetFirestoreClient()
+
+	docRef := firestoreClient.Collection(p.collectionName).Doc(product.Id)
+
+	// Reemplazamos el documento; si se leyó antes, solo si su UpdateTime no ha cambiado
+	done := metrics.TrackFirestore("ProductRepository", "UpdateProduct")
+	var result *firestore.WriteResult
+	var err error
+	if product.UpdateTime.IsZero() {
+		result, err = docRef.Set(ctx, product)
+	} else {
+		result, err = docRef.Update(ctx, firestoreUpdates(product), firestore.LastUpdateTime(product.UpdateTime))
+	}
+	if status.Code(err) == codes.FailedPrecondition {
+		done(nil)
+		slog.InfoContext(ctx, "Product was modified concurrently", "id", product.Id)
+		return nil, repository.ErrVersionConflict
+	}
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error updating product", "error", err)
+		return nil, err
+	}
+	product.UpdateTime = result.UpdateTime
 
 	return product, nil
 }
 
-func (p *ProductRepositoryImpl) DeleteProductById(id string) error {
-	ctx := context.Background()
+func (p *ProductRepositoryImpl) DeleteProductById(ctx context.Context, id string) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.DeleteProductById", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.String("product.id", id))
 	firestoreClient := database.GetFirestoreClient()
 
 	// Eliminamos el documento del producto
+	done := metrics.TrackFirestore("ProductRepository", "DeleteProductById")
 	_, err := firestoreClient.Collection(p.collectionName).Doc(id).Delete(ctx)
+	done(err)
 	if err != nil {
-		slog.Error("Error deleting product", "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error deleting product", "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func (p *ProductRepositoryImpl) GetProducts() ([]*model.Product, error) {
-	ctx := context.Background()
+func (p *ProductRepositoryImpl) GetProducts(ctx context.Context) ([]*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetProducts", p.collectionName)
+	defer span.End()
 	firestoreClient := database.GetFirestoreClient()
 
-	// Obtenemos todos los documentos de la colección de productos
-	docs, err := firestoreClient.Collection(p.collectionName).Documents(ctx).GetAll()
+	// Recorremos los documentos de la colección; el iterador se aborta si se cancela el contexto
+	done := metrics.TrackFirestore("ProductRepository", "GetProducts")
+	products, err := readProducts(ctx, firestoreClient.Collection(p.collectionName).Documents(ctx), false)
+	done(err)
 	if err != nil {
-		slog.Error("Error getting products", "error", err)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting products", "error", err)
 		return nil, err
 	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(products)))
 
-	var products []*model.Product
+	return products, nil
+}
+
+func (p *ProductRepositoryImpl) GetProductsBySkus(ctx context.Context, skus []string) ([]*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetProductsBySkus", p.collectionName)
+	defer span.End()
+	span.SetAttributes(attribute.Int("product.count", len(skus)))
+	if len(skus) == 0 {
+		return nil, nil
+	}
+	if len(skus) > repository.MaxSkusPerQuery {
+		return nil, fmt.Errorf("cannot query more than %d skus at once", repository.MaxSkusPerQuery)
+	}
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ProductRepository", "GetProductsBySkus")
+	products, err := readProducts(ctx, firestoreClient.Collection(p.collectionName).Where("sku", "in", skus).Documents(ctx), true)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting products by sku", "error", err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(products)))
+
+	return products, nil
+}
+
+func (p *ProductRepositoryImpl) ForEachProductChunk(ctx context.Context, categoryId string, chunkSize int, fn func([]*model.Product) error) error {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.ForEachProductChunk", p.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	// Ordenar por ID permite continuar cada página tras el último documento leído sin índices compuestos
+	query := firestoreClient.Collection(p.collectionName).OrderBy(firestore.DocumentID, firestore.Asc).Limit(chunkSize)
+	if categoryId != "" {
+		query = query.Where("categoryId", "==", categoryId)
+	}
+
+	returnedRows := 0
+	undecodable := 0
+	for cursor := query; ; {
+		done := metrics.TrackFirestore("ProductRepository", "ForEachProductChunk")
+		docs, err := readProductChunk(ctx, cursor.Documents(ctx))
+		done(err)
+		if err != nil {
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error getting products chunk", "error", err)
+			return err
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		products := make([]*model.Product, 0, len(docs))
+		for _, doc := range docs {
+			var product model.Product
+			if err := doc.DataTo(&product); err != nil {
+				slog.ErrorContext(ctx, "Error mapping product data", "id", doc.Ref.ID, "error", err)
+				undecodable++
+				continue
+			}
+			if product.IsDeleted() {
+				continue
+			}
+			product.UpdateTime = doc.UpdateTime
+			products = append(products, &product)
+		}
+		returnedRows += len(products)
+		if len(products) > 0 {
+			if err := fn(products); err != nil {
+				return err
+			}
+		}
+
+		if len(docs) < chunkSize {
+			break
+		}
+		cursor = query.StartAfter(docs[len(docs)-1])
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", returnedRows))
+
+	if undecodable > 0 {
+		return fmt.Errorf("%w: %d documents", repository.ErrUndecodableProducts, undecodable)
+	}
+	return nil
+}
+
+func (p *ProductRepositoryImpl) GetPublishedProducts(ctx context.Context, categoryId string, startAfterId string, limit int) ([]*model.Product, string, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetPublishedProducts", p.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	// Como ForEachProductChunk, la página continúa tras el último ID leído
+	query := firestoreClient.Collection(p.collectionName).
+		Where("status", "==", string(model.ProductStatusPublished)).
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Limit(limit)
+	if categoryId != "" {
+		query = query.Where("categoryId", "==", categoryId)
+	}
+	if startAfterId != "" {
+		query = query.StartAfter(startAfterId)
+	}
+
+	done := metrics.TrackFirestore("ProductRepository", "GetPublishedProducts")
+	docs, err := readProductChunk(ctx, query.Documents(ctx))
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting published products", "error", err)
+		return nil, "", err
+	}
+
+	products := make([]*model.Product, 0, len(docs))
 	for _, doc := range docs {
 		var product model.Product
 		if err := doc.DataTo(&product); err != nil {
-			slog.Error("Error mapping product data", "error", err)
+			slog.ErrorContext(ctx, "Error mapping product data", "id", doc.Ref.ID, "error", err)
+			continue
+		}
+		if product.IsDeleted() {
+			continue
+		}
+		product.UpdateTime = doc.UpdateTime
+		products = append(products, &product)
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(products)))
+
+	lastId := ""
+	if len(docs) == limit {
+		lastId = docs[len(docs)-1].Ref.ID
+	}
+	return products, lastId, nil
+}
+
+// readProductChunk lee todos los documentos de una página; se aborta si se cancela el contexto
+func readProductChunk(ctx context.Context, iter *firestore.DocumentIterator) ([]*firestore.DocumentSnapshot, error) {
+	defer iter.Stop()
+
+	var docs []*firestore.DocumentSnapshot
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func (p *ProductRepositoryImpl) GetProductsByAuthorId(ctx context.Context, authorId string) ([]*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository.GetProductsByAuthorId", p.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	// Filtramos por autor en Firestore
+	done := metrics.TrackFirestore("ProductRepository", "GetProductsByAuthorId")
+	products, err := readProducts(ctx, firestoreClient.Collection(p.collectionName).Where("authorId", "==", authorId).Documents(ctx), false)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting products by author", "error", err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(products)))
+
+	return products, nil
+}
+
+func (p *ProductRepositoryImpl) GetProductsScheduledToPublish(ctx context.Context, before string) ([]*model.Product, error) {
+	return p.getProductsScheduledBefore(ctx, "GetProductsScheduledToPublish", "publishAt", before)
+}
+
+func (p *ProductRepositoryImpl) GetProductsScheduledToUnpublish(ctx context.Context, before string) ([]*model.Product, error) {
+	return p.getProductsScheduledBefore(ctx, "GetProductsScheduledToUnpublish", "unpublishAt", before)
+}
+
+// getProductsScheduledBefore obtiene los productos cuya fecha programada (RFC 3339 en UTC,
+// comparable como texto) es anterior o igual a before
+func (p *ProductRepositoryImpl) getProductsScheduledBefore(ctx context.Context, method string, field string, before string) ([]*model.Product, error) {
+	ctx, span := tracing.StartFirestoreSpan(ctx, "ProductRepository."+method, p.collectionName)
+	defer span.End()
+	firestoreClient := database.GetFirestoreClient()
+
+	done := metrics.TrackFirestore("ProductRepository", method)
+	products, err := readProducts(ctx, firestoreClient.Collection(p.collectionName).Where(field, "<=", before).Documents(ctx), false)
+	done(err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting scheduled products", "field", field, "error", err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(products)))
+
+	return products, nil
+}
+
+// readProducts recorre el iterador y mapea cada documento a un producto; se aborta si se cancela el contexto.
+// Los productos borrados lógicamente solo se incluyen si includeDeleted es true.
+func readProducts(ctx context.Context, iter *firestore.DocumentIterator, includeDeleted bool) ([]*model.Product, error) {
+	defer iter.Stop()
+
+	var products []*model.Product
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var product model.Product
+		if err := doc.DataTo(&product); err != nil {
+			slog.ErrorContext(ctx, "Error mapping product data", "id", doc.Ref.ID, "error", err)
+			continue
+		}
+		if product.IsDeleted() && !includeDeleted {
 			continue
 		}
+		product.UpdateTime = doc.UpdateTime
 		products = append(products, &product)
 	}
 
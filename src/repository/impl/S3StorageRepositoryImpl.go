@@ -0,0 +1,357 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3StorageConfig configura un almacenamiento compatible con S3 (R2, MinIO, AWS S3...)
+type S3StorageConfig struct {
+	Endpoint        string // vacío usa el endpoint de AWS de la región
+	Bucket          string
+	Region          string
+	AccessKeyId     string
+	AccessKeySecret string
+	UsePathStyle    bool   // direcciones endpoint/bucket/clave en lugar de bucket.endpoint/clave (MinIO)
+	KeyPrefix       string // prefijo de las claves en el bucket, por ejemplo "products/"; vacío usa la raíz
+}
+
+// S3ClientConfig configura el cliente HTTP compartido y los reintentos de las operaciones
+type S3ClientConfig struct {
+	MaxIdleConns     int           // conexiones inactivas que se conservan con el endpoint
+	RetryMaxAttempts int           // intentos por operación, incluido el primero
+	RetryMaxBackoff  time.Duration // espera máxima entre intentos (backoff exponencial con jitter)
+}
+
+// S3StorageRepositoryImpl usa un único cliente S3, creado al arrancar, para todas las operaciones:
+// comparte el pool de conexiones y reintenta los errores transitorios (5xx, throttling y red)
+type S3StorageRepositoryImpl struct {
+	bucketName    string
+	keyPrefix     string
+	client        *s3.Client
+	presignClient *s3.PresignClient
+	uploader      *manager.Uploader
+}
+
+func NewS3StorageRepositoryImpl(storageConfig S3StorageConfig, clientConfig S3ClientConfig) *S3StorageRepositoryImpl {
+	options := []func(*config.LoadOptions) error{
+		config.WithRegion(storageConfig.Region),
+		config.WithHTTPClient(awshttp.NewBuildableClient().WithTransportOptions(func(transport *http.Transport) {
+			transport.MaxIdleConns = clientConfig.MaxIdleConns
+			transport.MaxIdleConnsPerHost = clientConfig.MaxIdleConns
+		})),
+		config.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = clientConfig.RetryMaxAttempts
+				o.MaxBackoff = clientConfig.RetryMaxBackoff
+				o.Backoff = retry.NewExponentialJitterBackoff(clientConfig.RetryMaxBackoff)
+			})
+		}),
+	}
+	// Sin credenciales explícitas se usa la cadena por defecto del SDK (variables AWS_*, rol de la instancia...)
+	if storageConfig.AccessKeyId != "" {
+		options = append(options, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(storageConfig.AccessKeyId, storageConfig.AccessKeySecret, ""),
+		))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		slog.Error("Error creating storage client", "error", err)
+		os.Exit(1)
+	}
+	otelaws.AppendMiddlewares(&cfg.APIOptions)
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if storageConfig.Endpoint != "" {
+			o.BaseEndpoint = aws.String(storageConfig.Endpoint)
+		}
+		o.UsePathStyle = storageConfig.UsePathStyle
+	})
+	keyPrefix := strings.Trim(storageConfig.KeyPrefix, "/")
+	if keyPrefix != "" {
+		keyPrefix += "/"
+	}
+	return &S3StorageRepositoryImpl{
+		bucketName:    storageConfig.Bucket,
+		keyPrefix:     keyPrefix,
+		client:        client,
+		presignClient: s3.NewPresignClient(client),
+		uploader:      manager.NewUploader(client),
+	}
+}
+
+// key devuelve la clave en el bucket de fileName: los nombres de archivo son relativos al prefijo
+func (this *S3StorageRepositoryImpl) key(fileName string) *string {
+	key := this.keyPrefix + fileName
+	return &key
+}
+
+func (this *S3StorageRepositoryImpl) UploadStream(ctx context.Context, body io.Reader, contentType string, extension string) (fileName string, size int64, err error) {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.UploadStream", attribute.String("file.content_type", contentType))
+	defer span.End()
+
+	fileName = uuid.New().String() + extension
+	span.SetAttributes(attribute.String("file.name", fileName))
+
+	// El uploader lee el cuerpo por partes de 5 MB: los archivos pequeños se suben con una sola
+	// petición y los grandes con una subida multiparte que se aborta si falla la lectura
+	counter := &countingReader{reader: body}
+	_, err = this.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      &this.bucketName,
+		Key:         this.key(fileName),
+		Body:        counter,
+		ContentType: &contentType,
+	})
+	metrics.ObserveStorageOperation("upload", counter.size, err)
+	span.SetAttributes(attribute.Int64("file.size", counter.size))
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error uploading file stream", "error", err)
+		return "", counter.size, err
+	}
+	return fileName, counter.size, nil
+}
+
+// countingReader cuenta los bytes leídos del cuerpo de una subida
+type countingReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (this *S3StorageRepositoryImpl) PutFile(ctx context.Context, fileName string, data []byte, contentType string) error {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.PutFile",
+		attribute.String("file.name", fileName),
+		attribute.String("file.content_type", contentType),
+		attribute.Int("file.size", len(data)),
+	)
+	defer span.End()
+
+	_, err := this.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &this.bucketName,
+		Key:         this.key(fileName),
+		Body:        bytes.NewReader(data),
+		ContentType: &contentType,
+	})
+	metrics.ObserveStorageOperation("upload", int64(len(data)), err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error uploading file", "fileName", fileName, "error", err)
+	}
+	return err
+}
+
+// MoveFile copia el objeto dentro del bucket y elimina el original: S3 no tiene una operación de
+// renombrado. Si falla el borrado el original queda duplicado, pero el destino ya es válido
+func (this *S3StorageRepositoryImpl) MoveFile(ctx context.Context, source string, destination string) error {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.MoveFile",
+		attribute.String("file.source", source),
+		attribute.String("file.name", destination),
+	)
+	defer span.End()
+
+	copySource := url.PathEscape(this.bucketName + "/" + *this.key(source))
+	_, err := this.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &this.bucketName,
+		Key:        this.key(destination),
+		CopySource: &copySource,
+	})
+	metrics.ObserveStorageOperation("move", 0, err)
+	if isS3NotFound(err) {
+		return fmt.Errorf("%w: %s", repository.ErrFileNotFound, source)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error copying file", "source", source, "destination", destination, "error", err)
+		return err
+	}
+	if err := this.DeleteFile(ctx, source); err != nil {
+		slog.ErrorContext(ctx, "Error deleting moved file", "fileName", source, "error", err)
+	}
+	return nil
+}
+
+func (this *S3StorageRepositoryImpl) OpenFile(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.OpenFile", attribute.String("file.name", fileName))
+	defer span.End()
+
+	response, err := this.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &this.bucketName,
+		Key:    this.key(fileName),
+	})
+	size := int64(0)
+	if err == nil && response.ContentLength != nil {
+		size = *response.ContentLength
+	}
+	metrics.ObserveStorageOperation("download", size, err)
+	if isS3NotFound(err) {
+		return nil, fmt.Errorf("%w: %s", repository.ErrFileNotFound, fileName)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error downloading file", "fileName", fileName, "error", err)
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (this *S3StorageRepositoryImpl) DeleteFile(ctx context.Context, fileName string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.DeleteFile", attribute.String("file.name", fileName))
+	defer span.End()
+
+	_, err = this.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &this.bucketName,
+		Key:    this.key(fileName),
+	})
+	metrics.ObserveStorageOperation("delete", 0, err)
+	tracing.RecordError(span, err)
+
+	return
+}
+
+// ListFiles lista con ListObjectsV2, en páginas de hasta 1000 objetos, solo las claves bajo el
+// prefijo configurado: el resto del bucket puede pertenecer a otros servicios
+func (this *S3StorageRepositoryImpl) ListFiles(ctx context.Context, fn func([]repository.HeadObject) error) error {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.ListFiles")
+	defer span.End()
+
+	listed := 0
+	paginator := s3.NewListObjectsV2Paginator(this.client, &s3.ListObjectsV2Input{
+		Bucket: &this.bucketName,
+		Prefix: &this.keyPrefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		metrics.ObserveStorageOperation("list", 0, err)
+		if err != nil {
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error listing files", "error", err)
+			return err
+		}
+		objects := make([]repository.HeadObject, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, repository.HeadObject{
+				FileName:      strings.TrimPrefix(aws.ToString(object.Key), this.keyPrefix),
+				ContentLength: aws.ToInt64(object.Size),
+				LastModified:  aws.ToTime(object.LastModified).Unix(),
+			})
+		}
+		listed += len(objects)
+		if err := fn(objects); err != nil {
+			return err
+		}
+	}
+	span.SetAttributes(attribute.Int("files.listed", listed))
+	return nil
+}
+
+func (this *S3StorageRepositoryImpl) HeadObject(ctx context.Context, fileName string) (*repository.HeadObject, error) {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.HeadObject", attribute.String("file.name", fileName))
+	defer span.End()
+
+	response, err := this.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &this.bucketName,
+		Key:    this.key(fileName),
+	})
+	metrics.ObserveStorageOperation("head", 0, err)
+	if isS3NotFound(err) {
+		return nil, fmt.Errorf("%w: %s", repository.ErrFileNotFound, fileName)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting file metadata", "fileName", fileName, "error", err)
+		return nil, err
+	}
+
+	return &repository.HeadObject{
+		FileName:      fileName,
+		ContentLength: aws.ToInt64(response.ContentLength),
+		ContentType:   aws.ToString(response.ContentType),
+		LastModified:  aws.ToTime(response.LastModified).Unix(),
+	}, nil
+}
+
+// isS3NotFound indica si el almacenamiento respondió 404; HeadObject no incluye un código de error
+// en la respuesta, así que se comprueba el estado HTTP
+func isS3NotFound(err error) bool {
+	var responseError *awshttp.ResponseError
+	return errors.As(err, &responseError) && responseError.HTTPStatusCode() == http.StatusNotFound
+}
+
+func (this *S3StorageRepositoryImpl) PresignUpload(ctx context.Context, fileName string, contentType string, size int64, expires time.Duration) (*repository.PresignedUpload, error) {
+	ctx, span := tracing.StartSpan(ctx, "StorageRepository.PresignUpload",
+		attribute.String("file.name", fileName),
+		attribute.String("file.content_type", contentType),
+		attribute.Int64("file.size", size),
+	)
+	defer span.End()
+
+	// La firma incluye Content-Type y Content-Length, de modo que la URL solo sirve para ese archivo
+	expiresAt := time.Now().Add(expires)
+	request, err := this.presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &this.bucketName,
+		Key:           this.key(fileName),
+		ContentType:   &contentType,
+		ContentLength: &size,
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error presigning upload", "error", err)
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(request.SignedHeader))
+	for name := range request.SignedHeader {
+		// El cliente HTTP envía Host a partir de la URL
+		if name != "Host" {
+			headers[name] = request.SignedHeader.Get(name)
+		}
+	}
+	return &repository.PresignedUpload{
+		URL:       request.URL,
+		Method:    request.Method,
+		Headers:   headers,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PresignDownload firma la URL localmente, sin peticiones al almacenamiento; no abre un span porque
+// se llama por cada imagen de las respuestas
+func (this *S3StorageRepositoryImpl) PresignDownload(ctx context.Context, fileName string, expires time.Duration) (string, error) {
+	request, err := this.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: &this.bucketName,
+		Key:    this.key(fileName),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error presigning download", "file", fileName, "error", err)
+		return "", err
+	}
+	return request.URL, nil
+}
@@ -0,0 +1,166 @@
+package impl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+var (
+	storageOnce       sync.Once
+	storageRepository repository.StorageRepository
+)
+
+// NewStorageRepository devuelve el almacenamiento de objetos elegido en STORAGE_BACKEND: "s3" (por
+// defecto; R2 o cualquier servicio compatible), "local" (un directorio que sirve el propio servicio) o
+// "memory" (una sola instancia, se pierde al reiniciar). Todas las llamadas comparten la misma
+// instancia para que los servicios vean los mismos archivos con el backend en memoria.
+func NewStorageRepository() repository.StorageRepository {
+	storageOnce.Do(func() {
+		storageRepository = newStorageRepository()
+	})
+	return storageRepository
+}
+
+func newStorageRepository() repository.StorageRepository {
+	switch backend := config.GetEnv("STORAGE_BACKEND", "s3"); backend {
+	case "local":
+		return NewLocalStorageRepository(config.GetEnv("STORAGE_LOCAL_DIR", "data/storage"), newServedStorage())
+	case "memory":
+		return NewInMemoryStorageRepository(newServedStorage())
+	case "s3":
+		return NewS3StorageRepositoryImpl(loadS3StorageConfig(), loadS3ClientConfig())
+	default:
+		slog.Warn("Unknown STORAGE_BACKEND, using s3", "value", backend)
+		return NewS3StorageRepositoryImpl(loadS3StorageConfig(), loadS3ClientConfig())
+	}
+}
+
+// loadS3StorageConfig lee la configuración S3. Sin STORAGE_S3_ENDPOINT se usa el endpoint de R2 de
+// R2_ACCOUNT_ID, y las credenciales de R2 si no se indican otras
+func loadS3StorageConfig() S3StorageConfig {
+	endpoint := config.GetEnv("STORAGE_S3_ENDPOINT", "")
+	if accountId := config.GetEnv("R2_ACCOUNT_ID", ""); endpoint == "" && accountId != "" {
+		endpoint = fmt.Sprintf("https://%s.r2.cloudflarestorage.com", accountId)
+	}
+	return S3StorageConfig{
+		Endpoint:        endpoint,
+		Bucket:          config.GetEnv("STORAGE_S3_BUCKET", "ecommerce"),
+		Region:          config.GetEnv("STORAGE_S3_REGION", "auto"),
+		AccessKeyId:     config.GetEnv("STORAGE_S3_ACCESS_KEY", config.GetEnv("R2_ACCESS_KEY", "")),
+		AccessKeySecret: config.GetEnv("STORAGE_S3_SECRET_KEY", config.GetEnv("R2_SECRET_KEY", "")),
+		UsePathStyle:    config.GetEnvBool("STORAGE_S3_PATH_STYLE", false),
+		KeyPrefix:       config.GetEnv("STORAGE_S3_KEY_PREFIX", ""),
+	}
+}
+
+// loadS3ClientConfig lee el tamaño del pool de conexiones y la política de reintentos del cliente S3
+func loadS3ClientConfig() S3ClientConfig {
+	clientConfig := S3ClientConfig{
+		MaxIdleConns:     config.GetEnvInt("STORAGE_MAX_IDLE_CONNS", 100),
+		RetryMaxAttempts: config.GetEnvInt("STORAGE_RETRY_MAX_ATTEMPTS", 3),
+		RetryMaxBackoff:  config.GetEnvDuration("STORAGE_RETRY_MAX_BACKOFF", 5*time.Second),
+	}
+	if clientConfig.MaxIdleConns <= 0 {
+		slog.Warn("Invalid STORAGE_MAX_IDLE_CONNS, using default", "value", clientConfig.MaxIdleConns)
+		clientConfig.MaxIdleConns = 100
+	}
+	if clientConfig.RetryMaxAttempts <= 0 {
+		slog.Warn("Invalid STORAGE_RETRY_MAX_ATTEMPTS, using default", "value", clientConfig.RetryMaxAttempts)
+		clientConfig.RetryMaxAttempts = 3
+	}
+	if clientConfig.RetryMaxBackoff <= 0 {
+		slog.Warn("Invalid STORAGE_RETRY_MAX_BACKOFF, using default", "value", clientConfig.RetryMaxBackoff)
+		clientConfig.RetryMaxBackoff = 5 * time.Second
+	}
+	return clientConfig
+}
+
+// validFileName indica si fileName es una clave relativa sin elementos vacíos, "." ni ".."; evita
+// que los backends que sirve el propio servicio lean o escriban fuera de su almacenamiento
+func validFileName(fileName string) bool {
+	return fileName != "." && fs.ValidPath(fileName) && !strings.Contains(fileName, `\`)
+}
+
+// servedStorage construye las URLs de los almacenamientos que sirve el propio servicio en la ruta de
+// STORAGE_PUBLIC_URL, y firma las subidas directas con HMAC-SHA256
+type servedStorage struct {
+	baseURL string
+	secret  []byte
+}
+
+// newServedStorage lee STORAGE_PUBLIC_URL y STORAGE_SIGNING_SECRET. Sin secreto se genera uno
+// aleatorio, de modo que las URLs firmadas dejan de valer al reiniciar
+func newServedStorage() servedStorage {
+	baseURL := config.GetEnv("STORAGE_PUBLIC_URL", "http://localhost:"+config.GetEnv("PORT", "8080")+"/storage")
+	secret := []byte(config.GetEnv("STORAGE_SIGNING_SECRET", ""))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return servedStorage{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+	}
+}
+
+func (s servedStorage) BaseURL() string {
+	return s.baseURL
+}
+
+// fileURL devuelve la URL pública de fileName
+func (s servedStorage) fileURL(fileName string) (string, error) {
+	return url.JoinPath(s.baseURL, fileName)
+}
+
+// presignUpload firma una subida PUT de fileName ligada al tipo y al tamaño
+func (s servedStorage) presignUpload(fileName string, contentType string, size int64, expires time.Duration) (*repository.PresignedUpload, error) {
+	fileURL, err := s.fileURL(fileName)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(expires)
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", s.signature(fileName, contentType, size, expiresAt.Unix()))
+	return &repository.PresignedUpload{
+		URL:    fileURL + "?" + query.Encode(),
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":   contentType,
+			"Content-Length": strconv.FormatInt(size, 10),
+		},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s servedStorage) VerifyUpload(fileName string, query url.Values, contentType string, size int64) error {
+	expiresAt, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return repository.ErrInvalidSignature
+	}
+	expected := s.signature(fileName, contentType, size, expiresAt)
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(expected)) {
+		return repository.ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s servedStorage) signature(fileName string, contentType string, size int64, expiresAt int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	_, _ = fmt.Fprintf(mac, "PUT\n%s\n%s\n%d\n%d", fileName, contentType, size, expiresAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
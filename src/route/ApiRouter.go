@@ -1,83 +1,239 @@
 package route
 
 import (
+	"log/slog"
+
 	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
 	"github.com/ruiborda/ecommerce-product-service/src/controller"
-	"github.com/ruiborda/ecommerce-user-service/src/middleware"
-	"github.com/ruiborda/ecommerce-user-service/src/model"
+	appMiddleware "github.com/ruiborda/ecommerce-product-service/src/middleware"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	repositoryImpl "github.com/ruiborda/ecommerce-product-service/src/repository/impl"
 )
 
 func ApiRouter(router *gin.Engine) {
 	productController := controller.NewProductController()
+	productImportController := controller.NewProductImportController()
+	productExportController := controller.NewProductExportController()
+	productImageController := controller.NewProductImageController()
 	categoryController := controller.NewCategoryController()
 
+	// Cada ruta exige el permiso configurado para ella en PermissionConfig
+	authorize := appMiddleware.Authorize(appMiddleware.LoadPermissionConfig())
+
+	// Las rutas que modifican datos aceptan Idempotency-Key para que los reintentos sean seguros
+	idempotent := appMiddleware.Idempotency(newIdempotencyRepository(), appMiddleware.LoadIdempotencyConfig())
+
 	router.POST(
 		"/api/v1/products",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
 		productController.CreateProduct,
 	)
 
+	router.POST(
+		"/api/v1/products/bulk",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productController.BulkProducts,
+	)
+
+	router.POST(
+		"/api/v1/products/import",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productImportController.ImportProducts,
+	)
+
+	router.GET(
+		"/api/v1/products/import/jobs/:id",
+		appMiddleware.RequireJWT(),
+		authorize,
+		productImportController.GetImportJob,
+	)
+
+	router.GET(
+		"/api/v1/products/export",
+		appMiddleware.RequireJWT(),
+		authorize,
+		productExportController.ExportProducts,
+	)
+
 	router.GET(
 		"/api/v1/products/:id",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
 		productController.GetProductById,
 	)
 
 	router.PUT(
 		"/api/v1/products/:id",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
 		productController.UpdateProduct,
 	)
 
+	router.PATCH(
+		"/api/v1/products/:id",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productController.PatchProduct,
+	)
+
 	router.DELETE(
 		"/api/v1/products/:id",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
 		productController.DeleteProduct,
 	)
 
+	router.POST(
+		"/api/v1/products/:id/restore",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productController.RestoreProduct,
+	)
+
 	router.GET(
 		"/api/v1/products/pages",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
 		productController.GetProductsPaginated,
 	)
+
+	router.GET(
+		"/api/v1/products/mine",
+		appMiddleware.RequireJWT(),
+		authorize,
+		productController.GetMyProductsPaginated,
+	)
 	router.PUT(
 		"/api/v1/products/:id/stock",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
 		productController.AdjustProductStock,
 	)
 
+	router.PUT(
+		"/api/v1/products/:id/status",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productController.ChangeProductStatus,
+	)
+
+	router.GET(
+		"/api/v1/products/:id/images",
+		appMiddleware.RequireJWT(),
+		authorize,
+		productImageController.GetProductImages,
+	)
+
+	router.POST(
+		"/api/v1/products/:id/images",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productImageController.AddProductImage,
+	)
+
+	router.PUT(
+		"/api/v1/products/:id/images",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productImageController.ReorderProductImages,
+	)
+
+	router.PATCH(
+		"/api/v1/products/:id/images/:imageId",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productImageController.UpdateProductImage,
+	)
+
+	router.DELETE(
+		"/api/v1/products/:id/images/:imageId",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productImageController.DeleteProductImage,
+	)
+
+	router.POST(
+		"/api/v1/products/:id/images/uploads",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productImageController.CreateImageUpload,
+	)
+
+	router.POST(
+		"/api/v1/products/:id/images/uploads/:uploadId/confirm",
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
+		productImageController.ConfirmImageUpload,
+	)
+
+	router.POST(
+		"/api/v1/admin/storage/reconcile",
+		appMiddleware.RequireJWT(),
+		authorize,
+		productImageController.ReconcileStorage,
+	)
+
 	router.GET(
 		"/api/v1/products/search",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
 		productController.SearchProducts,
 	)
 
 	// Rutas de categorías
 	router.POST(
 		"/api/v1/categories",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
 		categoryController.CreateCategory,
 	)
 
 	router.PUT(
 		"/api/v1/categories",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
+		idempotent,
 		categoryController.UpdateCategory,
 	)
 
 	router.GET(
 		"/api/v1/categories",
-		middleware.RequireJWT(),
-		middleware.RequirePermission(model.CreateUser),
+		appMiddleware.RequireJWT(),
+		authorize,
 		categoryController.GetCategories,
 	)
 }
+
+// newIdempotencyRepository elige el store de claves de idempotencia según IDEMPOTENCY_STORE:
+// "firestore" (por defecto, compartido entre instancias) o "memory" (una sola instancia)
+func newIdempotencyRepository() repository.IdempotencyRepository {
+	switch store := config.GetEnv("IDEMPOTENCY_STORE", "firestore"); store {
+	case "memory":
+		return repositoryImpl.NewInMemoryIdempotencyRepository()
+	case "firestore":
+		return repositoryImpl.NewIdempotencyRepositoryImpl()
+	default:
+		slog.Warn("Unknown IDEMPOTENCY_STORE, using firestore", "value", store)
+		return repositoryImpl.NewIdempotencyRepositoryImpl()
+	}
+}
@@ -0,0 +1,15 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+)
+
+// MetricsRouter expone las métricas de Prometheus
+func MetricsRouter(router *gin.Engine) {
+	if !config.GetEnvBool("METRICS_ENABLED", true) {
+		return
+	}
+	router.GET(config.GetEnv("METRICS_PATH", "/metrics"), gin.WrapH(promhttp.Handler()))
+}
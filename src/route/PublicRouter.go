@@ -0,0 +1,29 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/controller"
+	appMiddleware "github.com/ruiborda/ecommerce-product-service/src/middleware"
+)
+
+// PublicRouter registra el catálogo público de solo lectura, sin autenticación,
+// con su propio límite de peticiones por IP y cabeceras de caché
+func PublicRouter(router *gin.Engine) {
+	publicProductController := controller.NewPublicProductController()
+
+	public := router.Group(
+		"/api/v1/public",
+		appMiddleware.RateLimit(appMiddleware.RateLimitConfig{
+			RequestsPerSecond: config.GetEnvFloat("PUBLIC_RATE_LIMIT_RPS", 10),
+			Burst:             config.GetEnvInt("PUBLIC_RATE_LIMIT_BURST", 20),
+			MaxClients:        config.GetEnvInt("PUBLIC_RATE_LIMIT_MAX_CLIENTS", 10000),
+		}),
+		appMiddleware.CacheControl(config.GetEnvDuration("PUBLIC_CACHE_MAX_AGE", 60*time.Second)),
+	)
+
+	public.GET("/products", publicProductController.SearchPublicProducts)
+	public.GET("/products/:id", publicProductController.GetPublicProductById)
+}
@@ -0,0 +1,33 @@
+package route
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ruiborda/ecommerce-product-service/src/controller"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	repositoryImpl "github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+)
+
+// StorageRouter registra la ruta estática de los almacenamientos local y en memoria, en la ruta de
+// STORAGE_PUBLIC_URL. Con S3 no registra nada: los archivos se sirven desde el bucket o la CDN
+func StorageRouter(router *gin.Engine) {
+	storageRepository, ok := repositoryImpl.NewStorageRepository().(repository.ServedStorageRepository)
+	if !ok {
+		return
+	}
+	storageController := controller.NewStorageController(storageRepository)
+
+	prefix := "/storage"
+	if baseURL, err := url.Parse(storageRepository.BaseURL()); err == nil && strings.Trim(baseURL.Path, "/") != "" {
+		prefix = "/" + strings.Trim(baseURL.Path, "/")
+	} else {
+		slog.Warn("Invalid STORAGE_PUBLIC_URL path, serving files at default", "value", storageRepository.BaseURL(), "path", prefix)
+	}
+
+	router.GET(prefix+"/*fileName", storageController.GetFile)
+	router.HEAD(prefix+"/*fileName", storageController.GetFile)
+	router.PUT(prefix+"/*fileName", storageController.PutFile)
+}
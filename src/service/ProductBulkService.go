@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+)
+
+// ProductBulkService define las operaciones masivas sobre productos
+type ProductBulkService interface {
+	// ExecuteBulk aplica una lista de operaciones create/update/delete/stock-adjust en lotes
+	// de Firestore y devuelve el resultado de cada una
+	ExecuteBulk(ctx context.Context, request *product.BulkProductRequest, principal *auth.Principal) (*product.BulkProductResponse, error)
+}
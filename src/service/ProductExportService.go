@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/spreadsheet"
+)
+
+// ProductExportService define la exportación del catálogo a hojas de cálculo
+type ProductExportService interface {
+	// ExportProducts escribe en w los productos que cumplen los filtros de la búsqueda y el estado
+	// indicado, leyéndolos de la base de datos por bloques. Los errores de validación y de lectura
+	// de categorías se devuelven antes de escribir nada; un error posterior deja el archivo incompleto.
+	ExportProducts(ctx context.Context, format spreadsheet.Format, request *product.SearchProductsRequest, status string, w io.Writer) error
+}
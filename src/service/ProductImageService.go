@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+)
+
+// ProductImageService define la gestión de la galería de imágenes de un producto. Las operaciones
+// que modifican la galería admiten If-Match y aplican las mismas reglas de autoría que PUT.
+type ProductImageService interface {
+	// GetProductImages obtiene la galería de un producto ordenada por posición
+	GetProductImages(ctx context.Context, productId string) (*product.ProductImagesResponse, error)
+
+	// AddProductImage sube una imagen recibida en base64 y la añade a la galería
+	AddProductImage(ctx context.Context, productId string, request *product.AddProductImageRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error)
+
+	// UploadProductImage sube al almacenamiento, a medida que se recibe, una imagen enviada como
+	// archivo multipart y la añade a la galería. El tipo se obtiene del contenido: solo se admiten
+	// JPEG, PNG, WebP y AVIF.
+	UploadProductImage(ctx context.Context, productId string, request *product.UploadProductImageRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error)
+
+	// CreateImageUpload firma una URL para que el cliente suba una imagen directamente al
+	// almacenamiento, ligada al tipo y al tamaño declarados. La subida queda pendiente hasta que se
+	// confirma con ConfirmImageUpload.
+	CreateImageUpload(ctx context.Context, productId string, request *product.CreateImageUploadRequest, principal *auth.Principal) (*product.ImageUploadResponse, error)
+
+	// ConfirmImageUpload comprueba el tipo y el tamaño del objeto subido y lo añade a la galería
+	ConfirmImageUpload(ctx context.Context, productId string, uploadId string, request *product.ConfirmImageUploadRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error)
+
+	// SweepImageUploads elimina las subidas que vencieron antes de la fecha indicada sin confirmarse,
+	// junto con sus objetos. Devuelve el número de subidas eliminadas.
+	SweepImageUploads(ctx context.Context, expiredBefore time.Time) (int, error)
+
+	// BackfillRenditions genera las variantes que faltan a las imágenes de todos los productos; con
+	// force las regenera todas y con dryRun solo cuenta las que se generarían
+	BackfillRenditions(ctx context.Context, force bool, dryRun bool) (*product.RenditionBackfillReport, error)
+
+	// SyncImageReferences registra como referencias de cada producto, también de los de la papelera,
+	// los archivos de su galería. Es necesario una vez para las imágenes anteriores al recuento de
+	// referencias y se puede repetir: las referencias ya registradas se conservan.
+	SyncImageReferences(ctx context.Context) (*product.ImageReferenceSyncReport, error)
+
+	// ReconcileStorage cruza los archivos del almacenamiento con los campos de imagen de todos los
+	// productos e informa de los huérfanos y de las referencias rotas. Si se pide, elimina los
+	// huérfanos más antiguos que el periodo de gracia.
+	ReconcileStorage(ctx context.Context, request *product.StorageReconcileRequest) (*product.StorageReconcileReport, error)
+
+	// UpdateProductImage modifica el texto alternativo de una imagen o la convierte en la principal
+	UpdateProductImage(ctx context.Context, productId string, imageId string, request *product.UpdateProductImageRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error)
+
+	// ReorderProductImages cambia el orden de la galería
+	ReorderProductImages(ctx context.Context, productId string, request *product.ReorderProductImagesRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error)
+
+	// DeleteProductImage quita una imagen de la galería y elimina su archivo si ningún otro producto lo usa
+	DeleteProductImage(ctx context.Context, productId string, imageId string, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error)
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+)
+
+// ProductImportService define la importación de productos desde hojas de cálculo
+type ProductImportService interface {
+	// ImportProducts importa productos desde un CSV o XLSX haciendo upsert por SKU. Los archivos
+	// pequeños se procesan durante la petición; los grandes se procesan en segundo plano y se
+	// devuelve el trabajo pendiente con async a true.
+	ImportProducts(ctx context.Context, request *product.ImportProductsRequest, principal *auth.Principal) (response *product.ImportProductsResponse, async bool, err error)
+
+	// GetImportJob obtiene el estado y el informe de un trabajo de importación
+	GetImportJob(ctx context.Context, id string, principal *auth.Principal) (*product.ImportProductsResponse, error)
+
+	// FailStaleImportJobs marca como fallidos los trabajos pendientes o en curso que ya deberían
+	// haber terminado en now, porque la instancia que los ejecutaba se detuvo. Devuelve cuántos marcó.
+	FailStaleImportJobs(ctx context.Context, now time.Time) (int, error)
+}
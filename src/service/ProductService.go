@@ -1,29 +1,60 @@
 package service
 
 import (
+	"context"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
 	dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
 	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
 )
 
 type ProductService interface {
 	// CreateProduct crea un nuevo producto en el sistema
-	CreateProduct(createProductRequest *product.CreateProductRequest, authorId string) (*product.CreateProductResponse, error)
+	CreateProduct(ctx context.Context, createProductRequest *product.CreateProductRequest, principal *auth.Principal) (*product.CreateProductResponse, error)
 
 	// GetProductById obtiene un producto por su ID
-	GetProductById(id string) (*product.GetProductByIdResponse, error)
+	GetProductById(ctx context.Context, id string) (*product.GetProductByIdResponse, error)
 
 	// UpdateProduct actualiza un producto existente por su ID
-	UpdateProduct(id string, updateProductRequest *product.UpdateProductRequest) (*product.UpdateProductResponse, error)
+	UpdateProduct(ctx context.Context, id string, updateProductRequest *product.UpdateProductRequest, ifMatch string, principal *auth.Principal) (*product.UpdateProductResponse, error)
+
+	// PatchProduct aplica un JSON Merge Patch (RFC 7396) o un JSON Patch (RFC 6902) a un producto
+	PatchProduct(ctx context.Context, id string, patch []byte, patchType product.PatchType, ifMatch string, principal *auth.Principal) (*product.UpdateProductResponse, error)
+
+	// DeleteProduct borra lógicamente un producto por su ID
+	DeleteProduct(ctx context.Context, id string, ifMatch string, principal *auth.Principal) (*product.DeleteProductByIdResponse, error)
 
-	// DeleteProduct elimina un producto por su ID
-	DeleteProduct(id string) (*product.DeleteProductByIdResponse, error)
+	// RestoreProduct recupera un producto borrado lógicamente
+	RestoreProduct(ctx context.Context, id string, principal *auth.Principal) (*product.GetProductByIdResponse, error)
+
+	// PurgeDeletedProducts elimina definitivamente los productos borrados antes de la fecha indicada
+	PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time) (int, error)
 
 	// GetProductsPaginated obtiene una lista paginada de productos
-	GetProductsPaginated(pageable *dto.Pageable) (*dto.PaginationResponse[product.GetProductsPaginatedResponse], error)
+	GetProductsPaginated(ctx context.Context, pageable *dto.Pageable, status string) (*dto.PaginationResponse[product.GetProductsPaginatedResponse], error)
+
+	// GetMyProductsPaginated obtiene una lista paginada de los productos del usuario autenticado
+	GetMyProductsPaginated(ctx context.Context, pageable *dto.Pageable, status string, principal *auth.Principal) (*dto.PaginationResponse[product.GetProductsPaginatedResponse], error)
 
 	// AdjustProductStock ajusta el stock de un producto
-	AdjustProductStock(id string, request *product.AdjustProductStockRequest) (*product.AdjustProductStockResponse, error)
+	AdjustProductStock(ctx context.Context, id string, request *product.AdjustProductStockRequest, ifMatch string, principal *auth.Principal) (*product.AdjustProductStockResponse, error)
 
 	// SearchProducts busca productos con filtros avanzados
-	SearchProducts(request *product.SearchProductsRequest) (*dto.PaginationResponse[product.SearchProductsResponse], error)
+	SearchProducts(ctx context.Context, request *product.SearchProductsRequest) (*dto.PaginationResponse[product.SearchProductsResponse], error)
+
+	// ChangeProductStatus aplica una transición del ciclo de vida del producto
+	ChangeProductStatus(ctx context.Context, id string, request *product.ChangeProductStatusRequest, principal *auth.Principal) (*product.ChangeProductStatusResponse, error)
+
+	// ProcessScheduledStatusChanges publica y archiva los productos cuya programación ha vencido
+	ProcessScheduledStatusChanges(ctx context.Context, now time.Time) (int, error)
+
+	// GetPublicProductById obtiene la vista pública de un producto publicado
+	GetPublicProductById(ctx context.Context, id string) (*product.PublicProductResponse, error)
+
+	// SearchPublicProducts busca entre los productos publicados y devuelve su vista pública
+	SearchPublicProducts(ctx context.Context, request *product.SearchPublicProductsRequest) (*product.SearchPublicProductsResponse, error)
+
+	// BackfillStatus guarda como published el estado de los productos anteriores al ciclo de vida
+	BackfillStatus(ctx context.Context) (*product.StatusBackfillReport, error)
 }
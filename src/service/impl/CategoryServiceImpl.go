@@ -1,15 +1,18 @@
 package impl
 
 import (
-	"errors"
+	"context"
 	"log/slog"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/ruiborda/ecommerce-product-service/src/dto/category"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
 	"github.com/ruiborda/ecommerce-product-service/src/mapper"
 	"github.com/ruiborda/ecommerce-product-service/src/repository"
 	repoImpl "github.com/ruiborda/ecommerce-product-service/src/repository/impl"
 	"github.com/ruiborda/ecommerce-product-service/src/service"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
 )
 
 // CategoryServiceImpl implementa la interfaz CategoryService
@@ -27,14 +30,17 @@ func NewCategoryServiceImpl() service.CategoryService {
 }
 
 // CreateCategory implementa la creación de una nueva categoría
-func (s *CategoryServiceImpl) CreateCategory(createRequest *category.CreateCategoryRequest) (*category.CreateCategoryResponse, error) {
+func (s *CategoryServiceImpl) CreateCategory(ctx context.Context, createRequest *category.CreateCategoryRequest) (*category.CreateCategoryResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "CategoryService.CreateCategory")
+	defer span.End()
+
 	// Validar datos de entrada
 	if createRequest == nil {
-		return nil, errors.New("request cannot be nil")
+		return nil, exception.Validation(exception.CodeInvalidRequest, "Request body is required")
 	}
 
-	if createRequest.Name == "" {
-		return nil, errors.New("category name is required")
+	if strings.TrimSpace(createRequest.Name) == "" {
+		return nil, exception.Validation(exception.CodeValidationFailed, "The category data is not valid").WithField("name", "is required")
 	}
 
 	// Generar un ID único para la categoría
@@ -45,10 +51,11 @@ func (s *CategoryServiceImpl) CreateCategory(createRequest *category.CreateCateg
 	categoryModel.Id = categoryId
 
 	// Guardar la categoría en la base de datos
-	createdCategory, err := s.categoryRepository.CreateCategory(categoryModel)
+	createdCategory, err := s.categoryRepository.CreateCategory(ctx, categoryModel)
 	if err != nil {
-		slog.Error("Error creating category", "error", err)
-		return nil, err
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error creating category", "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
 	// Crear la respuesta usando el mapper
@@ -56,39 +63,47 @@ func (s *CategoryServiceImpl) CreateCategory(createRequest *category.CreateCateg
 }
 
 // UpdateCategory implementa la actualización de una categoría existente
-func (s *CategoryServiceImpl) UpdateCategory(updateRequest *category.UpdateCategoryRequest) (*category.UpdateCategoryResponse, error) {
+func (s *CategoryServiceImpl) UpdateCategory(ctx context.Context, updateRequest *category.UpdateCategoryRequest) (*category.UpdateCategoryResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "CategoryService.UpdateCategory")
+	defer span.End()
+
 	// Validar datos de entrada
 	if updateRequest == nil {
-		return nil, errors.New("request cannot be nil")
+		return nil, exception.Validation(exception.CodeInvalidRequest, "Request body is required")
 	}
 
+	validationError := exception.Validation(exception.CodeValidationFailed, "The category data is not valid")
 	if updateRequest.Id == "" {
-		return nil, errors.New("category id is required")
+		validationError.WithField("id", "is required")
 	}
-
-	if updateRequest.Name == "" {
-		return nil, errors.New("category name is required")
+	if strings.TrimSpace(updateRequest.Name) == "" {
+		validationError.WithField("name", "is required")
+	}
+	if len(validationError.Fields) > 0 {
+		return nil, validationError
 	}
 
 	// Verificar si la categoría existe
-	existingCategory, err := s.categoryRepository.GetCategoryById(updateRequest.Id)
+	existingCategory, err := s.categoryRepository.GetCategoryById(ctx, updateRequest.Id)
 	if err != nil {
-		slog.Error("Error fetching category for update", "id", updateRequest.Id, "error", err)
-		return nil, err
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error fetching category for update", "id", updateRequest.Id, "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
 	if existingCategory == nil {
-		return nil, errors.New("category not found")
+		return nil, exception.NotFound(exception.CodeCategoryNotFound, "Category not found")
 	}
 
 	// Actualizar sólo los campos proporcionados en la solicitud
 	existingCategory.Name = updateRequest.Name
 
 	// Guardar la categoría actualizada en la base de datos
-	updatedCategory, err := s.categoryRepository.UpdateCategory(existingCategory)
+	updatedCategory, err := s.categoryRepository.UpdateCategory(ctx, existingCategory)
 	if err != nil {
-		slog.Error("Error updating category", "id", updateRequest.Id, "error", err)
-		return nil, err
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error updating category", "id", updateRequest.Id, "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
 	// Crear la respuesta usando el mapper
@@ -96,13 +111,16 @@ func (s *CategoryServiceImpl) UpdateCategory(updateRequest *category.UpdateCateg
 }
 
 // GetAllCategoriesAsArray implementa la obtención de todas las categorías como un array
-func (s *CategoryServiceImpl) GetAllCategoriesAsArray() *[]*category.GetCategoriesResponse {
+func (s *CategoryServiceImpl) GetAllCategoriesAsArray(ctx context.Context) (*[]*category.GetCategoriesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "CategoryService.GetAllCategoriesAsArray")
+	defer span.End()
+
 	// Obtener todas las categorías desde el repositorio
-	categories, err := s.categoryRepository.GetCategories()
+	categories, err := s.categoryRepository.GetCategories(ctx)
 	if err != nil {
-		slog.Error("Error fetching categories", "error", err)
-		result := make([]*category.GetCategoriesResponse, 0)
-		return &result // Devolver puntero a array vacío en caso de error
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error fetching categories", "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
 	// Convertir las categorías a DTOs y devolverlas como array
@@ -116,5 +134,5 @@ func (s *CategoryServiceImpl) GetAllCategoriesAsArray() *[]*category.GetCategori
 		response = append(response, categoryDTO)
 	}
 
-	return &response
+	return &response, nil
 }
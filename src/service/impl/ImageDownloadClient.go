@@ -0,0 +1,89 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// maxImageDownloadRedirects limita las redirecciones que se siguen al descargar una imagen
+const maxImageDownloadRedirects = 5
+
+// errImageHostNotAllowed indica que una URL de imagen apunta a un host fuera de la lista permitida
+var errImageHostNotAllowed = errors.New("image host not allowed")
+
+// errImageAddressNotAllowed indica que el host de una URL de imagen resuelve a una dirección interna
+var errImageAddressNotAllowed = errors.New("image address not allowed")
+
+// sharedAddressSpace es el rango de NAT de operador (RFC 6598), que net.IP no considera privado
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// imageHostAllowlist son los hosts desde los que se pueden descargar imágenes. Vacía no permite
+// ninguno; "*" permite cualquier host cuya dirección sea pública.
+type imageHostAllowlist []string
+
+// allows indica si se pueden descargar imágenes del host
+func (a imageHostAllowlist) allows(host string) bool {
+	return slices.Contains(a, "*") || slices.Contains(a, strings.ToLower(host))
+}
+
+// newImageDownloadClient crea el cliente HTTP con el que se descargan imágenes de URLs indicadas
+// por los usuarios. Comprueba la lista de hosts también en cada redirección y rechaza las
+// conexiones a direcciones de loopback, privadas o de enlace local después de resolver el nombre,
+// para que no sirva para acceder a servicios internos ni a los metadatos de la nube.
+func newImageDownloadClient(allowlist imageHostAllowlist) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errImageAddressNotAllowed, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Sin proxy: la dirección comprobada al conectar debe ser la del host de la imagen
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: otelhttp.NewTransport(transport),
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= maxImageDownloadRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxImageDownloadRedirects)
+			}
+			if request.URL.Scheme != "http" && request.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", errImageHostNotAllowed, request.URL.Scheme)
+			}
+			if !allowlist.allows(request.URL.Hostname()) {
+				return fmt.Errorf("%w: redirect to %s", errImageHostNotAllowed, request.URL.Hostname())
+			}
+			return nil
+		},
+	}
+}
+
+// isPublicAddress indica si la dirección es accesible públicamente en Internet
+func isPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,256 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/imaging"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// imageFileAcquireAttempts limita las esperas a que termine la eliminación de un archivo que se
+// vuelve a subir justo cuando dejó de estar referenciado
+const imageFileAcquireAttempts = 5
+
+// imageStore guarda los archivos de la galería con claves derivadas del SHA-256 de su contenido, de
+// modo que la misma foto subida para varios productos se almacena una sola vez, y cuenta qué
+// productos usan cada archivo para eliminarlo solo cuando ninguno lo referencia
+type imageStore struct {
+	storageRepository   repository.StorageRepository
+	imageFileRepository repository.ImageFileRepository
+	renditions          *renditionGenerator
+}
+
+func newImageStore(storageRepository repository.StorageRepository, imageFileRepository repository.ImageFileRepository, renditions *renditionGenerator) *imageStore {
+	return &imageStore{
+		storageRepository:   storageRepository,
+		imageFileRepository: imageFileRepository,
+		renditions:          renditions,
+	}
+}
+
+// contentFileName devuelve la clave de un archivo a partir del hash de su contenido
+func contentFileName(sum []byte, extension string) string {
+	return hex.EncodeToString(sum) + extension
+}
+
+// isContentFileName indica si fileName es una clave derivada del contenido: el SHA-256 en
+// hexadecimal seguido de la extensión
+func isContentFileName(fileName string) bool {
+	sum, extension, found := strings.Cut(fileName, ".")
+	if !found || len(sum) != sha256.Size*2 || extension == "" || strings.ContainsAny(extension, "./") {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil && strings.ToLower(sum) == sum
+}
+
+// imageFileType detecta el tipo del archivo y su extensión a partir del contenido. Además de los
+// formatos de la galería admite cualquier tipo con extensión conocida, como hasta ahora al crear y
+// actualizar productos.
+func imageFileType(data []byte) (contentType string, extension string, err error) {
+	if contentType, extension, ok := sniffImage(data); ok {
+		return contentType, extension, nil
+	}
+	contentType = http.DetectContentType(data)
+	extensions, err := mime.ExtensionsByType(contentType)
+	if err != nil || len(extensions) == 0 {
+		return "", "", fmt.Errorf("%w: could not detect file extension", repository.ErrInvalidFile)
+	}
+	return contentType, extensions[0], nil
+}
+
+// store guarda la imagen sin metadatos como referencia de productId y devuelve su entrada de
+// galería, con un ID nuevo, las dimensiones y las variantes si el formato se puede leer (JPEG, PNG,
+// GIF y WebP). Si el archivo ya existe no se vuelve a subir; las variantes se regeneran con las
+// mismas claves.
+func (s *imageStore) store(ctx context.Context, productId string, data []byte) (model.ProductImage, error) {
+	ctx, span := tracing.StartSpan(ctx, "ImageStore.Store", attribute.String("product.id", productId), attribute.Int("file.size", len(data)))
+	defer span.End()
+
+	contentType, extension, err := imageFileType(data)
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+	// La clave se calcula sin los metadatos, que pueden incluir la ubicación GPS de la foto
+	var stripped bytes.Buffer
+	stripped.Grow(len(data))
+	if err := imaging.StripMetadata(&stripped, bytes.NewReader(data)); err != nil {
+		return model.ProductImage{}, fmt.Errorf("%w: %w", repository.ErrInvalidFile, err)
+	}
+	data = stripped.Bytes()
+	sum := sha256.Sum256(data)
+	fileName := contentFileName(sum[:], extension)
+	span.SetAttributes(attribute.String("file.name", fileName))
+
+	added, err := s.acquire(ctx, fileName, productId)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return model.ProductImage{}, err
+	}
+	existing, err := s.exists(ctx, fileName)
+	if err == nil && !existing {
+		err = s.storageRepository.PutFile(ctx, fileName, data, contentType)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		if added {
+			s.release(ctx, productId, nil, model.ProductImage{FileName: fileName})
+		}
+		return model.ProductImage{}, err
+	}
+	span.SetAttributes(attribute.Bool("file.deduplicated", existing))
+
+	newImage := model.ProductImage{
+		Id:        uuid.New().String(),
+		FileName:  fileName,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	newImage.Width, newImage.Height = imageDimensions(data)
+	s.renditions.apply(ctx, &newImage, data)
+	return newImage, nil
+}
+
+// storeStream sube body sin metadatos y sin cargarlo entero en memoria como referencia de
+// productId. Como la clave depende del contenido, el archivo se sube con un nombre temporal mientras se calcula el hash y
+// después se mueve a su clave, o se descarta si ese contenido ya existía.
+func (s *imageStore) storeStream(ctx context.Context, productId string, body io.Reader, contentType string, extension string) (string, error) {
+	ctx, span := tracing.StartSpan(ctx, "ImageStore.StoreStream", attribute.String("product.id", productId), attribute.String("file.content_type", contentType))
+	defer span.End()
+
+	stripped := imaging.NewMetadataStripper(body)
+	defer stripped.Close()
+	hash := sha256.New()
+	tempFileName, _, err := s.storageRepository.UploadStream(ctx, io.TeeReader(stripped, hash), contentType, extension)
+	if err != nil {
+		// Si la subida falló porque no se pudieron quitar los metadatos, la imagen no es válida
+		if closeErr := stripped.Close(); errors.Is(closeErr, imaging.ErrMetadataNotRemovable) {
+			err = fmt.Errorf("%w: %w", repository.ErrInvalidFile, closeErr)
+		}
+		tracing.RecordError(span, err)
+		return "", err
+	}
+	fileName := contentFileName(hash.Sum(nil), extension)
+	span.SetAttributes(attribute.String("file.name", fileName))
+
+	added, err := s.acquire(ctx, fileName, productId)
+	existing := false
+	if err == nil {
+		existing, err = s.exists(ctx, fileName)
+	}
+	if err == nil && !existing {
+		err = s.storageRepository.MoveFile(ctx, tempFileName, fileName)
+	}
+	if err != nil || existing {
+		deleteImageFiles(ctx, s.storageRepository, []string{tempFileName})
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		if added {
+			s.release(ctx, productId, nil, model.ProductImage{FileName: fileName})
+		}
+		return "", err
+	}
+	span.SetAttributes(attribute.Bool("file.deduplicated", existing))
+	return fileName, nil
+}
+
+// exists indica si el archivo ya está en el almacenamiento
+func (s *imageStore) exists(ctx context.Context, fileName string) (bool, error) {
+	_, err := s.storageRepository.HeadObject(ctx, fileName)
+	if errors.Is(err, repository.ErrFileNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// referencingProducts devuelve los IDs de los productos que referencian el archivo; ninguno si no
+// tiene registro o se está eliminando
+func (s *imageStore) referencingProducts(ctx context.Context, fileName string) ([]string, error) {
+	imageFile, err := s.imageFileRepository.GetImageFile(ctx, fileName)
+	if err != nil || imageFile == nil || imageFile.IsDeleting() {
+		return nil, err
+	}
+	return imageFile.ProductIds, nil
+}
+
+// acquire añade productId a las referencias del archivo. Si el archivo se está eliminando porque
+// dejó de estar referenciado espera a que termine, para no reutilizar un objeto que va a desaparecer.
+// Devuelve added=false si el producto ya lo referenciaba.
+func (s *imageStore) acquire(ctx context.Context, fileName string, productId string) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		added, err := s.imageFileRepository.AcquireImageFile(ctx, fileName, productId)
+		if !errors.Is(err, repository.ErrImageFileDeleting) || attempt == imageFileAcquireAttempts {
+			return added, err
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+		}
+	}
+}
+
+// release quita la referencia de productId a los archivos de las imágenes que el producto ya no usa
+// (referenced son los archivos que sigue referenciando en su versión guardada) y elimina, con sus
+// variantes, los que ningún producto referencia. Devuelve false si alguno no se pudo liberar o
+// eliminar; liberar de nuevo el mismo archivo reintenta la eliminación.
+func (s *imageStore) release(ctx context.Context, productId string, referenced []string, images ...model.ProductImage) bool {
+	ctx = context.WithoutCancel(ctx)
+	released := true
+	for _, image := range images {
+		if image.FileName == "" || slices.Contains(referenced, image.FileName) {
+			continue
+		}
+		unreferenced, err := s.imageFileRepository.ReleaseImageFile(ctx, image.FileName, productId)
+		if err != nil {
+			tracing.RecordError(trace.SpanFromContext(ctx), err)
+			released = false
+			continue
+		}
+		if unreferenced && !s.deleteImage(ctx, image) {
+			released = false
+		}
+	}
+	return released
+}
+
+// deleteImage elimina el archivo de una imagen que dejó de estar referenciada, sus variantes y su
+// registro. Si algún archivo no se puede eliminar el registro queda marcado para eliminación.
+func (s *imageStore) deleteImage(ctx context.Context, image model.ProductImage) bool {
+	files := image.Files()
+	for _, key := range s.renditions.keys(image.FileName) {
+		if !slices.Contains(files, key) {
+			files = append(files, key)
+		}
+	}
+
+	deleted := true
+	for _, fileName := range files {
+		if err := s.storageRepository.DeleteFile(ctx, fileName); err != nil {
+			tracing.RecordError(trace.SpanFromContext(ctx), err)
+			slog.ErrorContext(ctx, "Error deleting product image", "fileName", fileName, "error", err)
+			deleted = false
+		}
+	}
+	if !deleted {
+		return false
+	}
+	return s.imageFileRepository.DeleteImageFile(ctx, image.FileName) == nil
+}
@@ -0,0 +1,85 @@
+package impl
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+// Modos de construcción de las URLs de las imágenes (IMAGE_URL_MODE)
+const (
+	imageURLModePublic = "public" // base pública del bucket o de la CDN
+	imageURLModeSigned = "signed" // URLs GET firmadas con caducidad, para buckets privados
+	imageURLModeNone   = "none"   // solo el nombre del archivo
+)
+
+// imageURLResolver construye las URLs completas de los archivos de imagen que se devuelven en las respuestas
+type imageURLResolver struct {
+	storageRepository repository.StorageRepository
+	mode              string
+	baseURL           *url.URL
+	signedTTL         time.Duration
+}
+
+// newImageURLResolver lee la configuración del despliegue. IMAGE_PUBLIC_BASE_URL permite servir las
+// imágenes desde una CDN; si no se indica se usa la ruta estática de los almacenamientos local y en
+// memoria o, con S3, R2_PUBLIC_URL
+func newImageURLResolver(storageRepository repository.StorageRepository) *imageURLResolver {
+	resolver := &imageURLResolver{
+		storageRepository: storageRepository,
+		mode:              strings.ToLower(config.GetEnv("IMAGE_URL_MODE", imageURLModePublic)),
+		signedTTL:         config.GetEnvDuration("IMAGE_SIGNED_URL_TTL", time.Hour),
+	}
+
+	switch resolver.mode {
+	case imageURLModePublic:
+		defaultBase := config.GetEnv("R2_PUBLIC_URL", "")
+		if served, ok := storageRepository.(repository.ServedStorageRepository); ok {
+			defaultBase = served.BaseURL()
+		}
+		base := config.GetEnv("IMAGE_PUBLIC_BASE_URL", defaultBase)
+		if base == "" {
+			resolver.mode = imageURLModeNone
+			break
+		}
+		baseURL, err := url.Parse(base)
+		if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+			slog.Warn("Invalid image public base URL, returning file names only", "value", base)
+			resolver.mode = imageURLModeNone
+			break
+		}
+		resolver.baseURL = baseURL
+	case imageURLModeSigned:
+		if resolver.signedTTL <= 0 {
+			slog.Warn("Invalid IMAGE_SIGNED_URL_TTL, using default", "value", resolver.signedTTL)
+			resolver.signedTTL = time.Hour
+		}
+	case imageURLModeNone:
+	default:
+		slog.Warn("Invalid IMAGE_URL_MODE, returning file names only", "value", resolver.mode)
+		resolver.mode = imageURLModeNone
+	}
+	return resolver
+}
+
+// url devuelve la URL completa de fileName, o "" si no hay modo configurado o la firma falla. Con
+// URLs firmadas, ctx es el de la petición para que la firma se cancele y se trace con ella.
+func (r *imageURLResolver) url(ctx context.Context, fileName string) string {
+	switch r.mode {
+	case imageURLModePublic:
+		return r.baseURL.JoinPath(fileName).String()
+	case imageURLModeSigned:
+		signedURL, err := r.storageRepository.PresignDownload(ctx, fileName, r.signedTTL)
+		if err != nil {
+			return ""
+		}
+		return signedURL
+	default:
+		return ""
+	}
+}
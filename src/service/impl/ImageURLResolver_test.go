@@ -0,0 +1,52 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+// presignStorageRepository firma las descargas con el contexto recibido; el resto de métodos no se usan
+type presignStorageRepository struct {
+	repository.StorageRepository
+	contexts []context.Context
+}
+
+func (s *presignStorageRepository) PresignDownload(ctx context.Context, fileName string, ttl time.Duration) (string, error) {
+	s.contexts = append(s.contexts, ctx)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "https://storage.example/" + fileName + "?expires=" + ttl.String(), nil
+}
+
+type requestKey struct{}
+
+func TestSignedImageURLsUseTheRequestContext(t *testing.T) {
+	storage := &presignStorageRepository{}
+	resolver := &imageURLResolver{storageRepository: storage, mode: imageURLModeSigned, signedTTL: time.Minute}
+	productMapper := &mapper.ProductMapper{ImageURL: resolver.url}
+	p := &model.Product{Id: "p1", FileImage: "a.jpg", Images: []model.ProductImage{{Id: "i1", FileName: "a.jpg", Primary: true}}}
+
+	ctx := context.WithValue(context.Background(), requestKey{}, "request-1")
+	response := productMapper.ProductToPublicResponse(ctx, p)
+	if response.ImageUrl != "https://storage.example/a.jpg?expires=1m0s" || response.Images[0].Url != response.ImageUrl {
+		t.Errorf("urls = %q, %q", response.ImageUrl, response.Images[0].Url)
+	}
+	for _, presignCtx := range storage.contexts {
+		if presignCtx.Value(requestKey{}) != "request-1" {
+			t.Errorf("PresignDownload got a context without the request values")
+		}
+	}
+
+	// Si la petición se cancela no se firman URLs
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if response := productMapper.ProductToPublicResponse(canceled, p); response.ImageUrl != "" {
+		t.Errorf("ImageUrl = %q after the request was canceled, want none", response.ImageUrl)
+	}
+}
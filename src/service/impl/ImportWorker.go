@@ -0,0 +1,92 @@
+package impl
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+)
+
+var (
+	importWorkerOnce   sync.Once
+	sharedImportWorker *importWorker
+)
+
+// importWorker ejecuta en segundo plano las importaciones grandes, como máximo maxJobs a la vez.
+// Al apagar el servicio deja de aceptar trabajos y espera a que terminen los que están en curso.
+type importWorker struct {
+	slots  chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	closed bool
+	jobs   sync.WaitGroup
+}
+
+// getImportWorker devuelve el worker compartido, con IMPORT_MAX_CONCURRENT_JOBS trabajos a la vez
+func getImportWorker() *importWorker {
+	importWorkerOnce.Do(func() {
+		sharedImportWorker = newImportWorker(config.GetEnvInt("IMPORT_MAX_CONCURRENT_JOBS", 2))
+	})
+	return sharedImportWorker
+}
+
+func newImportWorker(maxJobs int) *importWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &importWorker{
+		slots:  make(chan struct{}, max(maxJobs, 1)),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// submit ejecuta run en segundo plano si hay un hueco libre y devuelve false si no lo hay o si el
+// servicio se está apagando. El contexto que recibe run se cancela si el apagado no puede esperar más.
+func (w *importWorker) submit(run func(ctx context.Context)) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		return false
+	}
+
+	w.jobs.Add(1)
+	go func() {
+		defer func() {
+			<-w.slots
+			w.jobs.Done()
+		}()
+		run(w.ctx)
+	}()
+	return true
+}
+
+// shutdown deja de aceptar trabajos y espera a que terminen los que están en curso. Si ctx vence
+// antes, los cancela y espera a que guarden su estado.
+func (w *importWorker) shutdown(ctx context.Context) {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		w.cancel()
+		<-done
+	}
+}
+
+// ShutdownImportJobs deja de aceptar importaciones en segundo plano y espera a que terminen las que
+// están en curso; las que no terminan antes de que venza ctx se cancelan y quedan como fallidas
+func ShutdownImportJobs(ctx context.Context) {
+	getImportWorker().shutdown(ctx)
+}
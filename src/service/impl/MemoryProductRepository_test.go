@@ -0,0 +1,255 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+// memoryProductRepository es un ProductRepository en memoria para las pruebas de los servicios.
+// Simula la precondición UpdateTime de Firestore: cada escritura asigna una versión nueva y las
+// actualizaciones de una versión anterior devuelven repository.ErrVersionConflict.
+type memoryProductRepository struct {
+	mu       sync.Mutex
+	products map[string]*model.Product
+	version  time.Time
+	// beforeUpdate se llama antes de aplicar cada UpdateProduct, sin el bloqueo, para simular
+	// escrituras concurrentes
+	beforeUpdate func(p *model.Product)
+	// writeErr, si no es nil, decide el error de WriteProducts para cada lote
+	writeErr func(products []*model.Product) error
+	// writeCalls registra el tamaño de cada llamada a WriteProducts
+	writeCalls []int
+	// skuQueries cuenta las llamadas a GetProductsBySkus
+	skuQueries int
+	// publishedQueries cuenta las llamadas a GetPublishedProducts
+	publishedQueries int
+}
+
+func newMemoryProductRepository(products ...*model.Product) *memoryProductRepository {
+	r := &memoryProductRepository{
+		products: map[string]*model.Product{},
+		version:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, p := range products {
+		r.store(cloneProduct(p))
+	}
+	return r
+}
+
+// store guarda el producto con una versión nueva; hay que llamarlo con el bloqueo
+func (r *memoryProductRepository) store(p *model.Product) {
+	r.version = r.version.Add(time.Second)
+	p.UpdateTime = r.version
+	r.products[p.Id] = p
+}
+
+// get devuelve una copia del producto guardado, incluido si está borrado
+func (r *memoryProductRepository) get(id string) *model.Product {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.products[id]; ok {
+		return cloneProduct(p)
+	}
+	return nil
+}
+
+// touch simula una escritura de otra petición: aplica change y guarda una versión nueva
+func (r *memoryProductRepository) touch(id string, change func(p *model.Product)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := cloneProduct(r.products[id])
+	change(p)
+	r.store(p)
+}
+
+func (r *memoryProductRepository) CreateProduct(_ context.Context, product *model.Product) (*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := cloneProduct(product)
+	r.store(stored)
+	product.UpdateTime = stored.UpdateTime
+	return product, nil
+}
+
+func (r *memoryProductRepository) GetProductById(_ context.Context, id string) (*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || p.IsDeleted() {
+		return nil, nil
+	}
+	return cloneProduct(p), nil
+}
+
+func (r *memoryProductRepository) GetDeletedProductById(_ context.Context, id string) (*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || !p.IsDeleted() {
+		return nil, nil
+	}
+	return cloneProduct(p), nil
+}
+
+func (r *memoryProductRepository) UpdateProduct(_ context.Context, product *model.Product) (*model.Product, error) {
+	if r.beforeUpdate != nil {
+		r.beforeUpdate(product)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkVersion(product); err != nil {
+		return nil, err
+	}
+	stored := cloneProduct(product)
+	r.store(stored)
+	product.UpdateTime = stored.UpdateTime
+	return product, nil
+}
+
+// checkVersion comprueba la precondición de un producto leído antes; hay que llamarlo con el bloqueo
+func (r *memoryProductRepository) checkVersion(product *model.Product) error {
+	if product.UpdateTime.IsZero() {
+		return nil
+	}
+	current, ok := r.products[product.Id]
+	if !ok || !current.UpdateTime.Equal(product.UpdateTime) {
+		return repository.ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *memoryProductRepository) GetProductsByIds(_ context.Context, ids []string) (map[string]*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := map[string]*model.Product{}
+	for _, id := range ids {
+		if p, ok := r.products[id]; ok && !p.IsDeleted() {
+			products[id] = cloneProduct(p)
+		}
+	}
+	return products, nil
+}
+
+func (r *memoryProductRepository) WriteProducts(_ context.Context, products []*model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeCalls = append(r.writeCalls, len(products))
+	if r.writeErr != nil {
+		if err := r.writeErr(products); err != nil {
+			return err
+		}
+	}
+	for _, p := range products {
+		if _, exists := r.products[p.Id]; exists && p.UpdateTime.IsZero() {
+			return repository.ErrVersionConflict
+		}
+		if err := r.checkVersion(p); err != nil {
+			return err
+		}
+	}
+	for _, p := range products {
+		stored := cloneProduct(p)
+		r.store(stored)
+		p.UpdateTime = stored.UpdateTime
+	}
+	return nil
+}
+
+func (r *memoryProductRepository) DeleteProductById(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.products, id)
+	return nil
+}
+
+func (r *memoryProductRepository) GetProducts(context.Context) ([]*model.Product, error) {
+	return r.filter(func(p *model.Product) bool { return !p.IsDeleted() }), nil
+}
+
+func (r *memoryProductRepository) GetProductsBySkus(_ context.Context, skus []string) ([]*model.Product, error) {
+	if len(skus) > repository.MaxSkusPerQuery {
+		return nil, fmt.Errorf("cannot query more than %d skus at once", repository.MaxSkusPerQuery)
+	}
+	r.mu.Lock()
+	r.skuQueries++
+	r.mu.Unlock()
+	return r.filter(func(p *model.Product) bool { return slices.Contains(skus, p.Sku) }), nil
+}
+
+func (r *memoryProductRepository) ForEachProductChunk(_ context.Context, categoryId string, chunkSize int, fn func([]*model.Product) error) error {
+	products := r.filter(func(p *model.Product) bool {
+		return !p.IsDeleted() && (categoryId == "" || p.CategoryId == categoryId)
+	})
+	for start := 0; start < len(products); start += chunkSize {
+		if err := fn(products[start:min(start+chunkSize, len(products))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryProductRepository) GetPublishedProducts(_ context.Context, categoryId string, startAfterId string, limit int) ([]*model.Product, string, error) {
+	// Como en Firestore, los productos sin estado no cumplen el filtro
+	matching := r.filter(func(p *model.Product) bool {
+		return p.Status == model.ProductStatusPublished && p.Id > startAfterId && (categoryId == "" || p.CategoryId == categoryId)
+	})
+	r.mu.Lock()
+	r.publishedQueries++
+	r.mu.Unlock()
+
+	page := matching[:min(limit, len(matching))]
+	lastId := ""
+	if len(page) == limit {
+		lastId = page[len(page)-1].Id
+	}
+	products := make([]*model.Product, 0, len(page))
+	for _, p := range page {
+		if !p.IsDeleted() {
+			products = append(products, p)
+		}
+	}
+	return products, lastId, nil
+}
+
+func (r *memoryProductRepository) GetProductsByAuthorId(_ context.Context, authorId string) ([]*model.Product, error) {
+	return r.filter(func(p *model.Product) bool { return !p.IsDeleted() && p.AuthorId == authorId }), nil
+}
+
+func (r *memoryProductRepository) GetProductsScheduledToPublish(_ context.Context, before string) ([]*model.Product, error) {
+	return r.filter(func(p *model.Product) bool { return !p.IsDeleted() && p.PublishAt != "" && p.PublishAt <= before }), nil
+}
+
+func (r *memoryProductRepository) GetProductsScheduledToUnpublish(_ context.Context, before string) ([]*model.Product, error) {
+	return r.filter(func(p *model.Product) bool { return !p.IsDeleted() && p.UnpublishAt != "" && p.UnpublishAt <= before }), nil
+}
+
+func (r *memoryProductRepository) GetProductsDeletedBefore(_ context.Context, before string) ([]*model.Product, error) {
+	return r.filter(func(p *model.Product) bool { return p.IsDeleted() && p.DeletedAt < before }), nil
+}
+
+// filter devuelve copias de los productos que cumplen keep, en orden de ID
+func (r *memoryProductRepository) filter(keep func(p *model.Product) bool) []*model.Product {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var products []*model.Product
+	for _, p := range r.products {
+		if keep(p) {
+			products = append(products, cloneProduct(p))
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].Id < products[j].Id })
+	return products
+}
+
+func cloneProduct(p *model.Product) *model.Product {
+	clone := *p
+	clone.Images = slices.Clone(p.Images)
+	return &clone
+}
@@ -0,0 +1,369 @@
+package impl
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxBulkOperations limita el número de operaciones de una petición masiva
+const maxBulkOperations = 1000
+
+type ProductBulkServiceImpl struct {
+	productRepository repository.ProductRepository
+	productMapper     *mapper.ProductMapper
+}
+
+func NewProductBulkServiceImpl() *ProductBulkServiceImpl {
+	return &ProductBulkServiceImpl{
+		productRepository: impl.NewProductRepositoryImpl(),
+		productMapper:     &mapper.ProductMapper{},
+	}
+}
+
+// bulkWrite es una operación ya validada pendiente de escribirse
+type bulkWrite struct {
+	index      int
+	product    *model.Product
+	status     int    // código HTTP de la operación si la escritura tiene éxito
+	ifMatch    string // If-Match de la operación: decide si un conflicto de escritura es un 412
+	stockDelta int    // ajuste de stock aplicado, para las métricas
+}
+
+// ExecuteBulk valida todas las operaciones y después las escribe en lotes de hasta
+// repository.MaxBatchWrites. En modo atomic cualquier operación inválida cancela la petición
+// completa y todas se escriben en un único lote; en modo best-effort se escriben las válidas
+// y, si un lote falla, sus operaciones se reintentan una a una para aislar el fallo.
+func (bs *ProductBulkServiceImpl) ExecuteBulk(ctx context.Context, request *product.BulkProductRequest, principal *auth.Principal) (*product.BulkProductResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductBulkService.ExecuteBulk")
+	defer span.End()
+
+	mode := request.Mode
+	if mode == "" {
+		mode = product.BulkModeAtomic
+	}
+	span.SetAttributes(attribute.String("bulk.mode", mode), attribute.Int("bulk.operations", len(request.Operations)))
+
+	if err := validateBulkRequest(mode, len(request.Operations)); err != nil {
+		return nil, err
+	}
+
+	// Leer de una vez los productos afectados por las operaciones que no son create
+	existingProducts, err := bs.getExistingProducts(ctx, request.Operations)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting products for bulk operation", "error", err)
+		return nil, exception.DatabaseError(err)
+	}
+
+	response := &product.BulkProductResponse{
+		Mode:    mode,
+		Results: make([]product.BulkProductResult, len(request.Operations)),
+	}
+	writes := make([]*bulkWrite, 0, len(request.Operations))
+	usedIds := map[string]bool{}
+	for i := range request.Operations {
+		operation := &request.Operations[i]
+		result := &response.Results[i]
+		result.Index = i
+		result.Operation = operation.Operation
+		result.Id = operation.Id
+
+		write, err := bs.prepareOperation(operation, existingProducts, usedIds, principal)
+		if err != nil {
+			result.Err = err
+			continue
+		}
+		write.index = i
+		write.ifMatch = operation.IfMatch
+		result.Id = write.product.Id
+		writes = append(writes, write)
+	}
+
+	if mode == product.BulkModeAtomic {
+		// Una operación inválida cancela todas las demás
+		if len(writes) < len(request.Operations) {
+			for i := range response.Results {
+				if response.Results[i].Err == nil {
+					response.Results[i].Err = exception.Conflict(exception.CodeBulkAborted, "Not applied because another operation of the atomic request failed")
+				}
+			}
+			return countBulkResults(response), nil
+		}
+
+		if err := bs.productRepository.WriteProducts(ctx, bulkProducts(writes)); err != nil {
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error writing atomic bulk operation", "error", err)
+			return nil, productWriteError(err, bulkIfMatch(writes))
+		}
+		completeBulkWrites(response, writes)
+		return countBulkResults(response), nil
+	}
+
+	writeErrors := writeProductsInBatches(ctx, bs.productRepository, bulkProducts(writes))
+	for i, write := range writes {
+		if writeErrors[i] != nil {
+			response.Results[write.index].Err = productWriteError(writeErrors[i], write.ifMatch)
+			continue
+		}
+		completeBulkWrites(response, []*bulkWrite{write})
+	}
+
+	return countBulkResults(response), nil
+}
+
+// writeProductsInBatches escribe los productos en lotes de hasta repository.MaxBatchWrites.
+// Un lote falla completo si una sola precondición falla, así que sus productos se reintentan
+// uno a uno para aislar el fallo. Devuelve el error de escritura de cada producto (nil si se escribió).
+func writeProductsInBatches(ctx context.Context, productRepository repository.ProductRepository, products []*model.Product) []error {
+	writeErrors := make([]error, len(products))
+	for start := 0; start < len(products); start += repository.MaxBatchWrites {
+		end := min(start+repository.MaxBatchWrites, len(products))
+		if err := productRepository.WriteProducts(ctx, products[start:end]); err == nil {
+			continue
+		}
+
+		slog.WarnContext(ctx, "Product batch failed, retrying writes one by one", "size", end-start)
+		for i := start; i < end; i++ {
+			writeErrors[i] = productRepository.WriteProducts(ctx, products[i:i+1])
+		}
+	}
+	return writeErrors
+}
+
+// getExistingProducts lee en bloques los productos referenciados por las operaciones update, delete y stock-adjust
+func (bs *ProductBulkServiceImpl) getExistingProducts(ctx context.Context, operations []product.BulkProductOperation) (map[string]*model.Product, error) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, operation := range operations {
+		if operation.Operation == product.BulkOperationCreate || operation.Id == "" || seen[operation.Id] {
+			continue
+		}
+		seen[operation.Id] = true
+		ids = append(ids, operation.Id)
+	}
+
+	existingProducts := make(map[string]*model.Product, len(ids))
+	for start := 0; start < len(ids); start += repository.MaxBatchWrites {
+		products, err := bs.productRepository.GetProductsByIds(ctx, ids[start:min(start+repository.MaxBatchWrites, len(ids))])
+		if err != nil {
+			return nil, err
+		}
+		for id, p := range products {
+			existingProducts[id] = p
+		}
+	}
+	return existingProducts, nil
+}
+
+// prepareOperation valida una operación con las mismas reglas que su endpoint individual y
+// devuelve el producto que hay que escribir. Las imágenes no se admiten en operaciones masivas.
+func (bs *ProductBulkServiceImpl) prepareOperation(operation *product.BulkProductOperation, existingProducts map[string]*model.Product, usedIds map[string]bool, principal *auth.Principal) (*bulkWrite, error) {
+	if operation.Operation == product.BulkOperationCreate {
+		return bs.prepareCreate(operation, principal)
+	}
+
+	invalidOperation := exception.Validation(exception.CodeValidationFailed, "The operation is not valid")
+	switch operation.Operation {
+	case product.BulkOperationUpdate, product.BulkOperationDelete, product.BulkOperationStockAdjust:
+	default:
+		return nil, invalidOperation.WithField("operation", "must be create, update, delete or stock-adjust")
+	}
+	if operation.Id == "" {
+		return nil, invalidOperation.WithField("id", "is required")
+	}
+
+	// La ruta exige product.write; borrar y ajustar stock exigen además el permiso de su endpoint
+	if permission := bulkOperationPermission(operation.Operation); permission != "" && !principal.HasPermission(permission) {
+		return nil, exception.Forbidden(exception.CodeForbidden, "The "+operation.Operation+" operation requires the "+string(permission)+" permission")
+	}
+
+	// Un lote no puede escribir dos veces el mismo documento
+	if usedIds[operation.Id] {
+		return nil, invalidOperation.WithField("id", "each product can only appear once per bulk request")
+	}
+	usedIds[operation.Id] = true
+
+	existingProduct, ok := existingProducts[operation.Id]
+	if !ok {
+		return nil, productNotFound()
+	}
+	if !principal.CanManage(existingProduct.AuthorId) {
+		return nil, productNotOwned()
+	}
+	if !existingProduct.MatchesIfMatch(operation.IfMatch) {
+		return nil, versionMismatch()
+	}
+
+	// Se modifica una copia que conserva el UpdateTime leído para la precondición
+	updatedProduct := *existingProduct
+	write := &bulkWrite{product: &updatedProduct, status: http.StatusOK}
+
+	switch operation.Operation {
+	case product.BulkOperationUpdate:
+		if err := validateBulkProduct(operation.Product); err != nil {
+			return nil, err
+		}
+		if operation.Product.Status != "" {
+			return nil, invalidOperation.WithField("product.status", "cannot be changed with update; use PUT /api/v1/products/:id/status")
+		}
+		publishAt, unpublishAt, err := normalizeSchedule(operation.Product.PublishAt, operation.Product.UnpublishAt)
+		if err != nil {
+			return nil, err
+		}
+		document := bulkPatchDocument(operation.Product)
+		document.PublishAt = publishAt
+		document.UnpublishAt = unpublishAt
+		bs.productMapper.ApplyPatchDocument(document, &updatedProduct)
+
+		if priceChanged(existingProduct, &updatedProduct) && !principal.HasPermission(model.PriceManage) {
+			return nil, exception.Forbidden(exception.CodeForbidden, "Changing the price, currency or discount requires the price.manage permission")
+		}
+	case product.BulkOperationDelete:
+		updatedProduct.DeletedAt = time.Now().UTC().Format(time.RFC3339)
+		updatedProduct.DeletedBy = principal.Subject
+	case product.BulkOperationStockAdjust:
+		updatedProduct.Stock = max(existingProduct.Stock+operation.Quantity, 0)
+		updatedProduct.UpdatedAt = time.Now().Format(time.RFC3339)
+		write.stockDelta = operation.Quantity
+	}
+
+	return write, nil
+}
+
+// prepareCreate valida una operación create y construye el producto nuevo
+func (bs *ProductBulkServiceImpl) prepareCreate(operation *product.BulkProductOperation, principal *auth.Principal) (*bulkWrite, error) {
+	if err := validateBulkProduct(operation.Product); err != nil {
+		return nil, err
+	}
+
+	status := model.ProductStatus(operation.Product.Status)
+	if status == "" {
+		status = model.ProductStatusDraft
+	}
+	if status != model.ProductStatusDraft && status != model.ProductStatusPublished {
+		return nil, exception.Validation(exception.CodeValidationFailed, "The product data is not valid").WithField("product.status", "must be draft or published")
+	}
+	publishAt, unpublishAt, err := normalizeSchedule(operation.Product.PublishAt, operation.Product.UnpublishAt)
+	if err != nil {
+		return nil, err
+	}
+
+	productModel := bs.productMapper.CreateRequestToProduct(operation.Product)
+	productModel.Id = uuid.New().String()
+	productModel.AuthorId = principal.Subject
+	productModel.Status = status
+	productModel.PublishAt = publishAt
+	productModel.UnpublishAt = unpublishAt
+
+	return &bulkWrite{product: productModel, status: http.StatusCreated}, nil
+}
+
+// bulkOperationPermission devuelve el permiso adicional que exige una operación
+func bulkOperationPermission(operation string) model.Permission {
+	switch operation {
+	case product.BulkOperationDelete:
+		return model.ProductDelete
+	case product.BulkOperationStockAdjust:
+		return model.StockAdjust
+	default:
+		return ""
+	}
+}
+
+// validateBulkRequest comprueba el modo y el número de operaciones
+func validateBulkRequest(mode string, operations int) error {
+	validationError := exception.Validation(exception.CodeValidationFailed, "The bulk request is not valid")
+	switch {
+	case mode != product.BulkModeAtomic && mode != product.BulkModeBestEffort:
+		return validationError.WithField("mode", "must be atomic or best-effort")
+	case operations == 0:
+		return validationError.WithField("operations", "must contain at least one operation")
+	case operations > maxBulkOperations:
+		return validationError.WithField("operations", "must not contain more than 1000 operations")
+	case mode == product.BulkModeAtomic && operations > repository.MaxBatchWrites:
+		return validationError.WithField("operations", "must not contain more than 500 operations in atomic mode")
+	}
+	return nil
+}
+
+// validateBulkProduct valida los datos de producto de una operación create o update
+func validateBulkProduct(request *product.CreateProductRequest) error {
+	if request == nil {
+		return exception.Validation(exception.CodeValidationFailed, "The operation is not valid").WithField("product", "is required")
+	}
+	if strings.TrimSpace(request.ImageBase64) != "" {
+		return exception.Validation(exception.CodeValidationFailed, "The operation is not valid").WithField("product.imageBase64", "images are not supported in bulk operations")
+	}
+	return validateProductFields(request.Name, request.Price, request.Discount, request.Stock)
+}
+
+// bulkPatchDocument convierte los datos de una operación update en el documento con los campos modificables
+func bulkPatchDocument(request *product.CreateProductRequest) *product.PatchProductDocument {
+	return &product.PatchProductDocument{
+		CategoryId:  request.CategoryId,
+		Name:        request.Name,
+		Description: request.Description,
+		Price:       request.Price,
+		Currency:    request.Currency,
+		Discount:    request.Discount,
+		Sku:         request.Sku,
+		Stock:       request.Stock,
+	}
+}
+
+func bulkProducts(writes []*bulkWrite) []*model.Product {
+	products := make([]*model.Product, len(writes))
+	for i, write := range writes {
+		products[i] = write.product
+	}
+	return products
+}
+
+// bulkIfMatch devuelve el primer If-Match de las operaciones: un conflicto al escribir un lote
+// atómico solo es un 412 si alguna operación lo envió
+func bulkIfMatch(writes []*bulkWrite) string {
+	for _, write := range writes {
+		if write.ifMatch != "" {
+			return write.ifMatch
+		}
+	}
+	return ""
+}
+
+// completeBulkWrites marca como correctas las operaciones escritas
+func completeBulkWrites(response *product.BulkProductResponse, writes []*bulkWrite) {
+	for _, write := range writes {
+		result := &response.Results[write.index]
+		result.Status = write.status
+		result.ETag = write.product.ETag()
+		if write.stockDelta != 0 {
+			metrics.ObserveStockAdjustment(write.stockDelta)
+		}
+	}
+}
+
+func countBulkResults(response *product.BulkProductResponse) *product.BulkProductResponse {
+	for _, result := range response.Results {
+		if result.Err != nil {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+	return response
+}
@@ -0,0 +1,298 @@
+package impl
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+)
+
+func newTestBulkService(productRepository *memoryProductRepository) *ProductBulkServiceImpl {
+	return &ProductBulkServiceImpl{
+		productRepository: productRepository,
+		productMapper:     &mapper.ProductMapper{},
+	}
+}
+
+// newBulkRepository crea p1 y p2 del autor de las pruebas y p3 de otro autor
+func newBulkRepository() *memoryProductRepository {
+	return newMemoryProductRepository(
+		&model.Product{Id: "p1", AuthorId: "author-1", Name: "Mug", Price: 10, Stock: 10},
+		&model.Product{Id: "p2", AuthorId: "author-1", Name: "Cup", Price: 5, Stock: 3},
+		&model.Product{Id: "p3", AuthorId: "author-2", Name: "Plate", Price: 8, Stock: 1},
+	)
+}
+
+func createOperation(name string) product.BulkProductOperation {
+	return product.BulkProductOperation{Operation: product.BulkOperationCreate, Product: &product.CreateProductRequest{Name: name, Price: 1}}
+}
+
+func updateOperation(id string, name string, price float64) product.BulkProductOperation {
+	return product.BulkProductOperation{Operation: product.BulkOperationUpdate, Id: id, Product: &product.CreateProductRequest{Name: name, Price: price}}
+}
+
+// assertBulkResult comprueba el código de una operación correcta o el error de una fallida
+func assertBulkResult(t *testing.T, result product.BulkProductResult, wantStatus int, wantKind exception.Kind, wantCode string) {
+	t.Helper()
+	if wantCode != "" {
+		assertDomainError(t, result.Err, wantKind, wantCode)
+		return
+	}
+	if result.Err != nil || result.Status != wantStatus {
+		t.Fatalf("result %d = %d (%v), want %d", result.Index, result.Status, result.Err, wantStatus)
+	}
+	if result.ETag == "" {
+		t.Errorf("result %d has no ETag", result.Index)
+	}
+}
+
+func TestExecuteBulkLimits(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		operations     int
+		wantErr        bool
+		wantWriteCalls []int
+	}{
+		{name: "atomic with 500 operations", mode: product.BulkModeAtomic, operations: 500, wantWriteCalls: []int{500}},
+		{name: "atomic is the default mode", operations: 500, wantWriteCalls: []int{500}},
+		{name: "atomic with 501 operations", mode: product.BulkModeAtomic, operations: 501, wantErr: true},
+		{name: "best-effort with 1000 operations in two batches", mode: product.BulkModeBestEffort, operations: 1000, wantWriteCalls: []int{500, 500}},
+		{name: "best-effort with 1001 operations", mode: product.BulkModeBestEffort, operations: 1001, wantErr: true},
+		{name: "no operations", mode: product.BulkModeBestEffort, wantErr: true},
+		{name: "unknown mode", mode: "eventually", operations: 1, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			productRepository := newMemoryProductRepository()
+			request := &product.BulkProductRequest{Mode: test.mode}
+			for range test.operations {
+				request.Operations = append(request.Operations, createOperation("Mug"))
+			}
+
+			response, err := newTestBulkService(productRepository).ExecuteBulk(context.Background(), request, author)
+			if test.wantErr {
+				assertDomainError(t, err, exception.KindValidation, exception.CodeValidationFailed)
+				if len(productRepository.writeCalls) > 0 {
+					t.Error("the products were written")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecuteBulk() error = %v", err)
+			}
+			if response.Succeeded != test.operations || response.Failed != 0 {
+				t.Errorf("succeeded = %d, failed = %d, want %d, 0", response.Succeeded, response.Failed, test.operations)
+			}
+			if !slices.Equal(productRepository.writeCalls, test.wantWriteCalls) {
+				t.Errorf("write calls = %v, want %v", productRepository.writeCalls, test.wantWriteCalls)
+			}
+		})
+	}
+}
+
+func TestExecuteBulkAtomicRollsBackOnSingleFailure(t *testing.T) {
+	productRepository := newBulkRepository()
+	request := &product.BulkProductRequest{Mode: product.BulkModeAtomic, Operations: []product.BulkProductOperation{
+		createOperation("Bowl"),
+		updateOperation("p1", "Big mug", 10),
+		updateOperation("missing", "Glass", 3),
+	}}
+
+	response, err := newTestBulkService(productRepository).ExecuteBulk(context.Background(), request, author)
+	if err != nil {
+		t.Fatalf("ExecuteBulk() error = %v", err)
+	}
+	if response.Succeeded != 0 || response.Failed != 3 {
+		t.Errorf("succeeded = %d, failed = %d, want 0, 3", response.Succeeded, response.Failed)
+	}
+	assertBulkResult(t, response.Results[0], 0, exception.KindConflict, exception.CodeBulkAborted)
+	assertBulkResult(t, response.Results[1], 0, exception.KindConflict, exception.CodeBulkAborted)
+	assertBulkResult(t, response.Results[2], 0, exception.KindNotFound, exception.CodeProductNotFound)
+	if len(productRepository.writeCalls) > 0 {
+		t.Errorf("write calls = %v, want none", productRepository.writeCalls)
+	}
+	if p1 := productRepository.get("p1"); p1.Name != "Mug" {
+		t.Errorf("p1 name = %s, want Mug", p1.Name)
+	}
+}
+
+func TestExecuteBulkAtomicVersionConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  bool
+		wantKind exception.Kind
+		wantCode string
+	}{
+		{name: "without If-Match", wantKind: exception.KindConflict, wantCode: exception.CodeConcurrentModification},
+		{name: "with If-Match", ifMatch: true, wantKind: exception.KindPrecondition, wantCode: exception.CodePreconditionFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			productRepository := newBulkRepository()
+			// Otra petición modifica p2 entre la lectura y la escritura del lote
+			productRepository.writeErr = func([]*model.Product) error { return repository.ErrVersionConflict }
+			update := updateOperation("p2", "Big cup", 5)
+			if test.ifMatch {
+				update.IfMatch = productRepository.get("p2").ETag()
+			}
+			request := &product.BulkProductRequest{Mode: product.BulkModeAtomic, Operations: []product.BulkProductOperation{
+				updateOperation("p1", "Big mug", 10),
+				update,
+			}}
+
+			_, err := newTestBulkService(productRepository).ExecuteBulk(context.Background(), request, author)
+			assertDomainError(t, err, test.wantKind, test.wantCode)
+			if !slices.Equal(productRepository.writeCalls, []int{2}) {
+				t.Errorf("write calls = %v, want [2]", productRepository.writeCalls)
+			}
+		})
+	}
+}
+
+func TestExecuteBulkBestEffortReportsEachOperation(t *testing.T) {
+	productRepository := newBulkRepository()
+	staleETag := productRepository.get("p2").ETag()
+	productRepository.touch("p2", func(p *model.Product) { p.Stock = 4 })
+	request := &product.BulkProductRequest{Mode: product.BulkModeBestEffort, Operations: []product.BulkProductOperation{
+		createOperation("Bowl"),
+		updateOperation("p1", "Big mug", 10),
+		{Operation: product.BulkOperationStockAdjust, Id: "p2", IfMatch: staleETag, Quantity: 1},
+		{Operation: product.BulkOperationDelete, Id: "p3"},
+		{Operation: product.BulkOperationDelete, Id: "p1"},
+		{Operation: "archive", Id: "p2"},
+		createOperation(""),
+	}}
+
+	response, err := newTestBulkService(productRepository).ExecuteBulk(context.Background(), request, author)
+	if err != nil {
+		t.Fatalf("ExecuteBulk() error = %v", err)
+	}
+	if response.Succeeded != 2 || response.Failed != 5 {
+		t.Errorf("succeeded = %d, failed = %d, want 2, 5", response.Succeeded, response.Failed)
+	}
+	assertBulkResult(t, response.Results[0], http.StatusCreated, "", "")
+	assertBulkResult(t, response.Results[1], http.StatusOK, "", "")
+	assertBulkResult(t, response.Results[2], 0, exception.KindPrecondition, exception.CodePreconditionFailed)
+	assertBulkResult(t, response.Results[3], 0, exception.KindForbidden, exception.CodeProductNotOwned)
+	// p1 ya se actualiza en la operación 1
+	assertBulkResult(t, response.Results[4], 0, exception.KindValidation, exception.CodeValidationFailed)
+	assertBulkResult(t, response.Results[5], 0, exception.KindValidation, exception.CodeValidationFailed)
+	assertBulkResult(t, response.Results[6], 0, exception.KindValidation, exception.CodeValidationFailed)
+
+	created := productRepository.get(response.Results[0].Id)
+	if created == nil || created.AuthorId != author.Subject || created.Status != model.ProductStatusDraft {
+		t.Errorf("created product = %+v", created)
+	}
+	if p1 := productRepository.get("p1"); p1.Name != "Big mug" || p1.IsDeleted() || response.Results[1].ETag != p1.ETag() {
+		t.Errorf("p1 = %+v", p1)
+	}
+	if p2 := productRepository.get("p2"); p2.Stock != 4 {
+		t.Errorf("p2 stock = %d, want 4", p2.Stock)
+	}
+	if p3 := productRepository.get("p3"); p3.IsDeleted() {
+		t.Error("p3 of another author was deleted")
+	}
+}
+
+func TestExecuteBulkBestEffortRetriesFailedBatchOneByOne(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  bool
+		wantKind exception.Kind
+		wantCode string
+	}{
+		{name: "without If-Match", wantKind: exception.KindConflict, wantCode: exception.CodeConcurrentModification},
+		{name: "with If-Match", ifMatch: true, wantKind: exception.KindPrecondition, wantCode: exception.CodePreconditionFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			productRepository := newBulkRepository()
+			// La precondición de p2 falla: el lote completo falla y solo su reintento individual
+			productRepository.writeErr = func(products []*model.Product) error {
+				if slices.ContainsFunc(products, func(p *model.Product) bool { return p.Id == "p2" }) {
+					return repository.ErrVersionConflict
+				}
+				return nil
+			}
+			update := updateOperation("p2", "Big cup", 5)
+			if test.ifMatch {
+				update.IfMatch = productRepository.get("p2").ETag()
+			}
+			request := &product.BulkProductRequest{Mode: product.BulkModeBestEffort, Operations: []product.BulkProductOperation{
+				updateOperation("p1", "Big mug", 10),
+				update,
+				{Operation: product.BulkOperationStockAdjust, Id: "p1", Quantity: -1},
+				{Operation: product.BulkOperationStockAdjust, Id: "p3", Quantity: -1},
+			}}
+
+			response, err := newTestBulkService(productRepository).ExecuteBulk(context.Background(), request, author)
+			if err != nil {
+				t.Fatalf("ExecuteBulk() error = %v", err)
+			}
+			if !slices.Equal(productRepository.writeCalls, []int{2, 1, 1}) {
+				t.Errorf("write calls = %v, want [2 1 1]", productRepository.writeCalls)
+			}
+			assertBulkResult(t, response.Results[0], http.StatusOK, "", "")
+			assertBulkResult(t, response.Results[1], 0, test.wantKind, test.wantCode)
+			assertBulkResult(t, response.Results[2], 0, exception.KindValidation, exception.CodeValidationFailed)
+			assertBulkResult(t, response.Results[3], 0, exception.KindForbidden, exception.CodeProductNotOwned)
+			if p1 := productRepository.get("p1"); p1.Name != "Big mug" {
+				t.Errorf("p1 name = %s, want Big mug", p1.Name)
+			}
+			if p2 := productRepository.get("p2"); p2.Name != "Cup" {
+				t.Errorf("p2 name = %s, want Cup", p2.Name)
+			}
+		})
+	}
+}
+
+func TestExecuteBulkChecksPermissionsPerOperation(t *testing.T) {
+	admin := &auth.Principal{Subject: "admin-1", Permissions: model.Permissions, Admin: true}
+	tests := []struct {
+		name       string
+		operation  product.BulkProductOperation
+		principal  *auth.Principal
+		wantStatus int
+		wantKind   exception.Kind
+		wantCode   string
+	}{
+		{name: "delete", operation: product.BulkProductOperation{Operation: product.BulkOperationDelete, Id: "p1"}, principal: author, wantStatus: http.StatusOK},
+		{name: "delete without product.delete", operation: product.BulkProductOperation{Operation: product.BulkOperationDelete, Id: "p1"}, principal: authorWithout(model.ProductDelete), wantKind: exception.KindForbidden, wantCode: exception.CodeForbidden},
+		{name: "stock-adjust", operation: product.BulkProductOperation{Operation: product.BulkOperationStockAdjust, Id: "p1", Quantity: 2}, principal: author, wantStatus: http.StatusOK},
+		{name: "stock-adjust without stock.adjust", operation: product.BulkProductOperation{Operation: product.BulkOperationStockAdjust, Id: "p1", Quantity: 2}, principal: authorWithout(model.StockAdjust), wantKind: exception.KindForbidden, wantCode: exception.CodeForbidden},
+		{name: "update price", operation: updateOperation("p1", "Mug", 12), principal: author, wantStatus: http.StatusOK},
+		{name: "update price without price.manage", operation: updateOperation("p1", "Mug", 12), principal: seller, wantKind: exception.KindForbidden, wantCode: exception.CodeForbidden},
+		{name: "update without price change nor price.manage", operation: updateOperation("p1", "Big mug", 10), principal: seller, wantStatus: http.StatusOK},
+		{name: "update product of another author", operation: updateOperation("p3", "Big plate", 8), principal: author, wantKind: exception.KindForbidden, wantCode: exception.CodeProductNotOwned},
+		{name: "delete product of another author", operation: product.BulkProductOperation{Operation: product.BulkOperationDelete, Id: "p3"}, principal: author, wantKind: exception.KindForbidden, wantCode: exception.CodeProductNotOwned},
+		{name: "administrator updates product of another author", operation: updateOperation("p3", "Big plate", 8), principal: admin, wantStatus: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			productRepository := newBulkRepository()
+			original := productRepository.get(test.operation.Id)
+			request := &product.BulkProductRequest{Mode: product.BulkModeBestEffort, Operations: []product.BulkProductOperation{test.operation}}
+
+			response, err := newTestBulkService(productRepository).ExecuteBulk(context.Background(), request, test.principal)
+			if err != nil {
+				t.Fatalf("ExecuteBulk() error = %v", err)
+			}
+			assertBulkResult(t, response.Results[0], test.wantStatus, test.wantKind, test.wantCode)
+			if stored := productRepository.get(test.operation.Id); (test.wantCode == "") == (stored.ETag() == original.ETag()) {
+				t.Errorf("written = %v, want %v", stored.ETag() != original.ETag(), test.wantCode == "")
+			}
+		})
+	}
+}
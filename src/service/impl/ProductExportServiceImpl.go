@@ -0,0 +1,105 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/spreadsheet"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// exportColumns son las columnas del archivo exportado. Los nombres coinciden con los campos de la
+// importación para que el archivo se pueda volver a importar; categoryId va antes que categoryName
+// para que la importación use el ID.
+var exportColumns = []string{
+	"id", "sku", "name", "description", "categoryId", "categoryName", "price", "currency", "discount", "finalPrice",
+	"stock", "status", "fileImage", "publishAt", "unpublishAt", "authorId", "createdAt", "updatedAt",
+}
+
+type ProductExportServiceImpl struct {
+	productRepository  repository.ProductRepository
+	categoryRepository repository.CategoryRepository
+	chunkSize          int
+}
+
+func NewProductExportServiceImpl() *ProductExportServiceImpl {
+	return &ProductExportServiceImpl{
+		productRepository:  impl.NewProductRepositoryImpl(),
+		categoryRepository: impl.NewCategoryRepositoryImpl(),
+		chunkSize:          max(config.GetEnvInt("EXPORT_CHUNK_SIZE", 500), 1),
+	}
+}
+
+func (es *ProductExportServiceImpl) ExportProducts(ctx context.Context, format spreadsheet.Format, request *product.SearchProductsRequest, status string, w io.Writer) error {
+	ctx, span := tracing.StartSpan(ctx, "ProductExportService.ExportProducts", attribute.String("export.format", string(format)))
+	defer span.End()
+
+	statusFilter, err := parseStatusFilter(status)
+	if err != nil {
+		return err
+	}
+
+	// Las categorías son pocas: se leen una vez para resolver sus nombres
+	categories, err := es.categoryRepository.GetCategories(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting categories for export", "error", err)
+		return exception.DatabaseError(err)
+	}
+	categoryNames := make(map[string]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.Id] = category.Name
+	}
+
+	writer, err := spreadsheet.NewWriter(format, w, exportColumns)
+	if err != nil {
+		return err
+	}
+
+	exported := 0
+	err = es.productRepository.ForEachProductChunk(ctx, request.CategoryId, es.chunkSize, func(products []*model.Product) error {
+		for _, p := range filterByStatus(filterProducts(products, request), statusFilter) {
+			if err := writer.Write(exportRow(p, categoryNames)); err != nil {
+				return err
+			}
+			exported++
+		}
+		// Cada bloque se envía al cliente para que la memoria no crezca con el tamaño del catálogo
+		return writer.Flush()
+	})
+	// Los productos que no se pueden leer se registran en el log y no interrumpen la exportación
+	if errors.Is(err, repository.ErrUndecodableProducts) {
+		err = nil
+	}
+	if err == nil {
+		err = writer.Close()
+	} else {
+		writer.Discard()
+	}
+	span.SetAttributes(attribute.Int("products.exported", exported))
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error exporting products", "format", format, "exported", exported, "error", err)
+		return exception.DatabaseError(err)
+	}
+
+	slog.InfoContext(ctx, "Products exported", "format", format, "exported", exported)
+	return nil
+}
+
+// exportRow devuelve los valores de un producto en el orden de exportColumns
+func exportRow(p *model.Product, categoryNames map[string]string) []any {
+	return []any{
+		p.Id, p.Sku, p.Name, p.Description, p.CategoryId, categoryNames[p.CategoryId], p.Price, p.Currency, p.Discount, p.FinalPrice(),
+		p.Stock, string(p.EffectiveStatus()), p.FileImage, p.PublishAt, p.UnpublishAt, p.AuthorId, p.CreatedAt, p.UpdatedAt,
+	}
+}
@@ -0,0 +1,1132 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/imaging"
+	"github.com/ruiborda/ecommerce-product-service/src/logging"
+	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	_ "golang.org/x/image/webp"
+	"golang.org/x/text/language"
+)
+
+const (
+	// maxAltTextLength limita el texto alternativo de cada idioma
+	maxAltTextLength = 250
+	// imageSniffLength son los bytes iniciales que se leen para detectar el tipo y las dimensiones de la imagen
+	imageSniffLength = 64 << 10
+	// imageUploadSweepBatchSize limita las subidas vencidas que se eliminan en cada ejecución del barrido
+	imageUploadSweepBatchSize = 500
+	// renditionBackfillChunkSize es el número de productos que se leen a la vez durante el backfill
+	renditionBackfillChunkSize = 100
+	// maxRenditionBackfillErrors limita los errores que se incluyen en el informe del backfill
+	maxRenditionBackfillErrors = 100
+	// maxImageReferenceSyncErrors limita los errores que se incluyen en el informe de la sincronización de referencias
+	maxImageReferenceSyncErrors = 100
+	// maxStorageReconcileItems limita los huérfanos y las referencias rotas que se incluyen en el informe de la reconciliación
+	maxStorageReconcileItems = 1000
+)
+
+// allowedImageTypes son los tipos de imagen admitidos en la galería y la extensión de sus archivos
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/avif": ".avif",
+}
+
+type ProductImageServiceImpl struct {
+	productRepository     repository.ProductRepository
+	storageRepository     repository.StorageRepository
+	imageUploadRepository repository.ImageUploadRepository
+	renditions            *renditionGenerator
+	imageStore            *imageStore
+	productMapper         *mapper.ProductMapper
+	maxImages             int
+	maxImageSize          int64
+	uploadURLTTL          time.Duration
+	uploadTTL             time.Duration
+	reconcileGracePeriod  time.Duration
+}
+
+func NewProductImageServiceImpl() *ProductImageServiceImpl {
+	storageRepository := impl.NewStorageRepository()
+	renditions := newRenditionGenerator(storageRepository)
+	return &ProductImageServiceImpl{
+		productRepository:     impl.NewProductRepositoryImpl(),
+		storageRepository:     storageRepository,
+		imageUploadRepository: impl.NewImageUploadRepositoryImpl(),
+		renditions:            renditions,
+		imageStore:            newImageStore(storageRepository, impl.NewImageFileRepositoryImpl(), renditions),
+		productMapper:         &mapper.ProductMapper{ImageURL: newImageURLResolver(storageRepository).url},
+		maxImages:             config.GetEnvInt("PRODUCT_MAX_IMAGES", 10),
+		maxImageSize:          int64(config.GetEnvInt("PRODUCT_IMAGE_MAX_SIZE", 10<<20)),
+		uploadURLTTL:          config.GetEnvDuration("PRODUCT_IMAGE_UPLOAD_URL_TTL", 15*time.Minute),
+		uploadTTL:             config.GetEnvDuration("PRODUCT_IMAGE_UPLOAD_TTL", time.Hour),
+		reconcileGracePeriod:  config.GetEnvDuration("STORAGE_RECONCILE_GRACE_PERIOD", 24*time.Hour),
+	}
+}
+
+func (is *ProductImageServiceImpl) GetProductImages(ctx context.Context, productId string) (*product.ProductImagesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.GetProductImages", attribute.String("product.id", productId))
+	defer span.End()
+
+	existingProduct, err := is.productRepository.GetProductById(ctx, productId)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting product images", "id", productId, "error", err)
+		return nil, exception.DatabaseError(err)
+	}
+	if existingProduct == nil {
+		return nil, productNotFound()
+	}
+
+	return is.productMapper.ProductToImagesResponse(ctx, existingProduct), nil
+}
+
+func (is *ProductImageServiceImpl) AddProductImage(ctx context.Context, productId string, request *product.AddProductImageRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.AddProductImage", attribute.String("product.id", productId))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, productId)
+
+	if err := validateAltText(request.Alt); err != nil {
+		return nil, err
+	}
+	data, err := decodeImageBase64(request.ImageBase64)
+	if err != nil {
+		return nil, imageUploadError(err)
+	}
+	if int64(len(data)) > is.maxImageSize {
+		return nil, is.imageTooLarge()
+	}
+	if _, _, ok := sniffImage(data); !ok {
+		return nil, unsupportedImageType()
+	}
+
+	if err := is.checkNewImage(ctx, productId, request.Position, ifMatch, principal); err != nil {
+		return nil, err
+	}
+
+	newImage, err := is.imageStore.store(ctx, productId, data)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error uploading product image", "error", err)
+		return nil, imageUploadError(err)
+	}
+	newImage.Alt = request.Alt
+
+	updatedProduct, err := is.attachImage(ctx, productId, ifMatch, principal, newImage, request.Primary, request.Position)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return is.productMapper.ProductToImagesResponse(ctx, updatedProduct), nil
+}
+
+func (is *ProductImageServiceImpl) UploadProductImage(ctx context.Context, productId string, request *product.UploadProductImageRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.UploadProductImage", attribute.String("product.id", productId))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, productId)
+
+	if err := validateAltText(request.Alt); err != nil {
+		return nil, err
+	}
+
+	// Las comprobaciones del producto se hacen antes de leer el archivo
+	if err := is.checkNewImage(ctx, productId, request.Position, ifMatch, principal); err != nil {
+		return nil, err
+	}
+
+	// El tipo se deduce de los primeros bytes; el Content-Type declarado por el cliente no se usa
+	file := bufio.NewReaderSize(request.File, imageSniffLength)
+	head, err := file.Peek(imageSniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, is.imageReadError(err)
+	}
+	if len(head) == 0 {
+		return nil, exception.Validation(exception.CodeInvalidImage, "The image is not a valid file").WithField("file", "must not be empty")
+	}
+	contentType, extension, ok := sniffImage(head)
+	if !ok {
+		return nil, unsupportedImageType()
+	}
+	span.SetAttributes(attribute.String("file.content_type", contentType))
+
+	limitedFile := &sizeLimitReader{reader: file, limit: is.maxImageSize}
+	fileName, err := is.imageStore.storeStream(ctx, productId, limitedFile, contentType, extension)
+	switch {
+	case limitedFile.exceeded:
+		return nil, is.imageTooLarge()
+	case limitedFile.err != nil:
+		return nil, is.imageReadError(limitedFile.err)
+	case errors.Is(err, imaging.ErrMetadataNotRemovable):
+		return nil, metadataNotRemovable("file")
+	case err != nil:
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error uploading product image", "error", err)
+		return nil, exception.StorageError(err)
+	}
+
+	newImage := model.ProductImage{
+		Id:        uuid.New().String(),
+		FileName:  fileName,
+		Alt:       request.Alt,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	newImage.Width, newImage.Height = imageDimensions(head)
+	is.renditions.applyFromStorage(ctx, &newImage)
+
+	updatedProduct, err := is.attachImage(ctx, productId, ifMatch, principal, newImage, request.Primary, request.Position)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return is.productMapper.ProductToImagesResponse(ctx, updatedProduct), nil
+}
+
+func (is *ProductImageServiceImpl) CreateImageUpload(ctx context.Context, productId string, request *product.CreateImageUploadRequest, principal *auth.Principal) (*product.ImageUploadResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.CreateImageUpload", attribute.String("product.id", productId))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, productId)
+
+	extension, ok := allowedImageTypes[request.ContentType]
+	if !ok {
+		return nil, unsupportedImageType()
+	}
+	if request.Size <= 0 {
+		return nil, exception.Validation(exception.CodeValidationFailed, "The upload data is not valid").WithField("size", "must be greater than 0")
+	}
+	if request.Size > is.maxImageSize {
+		return nil, is.imageTooLarge()
+	}
+
+	// Se rechaza antes de firmar si el producto no se puede modificar o la galería está llena;
+	// ambas comprobaciones se repiten al confirmar
+	if err := is.checkNewImage(ctx, productId, nil, "", principal); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	upload := &model.ImageUpload{
+		Id:          uuid.New().String(),
+		ProductId:   productId,
+		AuthorId:    principal.Subject,
+		FileName:    uuid.New().String() + extension,
+		ContentType: request.ContentType,
+		Size:        request.Size,
+		CreatedAt:   now.Format(time.RFC3339),
+		// El registro dura al menos lo que la URL para que el barrido no elimine una subida en curso
+		ExpiresAt: now.Add(max(is.uploadTTL, is.uploadURLTTL)),
+	}
+	span.SetAttributes(attribute.String("upload.id", upload.Id))
+
+	presigned, err := is.storageRepository.PresignUpload(ctx, upload.FileName, upload.ContentType, upload.Size, is.uploadURLTTL)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, exception.StorageError(err)
+	}
+	if err := is.imageUploadRepository.SaveImageUpload(ctx, upload); err != nil {
+		tracing.RecordError(span, err)
+		return nil, exception.DatabaseError(err)
+	}
+
+	return &product.ImageUploadResponse{
+		UploadId:    upload.Id,
+		FileName:    upload.FileName,
+		Url:         presigned.URL,
+		Method:      presigned.Method,
+		Headers:     presigned.Headers,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+		MaxSize:     is.maxImageSize,
+		ExpiresAt:   presigned.ExpiresAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+func (is *ProductImageServiceImpl) ConfirmImageUpload(ctx context.Context, productId string, uploadId string, request *product.ConfirmImageUploadRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.ConfirmImageUpload", attribute.String("product.id", productId), attribute.String("upload.id", uploadId))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, productId)
+
+	if err := validateAltText(request.Alt); err != nil {
+		return nil, err
+	}
+
+	upload, err := is.imageUploadRepository.GetImageUploadById(ctx, uploadId)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, exception.DatabaseError(err)
+	}
+	if upload == nil || upload.ProductId != productId || time.Now().After(upload.ExpiresAt) {
+		return nil, exception.NotFound(exception.CodeUploadNotFound, "Upload not found or expired")
+	}
+
+	existingProduct, err := is.getManagedProduct(ctx, productId, ifMatch, principal)
+	if err != nil {
+		return nil, err
+	}
+	// Una confirmación repetida cuyo registro no se llegó a eliminar no añade la imagen otra vez
+	if slices.Contains(existingProduct.MediaFiles(), upload.FileName) {
+		return is.productMapper.ProductToImagesResponse(ctx, existingProduct), nil
+	}
+	if _, err := is.newImagePosition(existingProduct, request.Position); err != nil {
+		return nil, err
+	}
+
+	// La firma ya obliga al tipo y al tamaño declarados; se comprueban de nuevo por si el objeto
+	// se hubiera escrito por otro medio
+	object, err := is.storageRepository.HeadObject(ctx, upload.FileName)
+	if errors.Is(err, repository.ErrFileNotFound) {
+		return nil, exception.Conflict(exception.CodeUploadIncomplete, "The file has not been uploaded yet")
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, exception.StorageError(err)
+	}
+	if object.ContentType != upload.ContentType {
+		is.discardImageUpload(ctx, upload)
+		return nil, unsupportedImageType()
+	}
+	if object.ContentLength != upload.Size || object.ContentLength > is.maxImageSize {
+		is.discardImageUpload(ctx, upload)
+		return nil, is.imageTooLarge()
+	}
+
+	// El objeto subido tiene una clave aleatoria: se copia sin metadatos a la clave de su contenido,
+	// como las demás imágenes, para que se deduplique, y después se elimina
+	file, err := is.storageRepository.OpenFile(ctx, upload.FileName)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, exception.StorageError(err)
+	}
+	defer file.Close()
+	content := bufio.NewReaderSize(file, imageSniffLength)
+	head, err := content.Peek(imageSniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		tracing.RecordError(span, err)
+		return nil, exception.StorageError(err)
+	}
+	contentType, extension, ok := sniffImage(head)
+	if !ok || contentType != upload.ContentType {
+		is.discardImageUpload(ctx, upload)
+		return nil, unsupportedImageType()
+	}
+	fileName, err := is.imageStore.storeStream(ctx, productId, content, contentType, extension)
+	if errors.Is(err, imaging.ErrMetadataNotRemovable) {
+		is.discardImageUpload(ctx, upload)
+		return nil, metadataNotRemovable("fileName")
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error storing confirmed image upload", "fileName", upload.FileName, "error", err)
+		return nil, exception.StorageError(err)
+	}
+	// Una confirmación repetida cuyo objeto no se llegó a eliminar no añade la imagen otra vez
+	if slices.Contains(existingProduct.MediaFiles(), fileName) {
+		is.discardImageUpload(ctx, upload)
+		return is.productMapper.ProductToImagesResponse(ctx, existingProduct), nil
+	}
+
+	newImage := model.ProductImage{
+		Id:        uuid.New().String(),
+		FileName:  fileName,
+		Alt:       request.Alt,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	newImage.Width, newImage.Height = imageDimensions(head)
+	is.renditions.applyFromStorage(ctx, &newImage)
+	// Si no se puede guardar, el objeto subido se conserva para reintentar la confirmación; el
+	// barrido lo libera al vencer
+	updatedProduct, err := is.attachImage(ctx, productId, ifMatch, principal, newImage, request.Primary, request.Position)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	// Si el objeto no se elimina, el registro se conserva y el barrido lo reintenta al vencer
+	is.discardImageUpload(ctx, upload)
+
+	return is.productMapper.ProductToImagesResponse(ctx, updatedProduct), nil
+}
+
+// SweepImageUploads libera los objetos de las subidas vencidas que no llegaron a confirmarse. Si no
+// se puede borrar un objeto, la subida se conserva para reintentarlo en la siguiente ejecución.
+func (is *ProductImageServiceImpl) SweepImageUploads(ctx context.Context, expiredBefore time.Time) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.SweepImageUploads")
+	defer span.End()
+
+	uploads, err := is.imageUploadRepository.GetImageUploadsExpiredBefore(ctx, expiredBefore, imageUploadSweepBatchSize)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, exception.DatabaseError(err)
+	}
+
+	swept := 0
+	for _, upload := range uploads {
+		uploadCtx := logging.WithProductId(ctx, upload.ProductId)
+		referenced, err := is.isProductFile(uploadCtx, upload.ProductId, upload.FileName)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return swept, exception.DatabaseError(err)
+		}
+		// También se eliminan las variantes, que pueden existir si falló el guardado de una confirmación
+		if !referenced && !is.imageStore.release(uploadCtx, upload.ProductId, nil, model.ProductImage{FileName: upload.FileName}) {
+			slog.ErrorContext(uploadCtx, "Error deleting unconfirmed image upload", "fileName", upload.FileName)
+			continue
+		}
+		if err := is.imageUploadRepository.DeleteImageUpload(uploadCtx, upload.Id); err != nil {
+			tracing.RecordError(span, err)
+			return swept, exception.DatabaseError(err)
+		}
+		swept++
+	}
+
+	span.SetAttributes(attribute.Int("uploads.swept", swept))
+	return swept, nil
+}
+
+func (is *ProductImageServiceImpl) BackfillRenditions(ctx context.Context, force bool, dryRun bool) (*product.RenditionBackfillReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.BackfillRenditions", attribute.Bool("backfill.force", force), attribute.Bool("backfill.dry_run", dryRun))
+	defer span.End()
+
+	report := &product.RenditionBackfillReport{DryRun: dryRun}
+	err := is.productRepository.ForEachProductChunk(ctx, "", renditionBackfillChunkSize, func(products []*model.Product) error {
+		for _, p := range products {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			report.Products++
+			is.backfillProductRenditions(logging.WithProductId(ctx, p.Id), p, force, dryRun, report)
+		}
+		return nil
+	})
+	// Los productos que no se pueden leer se registran en el log y no impiden procesar el resto
+	if errors.Is(err, repository.ErrUndecodableProducts) {
+		err = nil
+	}
+
+	span.SetAttributes(attribute.Int("backfill.generated", report.Generated), attribute.Int("backfill.failed", report.Failed))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return report, exception.DatabaseError(err)
+	}
+	return report, nil
+}
+
+// backfillProductRenditions genera las variantes de las imágenes de un producto y lo guarda con la
+// precondición de la versión leída. Si el producto cambió mientras tanto no se guarda: las variantes
+// subidas tienen claves predecibles y se reutilizan en la siguiente ejecución.
+func (is *ProductImageServiceImpl) backfillProductRenditions(ctx context.Context, p *model.Product, force bool, dryRun bool, report *product.RenditionBackfillReport) {
+	gallery := p.Gallery()
+	generated := 0
+	var replacedFiles []string
+	for i, image := range gallery {
+		report.Images++
+		if !force && !is.renditions.missing(image) {
+			report.Skipped++
+			continue
+		}
+		if dryRun {
+			report.Generated++
+			continue
+		}
+
+		renditions, err := is.renditions.generateFromStorage(ctx, image.FileName)
+		if err != nil {
+			logRenditionError(ctx, image.FileName, err)
+			report.Failed++
+			addRenditionBackfillError(report, product.RenditionBackfillError{ProductId: p.Id, ImageId: image.Id, FileName: image.FileName, Message: err.Error()})
+			continue
+		}
+		// Las variantes que ya no están configuradas dejan de estar referenciadas
+		for name, previous := range image.Renditions {
+			if _, ok := renditions[name]; !ok {
+				replacedFiles = append(replacedFiles, previous.FileName)
+			}
+		}
+		gallery[i].Renditions = renditions
+		generated++
+	}
+	if generated == 0 {
+		return
+	}
+
+	p.SetImages(gallery)
+	if _, err := is.productRepository.UpdateProduct(ctx, p); err != nil {
+		report.Failed += generated
+		addRenditionBackfillError(report, product.RenditionBackfillError{ProductId: p.Id, Message: "the product could not be saved: " + err.Error()})
+		return
+	}
+	report.Generated += generated
+	deleteImageFiles(ctx, is.storageRepository, replacedFiles)
+}
+
+// addRenditionBackfillError añade un error al informe del backfill hasta el máximo
+func addRenditionBackfillError(report *product.RenditionBackfillReport, backfillError product.RenditionBackfillError) {
+	if len(report.Errors) < maxRenditionBackfillErrors {
+		report.Errors = append(report.Errors, backfillError)
+	}
+}
+
+func (is *ProductImageServiceImpl) SyncImageReferences(ctx context.Context) (*product.ImageReferenceSyncReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.SyncImageReferences")
+	defer span.End()
+
+	report := &product.ImageReferenceSyncReport{}
+	syncProducts := func(products []*model.Product) error {
+		for _, p := range products {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			report.Products++
+			is.syncProductImageReferences(logging.WithProductId(ctx, p.Id), p, report)
+		}
+		return nil
+	}
+
+	// Primero los productos activos y después los de la papelera, que conservan sus imágenes hasta la purga
+	err := is.productRepository.ForEachProductChunk(ctx, "", renditionBackfillChunkSize, syncProducts)
+	if err == nil || errors.Is(err, repository.ErrUndecodableProducts) {
+		var deletedProducts []*model.Product
+		deletedProducts, err = is.productRepository.GetProductsDeletedBefore(ctx, time.Now().UTC().Format(time.RFC3339))
+		if err == nil {
+			err = syncProducts(deletedProducts)
+		}
+	}
+
+	span.SetAttributes(attribute.Int("sync.added", report.Added), attribute.Int("sync.failed", report.Failed))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return report, exception.DatabaseError(err)
+	}
+	return report, nil
+}
+
+// syncProductImageReferences registra la referencia del producto a cada archivo de su galería
+func (is *ProductImageServiceImpl) syncProductImageReferences(ctx context.Context, p *model.Product, report *product.ImageReferenceSyncReport) {
+	var files []string
+	for _, image := range p.Gallery() {
+		if !slices.Contains(files, image.FileName) {
+			files = append(files, image.FileName)
+		}
+	}
+	for _, fileName := range files {
+		report.Files++
+		added, err := is.imageStore.acquire(ctx, fileName, p.Id)
+		if err != nil {
+			report.Failed++
+			if len(report.Errors) < maxImageReferenceSyncErrors {
+				report.Errors = append(report.Errors, product.ImageReferenceSyncError{ProductId: p.Id, FileName: fileName, Message: err.Error()})
+			}
+			continue
+		}
+		if added {
+			report.Added++
+		}
+	}
+}
+
+// imageReference es un campo de imagen de un producto que apunta a un archivo del almacenamiento
+type imageReference struct {
+	productId string
+	imageId   string
+	field     string
+	deleted   bool
+}
+
+func (is *ProductImageServiceImpl) ReconcileStorage(ctx context.Context, request *product.StorageReconcileRequest) (*product.StorageReconcileReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.ReconcileStorage", attribute.Bool("reconcile.delete_orphans", request.DeleteOrphans))
+	defer span.End()
+
+	gracePeriod := is.reconcileGracePeriod
+	if request.GracePeriod != "" {
+		parsed, err := time.ParseDuration(request.GracePeriod)
+		if err != nil {
+			return nil, exception.Validation(exception.CodeValidationFailed, "The reconciliation options are not valid").
+				WithField("gracePeriod", "must be a duration such as 72h")
+		}
+		gracePeriod = parsed
+	}
+	// Las subidas directas pendientes de confirmar no están en ningún producto hasta que vencen
+	if minimum := max(is.uploadTTL, is.uploadURLTTL); gracePeriod < minimum {
+		return nil, exception.Validation(exception.CodeValidationFailed, "The reconciliation options are not valid").
+			WithField("gracePeriod", "must be at least "+minimum.String()+", the lifetime of pending uploads")
+	}
+	report := &product.StorageReconcileReport{DeleteOrphans: request.DeleteOrphans, GracePeriod: gracePeriod.String()}
+
+	// Las referencias se leen antes que el bucket: un archivo subido después de leerlas es más
+	// reciente que el periodo de gracia y no se elimina aunque aparezca como huérfano
+	references, err := is.collectImageReferences(ctx, report)
+	if errors.Is(err, repository.ErrUndecodableProducts) {
+		// Las imágenes de los productos que no se pueden leer aparecerían como huérfanas
+		report.UndecodableProducts = true
+		slog.WarnContext(ctx, "Orphans will not be deleted because some products could not be read", "error", err)
+	} else if err != nil {
+		tracing.RecordError(span, err)
+		return report, exception.DatabaseError(err)
+	}
+	renditionDirs := make(map[string]bool, len(references))
+	for fileName := range references {
+		renditionDirs[imaging.RenditionDir(fileName)] = true
+	}
+
+	existing := make(map[string]bool)
+	var orphans []repository.HeadObject
+	err = is.storageRepository.ListFiles(ctx, func(objects []repository.HeadObject) error {
+		for _, object := range objects {
+			report.Objects++
+			existing[object.FileName] = true
+			// Las variantes de una imagen referenciada se conservan aunque ya no estén en el producto
+			if _, ok := references[object.FileName]; ok || renditionDirs[path.Dir(object.FileName)] {
+				report.Referenced++
+				continue
+			}
+			orphans = append(orphans, object)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return report, exception.StorageError(err)
+	}
+
+	fileNames := slices.Sorted(maps.Keys(references))
+	for _, fileName := range fileNames {
+		if existing[fileName] {
+			continue
+		}
+		for _, reference := range references[fileName] {
+			report.BrokenReferences++
+			slog.WarnContext(ctx, "Broken image reference", "productId", reference.productId, "imageId", reference.imageId, "field", reference.field, "fileName", fileName)
+			if len(report.Broken) < maxStorageReconcileItems {
+				report.Broken = append(report.Broken, product.BrokenImageReference{
+					ProductId: reference.productId,
+					ImageId:   reference.imageId,
+					Field:     reference.field,
+					FileName:  fileName,
+					Deleted:   reference.deleted,
+				})
+			}
+		}
+	}
+
+	var outcomes map[string]string
+	switch {
+	case request.DeleteOrphans && report.UndecodableProducts:
+		outcomes = make(map[string]string, len(orphans))
+		for _, orphan := range orphans {
+			outcomes[orphan.FileName] = "some products could not be read"
+		}
+	case request.DeleteOrphans:
+		outcomes = is.deleteOrphans(ctx, orphans, time.Now().Add(-gracePeriod).Unix())
+	}
+	for _, orphan := range orphans {
+		report.OrphanCount++
+		report.OrphanBytes += orphan.ContentLength
+		reason, processed := outcomes[orphan.FileName]
+		deleted := processed && reason == ""
+		if deleted {
+			report.OrphansDeleted++
+		}
+		if len(report.Orphans) < maxStorageReconcileItems {
+			report.Orphans = append(report.Orphans, product.StorageOrphan{
+				FileName:     orphan.FileName,
+				Size:         orphan.ContentLength,
+				LastModified: time.Unix(orphan.LastModified, 0).UTC().Format(time.RFC3339),
+				Deleted:      deleted,
+				Reason:       reason,
+			})
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("reconcile.objects", report.Objects),
+		attribute.Int("reconcile.orphans", report.OrphanCount),
+		attribute.Int("reconcile.orphans_deleted", report.OrphansDeleted),
+		attribute.Int("reconcile.broken_references", report.BrokenReferences),
+	)
+	slog.InfoContext(ctx, "Storage reconciled", "objects", report.Objects, "orphans", report.OrphanCount, "orphansDeleted", report.OrphansDeleted, "brokenReferences", report.BrokenReferences)
+	return report, nil
+}
+
+// collectImageReferences devuelve, por archivo, los campos de imagen que lo referencian en los
+// productos activos y en los de la papelera, que conservan sus imágenes hasta la purga. Si algún
+// producto no se pudo leer devuelve las referencias del resto junto con ErrUndecodableProducts.
+func (is *ProductImageServiceImpl) collectImageReferences(ctx context.Context, report *product.StorageReconcileReport) (map[string][]imageReference, error) {
+	references := make(map[string][]imageReference)
+	collect := func(products []*model.Product) error {
+		for _, p := range products {
+			report.Products++
+			addImageReferences(references, p)
+		}
+		return ctx.Err()
+	}
+
+	undecodable := is.productRepository.ForEachProductChunk(ctx, "", renditionBackfillChunkSize, collect)
+	if undecodable != nil && !errors.Is(undecodable, repository.ErrUndecodableProducts) {
+		return nil, undecodable
+	}
+	deletedProducts, err := is.productRepository.GetProductsDeletedBefore(ctx, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	if err := collect(deletedProducts); err != nil {
+		return nil, err
+	}
+	return references, undecodable
+}
+
+// addImageReferences añade a references los campos de imagen del producto
+func addImageReferences(references map[string][]imageReference, p *model.Product) {
+	add := func(fileName string, imageId string, field string) {
+		if fileName != "" {
+			references[fileName] = append(references[fileName], imageReference{productId: p.Id, imageId: imageId, field: field, deleted: p.IsDeleted()})
+		}
+	}
+	// FileImage es una copia de la imagen principal salvo en los productos anteriores a la galería
+	if !slices.ContainsFunc(p.Images, func(image model.ProductImage) bool { return image.FileName == p.FileImage }) {
+		add(p.FileImage, "", "fileImage")
+	}
+	for _, image := range p.Images {
+		add(image.FileName, image.Id, "images")
+		for name, rendition := range image.Renditions {
+			add(rendition.FileName, image.Id, "images.renditions."+name)
+		}
+	}
+}
+
+// deleteOrphans elimina los huérfanos modificados antes de cutoff. Cada original se marca para
+// eliminación antes de borrarlo, de modo que si alguien sube el mismo contenido mientras tanto
+// espera a que termine, y se elimina junto con sus variantes huérfanas. Devuelve por archivo el
+// motivo por el que se conservó, o "" si se eliminó.
+func (is *ProductImageServiceImpl) deleteOrphans(ctx context.Context, orphans []repository.HeadObject, cutoff int64) map[string]string {
+	ctx = context.WithoutCancel(ctx)
+	outcomes := make(map[string]string, len(orphans))
+	renditions := make(map[string][]repository.HeadObject)
+	for _, orphan := range orphans {
+		if strings.HasPrefix(orphan.FileName, imaging.RenditionDir("")) {
+			dir := path.Dir(orphan.FileName)
+			renditions[dir] = append(renditions[dir], orphan)
+		}
+	}
+	keep := func(reason string, objects ...repository.HeadObject) {
+		for _, object := range objects {
+			outcomes[object.FileName] = reason
+		}
+	}
+	deleteFiles := func(objects ...repository.HeadObject) bool {
+		deleted := true
+		for _, object := range objects {
+			if err := is.storageRepository.DeleteFile(ctx, object.FileName); err != nil {
+				slog.ErrorContext(ctx, "Error deleting orphan file", "fileName", object.FileName, "error", err)
+				outcomes[object.FileName] = "delete failed: " + err.Error()
+				deleted = false
+				continue
+			}
+			outcomes[object.FileName] = ""
+		}
+		return deleted
+	}
+
+	for _, orphan := range orphans {
+		if strings.HasPrefix(orphan.FileName, imaging.RenditionDir("")) {
+			continue
+		}
+		dir := imaging.RenditionDir(orphan.FileName)
+		orphanRenditions := renditions[dir]
+		delete(renditions, dir)
+		if orphan.LastModified > cutoff {
+			keep("newer than the grace period", orphan)
+			keep("its original is newer than the grace period", orphanRenditions...)
+			continue
+		}
+		marked, err := is.imageStore.imageFileRepository.MarkImageFileDeleting(ctx, orphan.FileName)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error marking orphan file for deletion", "fileName", orphan.FileName, "error", err)
+			keep("could not be marked for deletion: "+err.Error(), orphan)
+			keep("its original could not be marked for deletion", orphanRenditions...)
+			continue
+		}
+		if !marked {
+			keep("registered as referenced in image_files", orphan)
+			keep("its original is registered as referenced in image_files", orphanRenditions...)
+			continue
+		}
+		if deleteFiles(append([]repository.HeadObject{orphan}, orphanRenditions...)...) {
+			if err := is.imageStore.imageFileRepository.DeleteImageFile(ctx, orphan.FileName); err != nil {
+				slog.ErrorContext(ctx, "Error deleting image file record", "fileName", orphan.FileName, "error", err)
+			}
+		}
+	}
+
+	// Variantes cuyo original ya no está en el almacenamiento
+	for _, objects := range renditions {
+		for _, object := range objects {
+			if object.LastModified > cutoff {
+				keep("newer than the grace period", object)
+				continue
+			}
+			deleteFiles(object)
+		}
+	}
+	return outcomes
+}
+
+// isProductFile indica si el archivo pertenece a las imágenes del producto, también si está en la papelera
+func (is *ProductImageServiceImpl) isProductFile(ctx context.Context, productId string, fileName string) (bool, error) {
+	p, err := is.productRepository.GetProductById(ctx, productId)
+	if err != nil {
+		return false, err
+	}
+	if p == nil {
+		if p, err = is.productRepository.GetDeletedProductById(ctx, productId); err != nil {
+			return false, err
+		}
+	}
+	return p != nil && slices.Contains(p.MediaFiles(), fileName), nil
+}
+
+// discardImageUpload elimina el objeto y el registro de una subida rechazada o ya copiada a la clave
+// de su contenido
+func (is *ProductImageServiceImpl) discardImageUpload(ctx context.Context, upload *model.ImageUpload) {
+	ctx = context.WithoutCancel(ctx)
+	if err := is.storageRepository.DeleteFile(ctx, upload.FileName); err != nil {
+		// El registro se conserva para que el barrido reintente eliminar el objeto
+		slog.ErrorContext(ctx, "Error deleting image upload", "fileName", upload.FileName, "error", err)
+		return
+	}
+	if err := is.imageUploadRepository.DeleteImageUpload(ctx, upload.Id); err != nil {
+		slog.ErrorContext(ctx, "Error deleting image upload", "uploadId", upload.Id, "error", err)
+	}
+}
+
+// checkNewImage comprueba, antes de subir la imagen, que el producto se puede modificar y que la
+// galería admite una imagen más en la posición pedida; se vuelve a comprobar al guardarla
+func (is *ProductImageServiceImpl) checkNewImage(ctx context.Context, productId string, requestedPosition *int, ifMatch string, principal *auth.Principal) error {
+	existingProduct, err := is.getManagedProduct(ctx, productId, ifMatch, principal)
+	if err != nil {
+		return err
+	}
+	_, err = is.newImagePosition(existingProduct, requestedPosition)
+	return err
+}
+
+// newImagePosition comprueba el límite de la galería y la posición pedida para una nueva imagen
+func (is *ProductImageServiceImpl) newImagePosition(existingProduct *model.Product, requestedPosition *int) (int, error) {
+	galleryLength := len(existingProduct.Gallery())
+	if galleryLength >= is.maxImages {
+		return 0, exception.Conflict(exception.CodeImageLimitReached, "The product already has the maximum of "+strconv.Itoa(is.maxImages)+" images")
+	}
+	if requestedPosition == nil {
+		return galleryLength, nil
+	}
+	if *requestedPosition < 0 || *requestedPosition > galleryLength {
+		return 0, exception.Validation(exception.CodeValidationFailed, "The image data is not valid").WithField("position", "must be between 0 and "+strconv.Itoa(galleryLength))
+	}
+	return *requestedPosition, nil
+}
+
+// attachImage inserta la imagen ya subida en la galería (por defecto al final) y guarda el producto;
+// si no se puede guardar, libera su archivo salvo que el producto ya lo usara en otra imagen
+func (is *ProductImageServiceImpl) attachImage(ctx context.Context, productId string, ifMatch string, principal *auth.Principal, newImage model.ProductImage, primary bool, requestedPosition *int) (*model.Product, error) {
+	var referenced []string
+	updatedProduct, err := is.updateGallery(ctx, productId, ifMatch, principal, func(existingProduct *model.Product) error {
+		referenced = existingProduct.MediaFiles()
+		position, err := is.newImagePosition(existingProduct, requestedPosition)
+		if err != nil {
+			return err
+		}
+
+		gallery := existingProduct.Gallery()
+		image := newImage
+		if primary {
+			for i := range gallery {
+				gallery[i].Primary = false
+			}
+			image.Primary = true
+		}
+		existingProduct.SetImages(slices.Insert(gallery, position, image))
+		return nil
+	})
+	if err != nil {
+		is.imageStore.release(ctx, productId, referenced, newImage)
+		return nil, err
+	}
+	return updatedProduct, nil
+}
+
+func (is *ProductImageServiceImpl) UpdateProductImage(ctx context.Context, productId string, imageId string, request *product.UpdateProductImageRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.UpdateProductImage", attribute.String("product.id", productId), attribute.String("image.id", imageId))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, productId)
+
+	if err := validateAltText(request.Alt); err != nil {
+		return nil, err
+	}
+
+	updatedProduct, err := is.updateGallery(ctx, productId, ifMatch, principal, func(existingProduct *model.Product) error {
+		gallery := existingProduct.Gallery()
+		index := slices.IndexFunc(gallery, func(image model.ProductImage) bool { return image.Id == imageId })
+		if index < 0 {
+			return imageNotFound()
+		}
+		if request.Alt != nil {
+			gallery[index].Alt = request.Alt
+			if len(request.Alt) == 0 {
+				gallery[index].Alt = nil
+			}
+		}
+		if request.Primary {
+			for i := range gallery {
+				gallery[i].Primary = i == index
+			}
+		}
+		existingProduct.SetImages(gallery)
+		return nil
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return is.productMapper.ProductToImagesResponse(ctx, updatedProduct), nil
+}
+
+func (is *ProductImageServiceImpl) ReorderProductImages(ctx context.Context, productId string, request *product.ReorderProductImagesRequest, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.ReorderProductImages", attribute.String("product.id", productId))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, productId)
+
+	updatedProduct, err := is.updateGallery(ctx, productId, ifMatch, principal, func(existingProduct *model.Product) error {
+		// El nuevo orden debe contener cada imagen exactamente una vez
+		gallery := existingProduct.Gallery()
+		invalidOrder := exception.Validation(exception.CodeValidationFailed, "The image order is not valid")
+		if len(request.ImageIds) != len(gallery) {
+			return invalidOrder.WithField("imageIds", "must contain the "+strconv.Itoa(len(gallery))+" image IDs of the product")
+		}
+		reordered := make([]model.ProductImage, 0, len(gallery))
+		for i, imageId := range request.ImageIds {
+			index := slices.IndexFunc(gallery, func(image model.ProductImage) bool { return image.Id == imageId })
+			if index < 0 {
+				return invalidOrder.WithField("imageIds["+strconv.Itoa(i)+"]", "is not an image of the product")
+			}
+			if slices.Index(request.ImageIds[:i], imageId) >= 0 {
+				return invalidOrder.WithField("imageIds["+strconv.Itoa(i)+"]", "is duplicated")
+			}
+			reordered = append(reordered, gallery[index])
+		}
+		existingProduct.SetImages(reordered)
+		return nil
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return is.productMapper.ProductToImagesResponse(ctx, updatedProduct), nil
+}
+
+func (is *ProductImageServiceImpl) DeleteProductImage(ctx context.Context, productId string, imageId string, ifMatch string, principal *auth.Principal) (*product.ProductImagesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImageService.DeleteProductImage", attribute.String("product.id", productId), attribute.String("image.id", imageId))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, productId)
+
+	var removed model.ProductImage
+	updatedProduct, err := is.updateGallery(ctx, productId, ifMatch, principal, func(existingProduct *model.Product) error {
+		gallery := existingProduct.Gallery()
+		index := slices.IndexFunc(gallery, func(image model.ProductImage) bool { return image.Id == imageId })
+		if index < 0 {
+			return imageNotFound()
+		}
+		removed = gallery[index]
+		// Si se quita la imagen principal, la primera de las restantes pasa a serlo
+		existingProduct.SetImages(slices.Delete(gallery, index, index+1))
+		return nil
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	// El archivo se elimina si ningún producto lo referencia; si falla solo queda huérfano
+	is.imageStore.release(ctx, productId, updatedProduct.MediaFiles(), removed)
+
+	return is.productMapper.ProductToImagesResponse(ctx, updatedProduct), nil
+}
+
+// getManagedProduct obtiene el producto que se va a modificar y comprueba la autoría y la versión
+func (is *ProductImageServiceImpl) getManagedProduct(ctx context.Context, productId string, ifMatch string, principal *auth.Principal) (*model.Product, error) {
+	existingProduct, err := is.productRepository.GetProductById(ctx, productId)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting product for image change", "id", productId, "error", err)
+		return nil, exception.DatabaseError(err)
+	}
+	if existingProduct == nil {
+		return nil, productNotFound()
+	}
+
+	// Solo el autor o un administrador pueden modificar el producto
+	if !principal.CanManage(existingProduct.AuthorId) {
+		return nil, productNotOwned()
+	}
+
+	// El cliente solo puede modificar la versión que leyó
+	if !existingProduct.MatchesIfMatch(ifMatch) {
+		return nil, versionMismatch()
+	}
+	return existingProduct, nil
+}
+
+// updateGallery aplica change a la galería del producto y lo guarda con la precondición de la
+// versión leída; sin If-Match se repite sobre la versión nueva si otra escritura se adelanta
+func (is *ProductImageServiceImpl) updateGallery(ctx context.Context, productId string, ifMatch string, principal *auth.Principal, change func(existingProduct *model.Product) error) (*model.Product, error) {
+	return updateManagedProduct(ctx, is.productRepository, productId, ifMatch, principal, func(existingProduct *model.Product) error {
+		if err := change(existingProduct); err != nil {
+			return err
+		}
+		existingProduct.UpdatedAt = time.Now().Format(time.RFC3339)
+		return nil
+	})
+}
+
+// metadataNotRemovable crea el error de imagen cuyos metadatos (ubicación GPS incluida) no se
+// pueden quitar sin volver a codificarla
+func metadataNotRemovable(field string) error {
+	return exception.Validation(exception.CodeInvalidImage, "The image is not a valid file").WithField(field, "metadata such as the GPS location could not be removed; export the image again without metadata")
+}
+
+// imageNotFound crea el error de imagen inexistente en la galería
+func imageNotFound() error {
+	return exception.NotFound(exception.CodeImageNotFound, "Image not found in the product gallery")
+}
+
+// imageTooLarge crea el error de imagen que supera el tamaño máximo
+func (is *ProductImageServiceImpl) imageTooLarge() error {
+	return exception.PayloadTooLarge(exception.CodeFileTooLarge, "The image must not exceed "+strconv.FormatInt(is.maxImageSize>>20, 10)+" MB")
+}
+
+// imageReadError distingue un cuerpo que supera el límite de la petición de una subida interrumpida
+func (is *ProductImageServiceImpl) imageReadError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return is.imageTooLarge()
+	}
+	return exception.Validation(exception.CodeInvalidRequest, "The image upload could not be read: "+err.Error())
+}
+
+// unsupportedImageType crea el error de imagen con un formato no admitido
+func unsupportedImageType() error {
+	return exception.UnsupportedMediaType(exception.CodeUnsupportedMediaType, "Only JPEG, PNG, WebP and AVIF images are accepted")
+}
+
+// sniffImage detecta el tipo de imagen a partir de sus primeros bytes y devuelve su tipo MIME y
+// la extensión del archivo; ok es false si no es uno de los formatos admitidos
+func sniffImage(head []byte) (contentType string, extension string, ok bool) {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xFF\xD8\xFF")):
+		return "image/jpeg", ".jpg", true
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png", ".png", true
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return "image/webp", ".webp", true
+	case len(head) >= 12 && string(head[4:8]) == "ftyp" && (string(head[8:12]) == "avif" || string(head[8:12]) == "avis"):
+		// net/http no detecta AVIF: se comprueba la marca del contenedor ISO BMFF
+		return "image/avif", ".avif", true
+	default:
+		return "", "", false
+	}
+}
+
+// imageDimensions devuelve el ancho y el alto de la imagen si su formato se puede leer (JPEG, PNG,
+// GIF y WebP); 0 si no se pueden obtener a partir de los bytes disponibles
+func imageDimensions(data []byte) (width int, height int) {
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return imageConfig.Width, imageConfig.Height
+}
+
+// errImageTooLarge interrumpe la lectura de una imagen que supera el tamaño máximo
+var errImageTooLarge = errors.New("image exceeds the maximum size")
+
+// sizeLimitReader deja de leer cuando se supera el límite y registra si la lectura se
+// interrumpió por el límite o por un error del cliente
+type sizeLimitReader struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+	err      error
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		r.exceeded = true
+		return n, errImageTooLarge
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+// validateAltText comprueba que cada idioma sea una etiqueta BCP 47 y que los textos no sean demasiado largos
+func validateAltText(alt map[string]string) error {
+	validationError := exception.Validation(exception.CodeValidationFailed, "The alternative text is not valid")
+	for locale, text := range alt {
+		if _, err := language.Parse(locale); err != nil {
+			validationError.WithField("alt."+locale, "must be keyed by a BCP 47 language tag such as es or en-US")
+			continue
+		}
+		if utf8.RuneCountInString(text) > maxAltTextLength {
+			validationError.WithField("alt."+locale, "must not exceed "+strconv.Itoa(maxAltTextLength)+" characters")
+		}
+	}
+	if len(validationError.Fields) > 0 {
+		return validationError
+	}
+	return nil
+}
+
+// decodeImageBase64 decodifica una imagen recibida en base64 dentro del JSON
+func decodeImageBase64(imageBase64 string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(imageBase64)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("%w: the image is not valid base64", repository.ErrInvalidFile)
+	}
+	return data, nil
+}
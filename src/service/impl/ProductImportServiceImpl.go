@@ -0,0 +1,760 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/repository/impl"
+	"github.com/ruiborda/ecommerce-product-service/src/spreadsheet"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Campos de producto a los que se puede asignar una columna de la hoja
+const (
+	importFieldSku         = "sku"
+	importFieldName        = "name"
+	importFieldDescription = "description"
+	importFieldPrice       = "price"
+	importFieldCurrency    = "currency"
+	importFieldDiscount    = "discount"
+	importFieldStock       = "stock"
+	importFieldCategory    = "category"
+	importFieldImage       = "image"
+	importFieldStatus      = "status"
+	importFieldPublishAt   = "publishAt"
+	importFieldUnpublishAt = "unpublishAt"
+)
+
+var importFields = []string{
+	importFieldSku, importFieldName, importFieldDescription, importFieldPrice, importFieldCurrency, importFieldDiscount,
+	importFieldStock, importFieldCategory, importFieldImage, importFieldStatus, importFieldPublishAt, importFieldUnpublishAt,
+}
+
+// importFieldAliases son cabeceras habituales que se asignan a un campo sin configurar el mapeo
+var importFieldAliases = map[string]string{
+	"categoryid":   importFieldCategory,
+	"categoryname": importFieldCategory,
+	"imageurl":     importFieldImage,
+	"fileimage":    importFieldImage,
+}
+
+const (
+	// maxImportErrors limita los errores de fila guardados (un documento de Firestore no puede superar 1 MiB)
+	maxImportErrors = 1000
+	// importJobRetention es el tiempo que se conservan los trabajos antes de que los elimine el TTL de Firestore
+	importJobRetention = 7 * 24 * time.Hour
+	// maxImportImageSize limita el tamaño de las imágenes descargadas desde una URL
+	maxImportImageSize = 10 << 20
+	// importJobStaleGrace es el margen, además de IMPORT_JOB_TIMEOUT, tras el que un trabajo sin
+	// terminar se considera abandonado: cubre el guardado del informe y la diferencia de relojes
+	importJobStaleGrace = 5 * time.Minute
+)
+
+type ProductImportServiceImpl struct {
+	productRepository   repository.ProductRepository
+	categoryRepository  repository.CategoryRepository
+	importJobRepository repository.ImportJobRepository
+	imageStore          *imageStore
+	productMapper       *mapper.ProductMapper
+	httpClient          *http.Client
+	syncMaxRows         int
+	jobTimeout          time.Duration
+	allowedImageHosts   imageHostAllowlist
+	importWorker        *importWorker
+}
+
+func NewProductImportServiceImpl() *ProductImportServiceImpl {
+	storageRepository := impl.NewStorageRepository()
+	allowedImageHosts := imageHostAllowlist(splitList(config.GetEnv("IMPORT_IMAGE_ALLOWED_HOSTS", "")))
+	return &ProductImportServiceImpl{
+		productRepository:   impl.NewProductRepositoryImpl(),
+		categoryRepository:  impl.NewCategoryRepositoryImpl(),
+		importJobRepository: impl.NewImportJobRepositoryImpl(),
+		imageStore:          newImageStore(storageRepository, impl.NewImageFileRepositoryImpl(), newRenditionGenerator(storageRepository)),
+		productMapper:       &mapper.ProductMapper{},
+		httpClient:          newImageDownloadClient(allowedImageHosts),
+		syncMaxRows:         config.GetEnvInt("IMPORT_SYNC_MAX_ROWS", 200),
+		jobTimeout:          config.GetEnvDuration("IMPORT_JOB_TIMEOUT", 30*time.Minute),
+		allowedImageHosts:   allowedImageHosts,
+		importWorker:        getImportWorker(),
+	}
+}
+
+// importRow es una fila de datos de la hoja con su número de fila original
+type importRow struct {
+	number int
+	cells  []string
+}
+
+// importWrite es una fila válida pendiente de escribirse
+type importWrite struct {
+	row      int
+	product  *model.Product
+	created  bool
+	imageURL string // imagen que hay que descargar antes de escribir
+	// image es la imagen principal que asigna la fila, que se libera si no se escribe
+	image model.ProductImage
+	// storedFiles son los archivos que el producto ya referenciaba antes de la importación
+	storedFiles []string
+	// replacedImages son las imágenes reemplazadas, que se liberan tras escribir
+	replacedImages []model.ProductImage
+}
+
+// importContext contiene los datos que se leen una vez por importación
+type importContext struct {
+	columns    map[string]int
+	principal  *auth.Principal
+	categories map[string]string // ID y nombre en minúsculas -> ID de categoría
+	bySku      map[string]*model.Product
+	seenSkus   map[string]int // SKU -> fila en la que apareció primero
+	// reusableImages indica, para cada archivo ya comprobado, si el usuario puede asignarlo
+	reusableImages map[string]bool
+}
+
+func (is *ProductImportServiceImpl) ImportProducts(ctx context.Context, request *product.ImportProductsRequest, principal *auth.Principal) (*product.ImportProductsResponse, bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImportService.ImportProducts", attribute.String("import.file_name", request.FileName))
+	defer span.End()
+
+	format, err := spreadsheet.DetectFormat(request.FileName, request.Content)
+	if err != nil {
+		return nil, false, exception.UnsupportedMediaType(exception.CodeUnsupportedMediaType, "Only CSV and XLSX files can be imported")
+	}
+	rows, err := spreadsheet.Read(format, request.Content)
+	if err != nil {
+		return nil, false, exception.Validation(exception.CodeInvalidFile, "The file could not be read: "+err.Error())
+	}
+	if len(rows) == 0 {
+		return nil, false, exception.Validation(exception.CodeInvalidFile, "The file is empty")
+	}
+	columns, err := mapImportColumns(rows[0], request.Mapping)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Las filas vacías se ignoran; se conserva su número para el informe de errores
+	var dataRows []importRow
+	for i, cells := range rows[1:] {
+		if slices.ContainsFunc(cells, func(cell string) bool { return strings.TrimSpace(cell) != "" }) {
+			dataRows = append(dataRows, importRow{number: i + 2, cells: cells})
+		}
+	}
+
+	now := time.Now()
+	job := &model.ImportJob{
+		Id:        uuid.New().String(),
+		AuthorId:  principal.Subject,
+		FileName:  request.FileName,
+		Format:    string(format),
+		DryRun:    request.DryRun,
+		Status:    model.ImportJobPending,
+		TotalRows: len(dataRows),
+		Errors:    []model.ImportRowError{},
+		CreatedAt: now.UTC().Format(time.RFC3339),
+		ExpiresAt: now.Add(importJobRetention),
+	}
+	span.SetAttributes(attribute.String("import.job_id", job.Id), attribute.Int("import.rows", job.TotalRows), attribute.Bool("import.dry_run", job.DryRun))
+
+	if len(dataRows) <= is.syncMaxRows {
+		is.runImport(ctx, job, columns, dataRows, principal)
+		if err := is.importJobRepository.SaveImportJob(ctx, job); err != nil {
+			slog.WarnContext(ctx, "Error saving import job report", "id", job.Id, "error", err)
+		}
+		return is.productMapper.ImportJobToResponse(job), false, nil
+	}
+
+	// Los archivos grandes se procesan en segundo plano; el cliente consulta el estado del trabajo
+	if err := is.importJobRepository.SaveImportJob(ctx, job); err != nil {
+		tracing.RecordError(span, err)
+		return nil, false, exception.DatabaseError(err)
+	}
+	response := is.productMapper.ImportJobToResponse(job)
+
+	submitted := is.importWorker.submit(func(workerCtx context.Context) {
+		jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), is.jobTimeout)
+		defer cancel()
+		// Si el servicio se apaga y no puede esperar a que termine, el trabajo se interrumpe
+		stopAfter := context.AfterFunc(workerCtx, cancel)
+		defer stopAfter()
+
+		job.Status = model.ImportJobRunning
+		if err := is.importJobRepository.SaveImportJob(jobCtx, job); err != nil {
+			slog.WarnContext(jobCtx, "Error saving import job status", "id", job.Id, "error", err)
+		}
+		is.runImport(jobCtx, job, columns, dataRows, principal)
+
+		// El informe se guarda aunque el trabajo haya agotado su plazo
+		saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(jobCtx), 30*time.Second)
+		defer cancelSave()
+		if err := is.importJobRepository.SaveImportJob(saveCtx, job); err != nil {
+			slog.ErrorContext(saveCtx, "Error saving import job report", "id", job.Id, "error", err)
+		}
+	})
+	if !submitted {
+		job.Status = model.ImportJobFailed
+		job.Message = "Too many imports in progress; no rows were imported"
+		job.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+		if err := is.importJobRepository.SaveImportJob(ctx, job); err != nil {
+			slog.WarnContext(ctx, "Error saving import job status", "id", job.Id, "error", err)
+		}
+		return nil, false, exception.RateLimited(exception.CodeImportJobsBusy, "Too many imports in progress, please retry later")
+	}
+
+	return response, true, nil
+}
+
+func (is *ProductImportServiceImpl) GetImportJob(ctx context.Context, id string, principal *auth.Principal) (*product.ImportProductsResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImportService.GetImportJob", attribute.String("import.job_id", id))
+	defer span.End()
+
+	job, err := is.importJobRepository.GetImportJobById(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, exception.DatabaseError(err)
+	}
+
+	// Los trabajos de otros usuarios no existen para quien no puede gestionarlos
+	if job == nil || !principal.CanManage(job.AuthorId) {
+		return nil, exception.NotFound(exception.CodeImportJobNotFound, "Import job not found")
+	}
+
+	return is.productMapper.ImportJobToResponse(job), nil
+}
+
+func (is *ProductImportServiceImpl) FailStaleImportJobs(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImportService.FailStaleImportJobs")
+	defer span.End()
+
+	jobs, err := is.importJobRepository.GetUnfinishedImportJobs(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, exception.DatabaseError(err)
+	}
+
+	// Los trabajos empiezan al crearse y se cancelan al agotar su plazo
+	cutoff := now.Add(-is.jobTimeout - importJobStaleGrace)
+	failed := 0
+	for _, job := range jobs {
+		createdAt, err := time.Parse(time.RFC3339, job.CreatedAt)
+		if err != nil || createdAt.After(cutoff) {
+			continue
+		}
+		job.Status = model.ImportJobFailed
+		job.Message = "The import was interrupted before finishing"
+		job.FinishedAt = now.UTC().Format(time.RFC3339)
+		if err := is.importJobRepository.SaveImportJob(ctx, job); err != nil {
+			tracing.RecordError(span, err)
+			return failed, exception.DatabaseError(err)
+		}
+		slog.WarnContext(ctx, "Stale import job marked as failed", "id", job.Id, "processed", job.ProcessedRows, "rows", job.TotalRows)
+		failed++
+	}
+
+	span.SetAttributes(attribute.Int("import.stale_jobs", failed))
+	return failed, nil
+}
+
+// runImport valida todas las filas y, salvo en modo de prueba, escribe las válidas en lotes.
+// El resultado y los errores de cada fila quedan registrados en el trabajo.
+func (is *ProductImportServiceImpl) runImport(ctx context.Context, job *model.ImportJob, columns map[string]int, rows []importRow, principal *auth.Principal) {
+	ctx, span := tracing.StartSpan(ctx, "ProductImportService.RunImport", attribute.String("import.job_id", job.Id))
+	defer span.End()
+
+	job.Status = model.ImportJobRunning
+	defer func() {
+		if job.Status == model.ImportJobRunning {
+			job.Status = model.ImportJobCompleted
+		}
+		job.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+		slog.InfoContext(ctx, "Product import finished", "id", job.Id, "status", job.Status, "dryRun", job.DryRun,
+			"rows", job.TotalRows, "created", job.Created, "updated", job.Updated, "failed", job.Failed)
+	}()
+
+	importCtx, err := is.loadImportContext(ctx, columns, rows, principal)
+	if err != nil {
+		tracing.RecordError(span, err)
+		job.Status = model.ImportJobFailed
+		job.Message = "The catalog could not be read; no rows were imported"
+		return
+	}
+
+	writes := make([]*importWrite, 0, len(rows))
+	for _, row := range rows {
+		write, err := is.prepareRow(ctx, importCtx, row)
+		job.ProcessedRows++
+		if err != nil {
+			addImportError(job, row.number, rowCell(row, columns, importFieldSku), err)
+			continue
+		}
+		writes = append(writes, write)
+	}
+
+	if job.DryRun {
+		countImportWrites(job, writes)
+		return
+	}
+
+	// Descargar las imágenes indicadas por URL y referenciar los archivos existentes; una imagen
+	// fallida solo invalida su fila
+	valid := writes[:0]
+	for _, write := range writes {
+		switch {
+		case write.imageURL != "":
+			primaryImage, err := is.uploadImageFromURL(ctx, write.product.Id, write.imageURL)
+			if err != nil {
+				addImportError(job, write.row, write.product.Sku, exception.Validation(exception.CodeInvalidImage, "The image could not be imported").WithField(importFieldImage, err.Error()))
+				continue
+			}
+			write.image = primaryImage
+			write.replacedImages = write.product.SetPrimaryImage(primaryImage)
+		case write.image.FileName != "":
+			if _, err := is.imageStore.acquire(ctx, write.image.FileName, write.product.Id); err != nil {
+				addImportError(job, write.row, write.product.Sku, exception.Validation(exception.CodeInvalidImage, "The image could not be imported").WithField(importFieldImage, "could not be referenced"))
+				continue
+			}
+		}
+		valid = append(valid, write)
+	}
+	writes = valid
+
+	products := make([]*model.Product, len(writes))
+	for i, write := range writes {
+		products[i] = write.product
+	}
+	writeErrors := writeProductsInBatches(ctx, is.productRepository, products)
+
+	written := writes[:0]
+	for i, write := range writes {
+		if writeErrors[i] != nil {
+			addImportError(job, write.row, write.product.Sku, productWriteError(writeErrors[i], ""))
+			// La imagen de una fila que no se escribió deja de estar referenciada por el producto
+			is.imageStore.release(ctx, write.product.Id, write.storedFiles, write.image)
+			continue
+		}
+		is.imageStore.release(ctx, write.product.Id, write.product.MediaFiles(), write.replacedImages...)
+		written = append(written, write)
+	}
+	countImportWrites(job, written)
+
+	if ctx.Err() != nil {
+		job.Status = model.ImportJobFailed
+		job.Message = "The import was interrupted before finishing"
+	}
+}
+
+// loadImportContext lee las categorías y, en bloques, los productos con los SKUs del archivo
+// (también los de la papelera) para resolver nombres de categoría y hacer upsert por SKU sin una
+// consulta por fila
+func (is *ProductImportServiceImpl) loadImportContext(ctx context.Context, columns map[string]int, rows []importRow, principal *auth.Principal) (*importContext, error) {
+	categories, err := is.categoryRepository.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	importCtx := &importContext{
+		columns:        columns,
+		principal:      principal,
+		categories:     make(map[string]string, len(categories)*2),
+		bySku:          map[string]*model.Product{},
+		seenSkus:       map[string]int{},
+		reusableImages: map[string]bool{},
+	}
+	for _, category := range categories {
+		importCtx.categories[category.Id] = category.Id
+		importCtx.categories[strings.ToLower(strings.TrimSpace(category.Name))] = category.Id
+	}
+
+	var skus []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if sku := rowCell(row, columns, importFieldSku); sku != "" && !seen[sku] {
+			seen[sku] = true
+			skus = append(skus, sku)
+		}
+	}
+	for start := 0; start < len(skus); start += repository.MaxSkusPerQuery {
+		products, err := is.productRepository.GetProductsBySkus(ctx, skus[start:min(start+repository.MaxSkusPerQuery, len(skus))])
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			// Si varios productos comparten el SKU se actualiza el que no está en la papelera
+			if existing, ok := importCtx.bySku[p.Sku]; !ok || existing.IsDeleted() {
+				importCtx.bySku[p.Sku] = p
+			}
+		}
+	}
+	return importCtx, nil
+}
+
+// prepareRow convierte una fila en el producto que hay que crear o actualizar. En los productos
+// existentes solo se modifican las celdas con valor; en los nuevos se aplican las mismas reglas
+// que en la creación individual.
+func (is *ProductImportServiceImpl) prepareRow(ctx context.Context, importCtx *importContext, row importRow) (*importWrite, error) {
+	cell := func(field string) (string, bool) {
+		value := rowCell(row, importCtx.columns, field)
+		return value, value != ""
+	}
+	invalidRow := exception.Validation(exception.CodeValidationFailed, "The row is not valid")
+
+	sku, _ := cell(importFieldSku)
+	if sku == "" {
+		return nil, invalidRow.WithField(importFieldSku, "is required")
+	}
+	if firstRow, ok := importCtx.seenSkus[sku]; ok {
+		return nil, invalidRow.WithField(importFieldSku, fmt.Sprintf("is duplicated (first seen in row %d)", firstRow))
+	}
+	importCtx.seenSkus[sku] = row.number
+
+	now := time.Now().Format(time.RFC3339)
+	write := &importWrite{row: row.number}
+	existingProduct := importCtx.bySku[sku]
+	if existingProduct != nil {
+		if !importCtx.principal.CanManage(existingProduct.AuthorId) {
+			return nil, productNotOwned()
+		}
+		// Crear otro producto con el mismo SKU dejaría dos productos al restaurar el borrado
+		if existingProduct.IsDeleted() {
+			return nil, invalidRow.WithField(importFieldSku, "belongs to a product in the trash; restore it with POST /api/v1/products/:id/restore before importing it")
+		}
+		// Se modifica una copia que conserva el UpdateTime leído para la precondición
+		updatedProduct := *existingProduct
+		write.product = &updatedProduct
+	} else {
+		write.created = true
+		write.product = &model.Product{
+			Id:        uuid.New().String(),
+			AuthorId:  importCtx.principal.Subject,
+			Sku:       sku,
+			Status:    model.ProductStatusDraft,
+			CreatedAt: now,
+		}
+	}
+	p := write.product
+	p.UpdatedAt = now
+
+	if value, ok := cell(importFieldName); ok {
+		p.Name = value
+	}
+	if value, ok := cell(importFieldDescription); ok {
+		p.Description = value
+	}
+	if value, ok := cell(importFieldCurrency); ok {
+		p.Currency = strings.ToUpper(value)
+	}
+	if value, ok := cell(importFieldPrice); ok {
+		if price, err := parseDecimal(value); err != nil {
+			invalidRow.WithField(importFieldPrice, "must be a number")
+		} else {
+			p.Price = price
+		}
+	}
+	if value, ok := cell(importFieldDiscount); ok {
+		if discount, err := parseDecimal(value); err != nil {
+			invalidRow.WithField(importFieldDiscount, "must be a number")
+		} else {
+			p.Discount = discount
+		}
+	}
+	if value, ok := cell(importFieldStock); ok {
+		if stock, err := strconv.Atoi(value); err != nil {
+			invalidRow.WithField(importFieldStock, "must be an integer")
+		} else {
+			p.Stock = stock
+		}
+	}
+	if value, ok := cell(importFieldCategory); ok {
+		if categoryId, found := importCtx.categories[value]; found {
+			p.CategoryId = categoryId
+		} else if categoryId, found := importCtx.categories[strings.ToLower(value)]; found {
+			p.CategoryId = categoryId
+		} else {
+			invalidRow.WithField(importFieldCategory, "does not match any category ID or name")
+		}
+	}
+	if value, ok := cell(importFieldStatus); ok {
+		status := model.ProductStatus(strings.ToLower(value))
+		switch {
+		case existingProduct != nil && status != existingProduct.EffectiveStatus():
+			invalidRow.WithField(importFieldStatus, "cannot be changed by an import; use PUT /api/v1/products/:id/status")
+		case existingProduct == nil && status != model.ProductStatusDraft && status != model.ProductStatusPublished:
+			invalidRow.WithField(importFieldStatus, "must be draft or published")
+		default:
+			p.Status = status
+		}
+	}
+	if value, ok := cell(importFieldPublishAt); ok {
+		p.PublishAt = value
+	}
+	if value, ok := cell(importFieldUnpublishAt); ok {
+		p.UnpublishAt = value
+	}
+	if value, ok := cell(importFieldImage); ok && value != p.FileImage {
+		if err := is.resolveImage(ctx, importCtx, write, value); err != nil {
+			invalidRow.WithField(importFieldImage, err.Error())
+		}
+	}
+	if len(invalidRow.Fields) > 0 {
+		return nil, invalidRow
+	}
+
+	if err := validateProductFields(p.Name, p.Price, p.Discount, p.Stock); err != nil {
+		return nil, err
+	}
+	publishAt, unpublishAt, err := normalizeSchedule(p.PublishAt, p.UnpublishAt)
+	if err != nil {
+		return nil, err
+	}
+	p.PublishAt = publishAt
+	p.UnpublishAt = unpublishAt
+
+	// Cambiar el precio de un producto existente requiere el permiso price.manage
+	if existingProduct != nil && priceChanged(existingProduct, p) && !importCtx.principal.HasPermission(model.PriceManage) {
+		return nil, exception.Forbidden(exception.CodeForbidden, "Changing the price, currency or discount requires the price.manage permission")
+	}
+
+	return write, nil
+}
+
+// resolveImage interpreta la celda de imagen: una URL http(s) se descarga al escribir y
+// cualquier otro valor es el nombre de un archivo de la galería de un producto que el usuario
+// puede gestionar
+func (is *ProductImportServiceImpl) resolveImage(ctx context.Context, importCtx *importContext, write *importWrite, value string) error {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		imageURL, err := url.Parse(value)
+		if err != nil || imageURL.Host == "" {
+			return fmt.Errorf("must be a valid URL")
+		}
+		if len(is.allowedImageHosts) == 0 {
+			return fmt.Errorf("image URLs are not allowed; use the name of a file in storage")
+		}
+		if !is.allowedImageHosts.allows(imageURL.Hostname()) {
+			return fmt.Errorf("the host %s is not allowed", imageURL.Hostname())
+		}
+		write.imageURL = value
+		write.storedFiles = write.product.MediaFiles()
+		return nil
+	}
+
+	// Aceptar cualquier clave del almacenamiento permitiría asignar archivos ajenos a la galería,
+	// como los objetos pendientes de una subida directa de otro usuario
+	if !isContentFileName(value) {
+		return fmt.Errorf("must be an image URL or the file name of an image of one of your products")
+	}
+	if !slices.Contains(write.product.MediaFiles(), value) {
+		reusable, err := is.canReuseImage(ctx, importCtx, value)
+		if err != nil {
+			return fmt.Errorf("the file %s could not be checked", value)
+		}
+		if !reusable {
+			return fmt.Errorf("the file %s is not an image of any of your products", value)
+		}
+	}
+	// La referencia al archivo se añade al escribir, no en las simulaciones
+	write.storedFiles = write.product.MediaFiles()
+	write.image = model.ProductImage{
+		Id:        uuid.New().String(),
+		FileName:  value,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	write.replacedImages = write.product.SetPrimaryImage(write.image)
+	return nil
+}
+
+// canReuseImage indica si el archivo está en la galería de algún producto, fuera de la papelera,
+// que el usuario puede gestionar. El resultado se guarda para las demás filas con el mismo archivo.
+func (is *ProductImportServiceImpl) canReuseImage(ctx context.Context, importCtx *importContext, fileName string) (bool, error) {
+	if reusable, ok := importCtx.reusableImages[fileName]; ok {
+		return reusable, nil
+	}
+	productIds, err := is.imageStore.referencingProducts(ctx, fileName)
+	if err != nil {
+		return false, err
+	}
+
+	reusable := false
+	for start := 0; start < len(productIds) && !reusable; start += repository.MaxBatchWrites {
+		products, err := is.productRepository.GetProductsByIds(ctx, productIds[start:min(start+repository.MaxBatchWrites, len(productIds))])
+		if err != nil {
+			return false, err
+		}
+		for _, p := range products {
+			if importCtx.principal.CanManage(p.AuthorId) && slices.Contains(p.MediaFiles(), fileName) {
+				reusable = true
+				break
+			}
+		}
+	}
+	importCtx.reusableImages[fileName] = reusable
+	return reusable, nil
+}
+
+// uploadImageFromURL descarga la imagen con el cliente restringido a los hosts permitidos y la guarda
+// en el almacenamiento como imagen del producto
+func (is *ProductImportServiceImpl) uploadImageFromURL(ctx context.Context, productId string, imageURL string) (model.ProductImage, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return model.ProductImage{}, fmt.Errorf("must be a valid URL")
+	}
+	response, err := is.httpClient.Do(request)
+	if errors.Is(err, errImageHostNotAllowed) || errors.Is(err, errImageAddressNotAllowed) {
+		return model.ProductImage{}, fmt.Errorf("could not be downloaded: the URL redirects to a host that is not allowed or resolves to an internal address")
+	}
+	if err != nil {
+		return model.ProductImage{}, fmt.Errorf("could not be downloaded")
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return model.ProductImage{}, fmt.Errorf("could not be downloaded (HTTP %d)", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxImportImageSize+1))
+	if err != nil {
+		return model.ProductImage{}, fmt.Errorf("could not be downloaded")
+	}
+	if len(data) > maxImportImageSize {
+		return model.ProductImage{}, fmt.Errorf("must not exceed %d MB", maxImportImageSize>>20)
+	}
+	// Solo se guardan imágenes reconocibles: cualquier otra respuesta se publicaría como archivo público
+	if _, _, ok := sniffImage(data); !ok {
+		return model.ProductImage{}, fmt.Errorf("must be a JPEG, PNG, WebP or AVIF image")
+	}
+
+	primaryImage, err := is.imageStore.store(ctx, productId, data)
+	if err != nil {
+		return model.ProductImage{}, fmt.Errorf("could not be stored: %w", imageUploadError(err))
+	}
+	return primaryImage, nil
+}
+
+// mapImportColumns asigna cada campo a su columna. El mapeo explícito tiene prioridad; el resto
+// de columnas se asignan si su cabecera coincide con un campo o un alias (sin distinguir
+// mayúsculas, espacios, guiones ni guiones bajos).
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	invalidMapping := exception.Validation(exception.CodeInvalidFile, "The column mapping is not valid")
+	fieldsByKey := make(map[string]string, len(importFields))
+	for _, field := range importFields {
+		fieldsByKey[normalizeColumn(field)] = field
+	}
+
+	columns := map[string]int{}
+	mappedColumns := map[string]bool{}
+	for column, field := range mapping {
+		target, ok := fieldsByKey[normalizeColumn(field)]
+		if !ok {
+			invalidMapping.WithField(column, "maps to an unknown field "+field)
+			continue
+		}
+		index := slices.IndexFunc(header, func(name string) bool { return normalizeColumn(name) == normalizeColumn(column) })
+		if index < 0 {
+			invalidMapping.WithField(column, "is not a column of the file")
+			continue
+		}
+		columns[target] = index
+		mappedColumns[normalizeColumn(column)] = true
+	}
+
+	for index, name := range header {
+		key := normalizeColumn(name)
+		if mappedColumns[key] {
+			continue
+		}
+		field, ok := fieldsByKey[key]
+		if !ok {
+			field, ok = importFieldAliases[key]
+		}
+		if _, assigned := columns[field]; ok && !assigned {
+			columns[field] = index
+		}
+	}
+
+	if _, ok := columns[importFieldSku]; !ok {
+		invalidMapping.WithField(importFieldSku, "no column is mapped to the SKU, which is required to match existing products")
+	}
+	if len(invalidMapping.Fields) > 0 {
+		return nil, invalidMapping
+	}
+	return columns, nil
+}
+
+func normalizeColumn(name string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func rowCell(row importRow, columns map[string]int, field string) string {
+	index, ok := columns[field]
+	if !ok || index >= len(row.cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.cells[index])
+}
+
+// parseDecimal acepta tanto el punto como la coma decimal ("19.90" y "19,90")
+func parseDecimal(value string) (float64, error) {
+	if !strings.Contains(value, ".") {
+		value = strings.ReplaceAll(value, ",", ".")
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// addImportError registra el error de una fila; los errores de validación generan una entrada por campo
+func addImportError(job *model.ImportJob, row int, sku string, err error) {
+	job.Failed++
+
+	var rowErrors []model.ImportRowError
+	if domainError, ok := exception.As(err); ok && len(domainError.Fields) > 0 {
+		for _, field := range domainError.Fields {
+			rowErrors = append(rowErrors, model.ImportRowError{Row: row, Sku: sku, Field: field.Field, Message: field.Message})
+		}
+	} else if ok {
+		rowErrors = append(rowErrors, model.ImportRowError{Row: row, Sku: sku, Message: domainError.Message})
+	} else {
+		rowErrors = append(rowErrors, model.ImportRowError{Row: row, Sku: sku, Message: err.Error()})
+	}
+
+	for _, rowError := range rowErrors {
+		if len(job.Errors) >= maxImportErrors {
+			job.ErrorsTruncated = true
+			return
+		}
+		job.Errors = append(job.Errors, rowError)
+	}
+}
+
+func countImportWrites(job *model.ImportJob, writes []*importWrite) {
+	for _, write := range writes {
+		if write.created {
+			job.Created++
+		} else {
+			job.Updated++
+		}
+	}
+}
+
+// splitList separa una lista de valores separados por comas, en minúsculas
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,412 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+// memoryCategoryRepository es un CategoryRepository en memoria para las pruebas de la importación
+type memoryCategoryRepository struct {
+	categories []*model.Category
+}
+
+func (r *memoryCategoryRepository) CreateCategory(_ context.Context, category *model.Category) (*model.Category, error) {
+	r.categories = append(r.categories, category)
+	return category, nil
+}
+
+func (r *memoryCategoryRepository) GetCategoryById(_ context.Context, id string) (*model.Category, error) {
+	for _, category := range r.categories {
+		if category.Id == id {
+			return category, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryCategoryRepository) UpdateCategory(_ context.Context, category *model.Category) (*model.Category, error) {
+	return category, nil
+}
+
+func (r *memoryCategoryRepository) DeleteCategoryById(context.Context, string) error {
+	return nil
+}
+
+func (r *memoryCategoryRepository) GetCategories(context.Context) ([]*model.Category, error) {
+	return r.categories, nil
+}
+
+// memoryImportJobRepository es un ImportJobRepository en memoria
+type memoryImportJobRepository struct {
+	mu   sync.Mutex
+	jobs map[string]model.ImportJob
+}
+
+func (r *memoryImportJobRepository) SaveImportJob(_ context.Context, job *model.ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.Id] = *job
+	return nil
+}
+
+func (r *memoryImportJobRepository) GetImportJobById(_ context.Context, id string) (*model.ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok {
+		return &job, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryImportJobRepository) GetUnfinishedImportJobs(context.Context) ([]*model.ImportJob, error) {
+	return nil, nil
+}
+
+// memoryImageFileRepository es un ImageFileRepository en memoria
+type memoryImageFileRepository struct {
+	mu    sync.Mutex
+	files map[string]*model.ImageFile
+}
+
+func (r *memoryImageFileRepository) AcquireImageFile(_ context.Context, fileName string, productId string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	imageFile, ok := r.files[fileName]
+	if !ok {
+		imageFile = &model.ImageFile{FileName: fileName}
+		r.files[fileName] = imageFile
+	}
+	if slices.Contains(imageFile.ProductIds, productId) {
+		return false, nil
+	}
+	imageFile.ProductIds = append(imageFile.ProductIds, productId)
+	imageFile.References = len(imageFile.ProductIds)
+	return true, nil
+}
+
+func (r *memoryImageFileRepository) ReleaseImageFile(_ context.Context, fileName string, productId string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	imageFile, ok := r.files[fileName]
+	if !ok {
+		return true, nil
+	}
+	imageFile.ProductIds = slices.DeleteFunc(imageFile.ProductIds, func(id string) bool { return id == productId })
+	imageFile.References = len(imageFile.ProductIds)
+	return imageFile.References == 0, nil
+}
+
+func (r *memoryImageFileRepository) MarkImageFileDeleting(_ context.Context, fileName string) (bool, error) {
+	return false, nil
+}
+
+func (r *memoryImageFileRepository) GetImageFile(_ context.Context, fileName string) (*model.ImageFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if imageFile, ok := r.files[fileName]; ok {
+		clone := *imageFile
+		clone.ProductIds = slices.Clone(imageFile.ProductIds)
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryImageFileRepository) DeleteImageFile(_ context.Context, fileName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.files, fileName)
+	return nil
+}
+
+// newTestImportService crea el servicio sin almacenamiento: las pruebas no descargan imágenes
+func newTestImportService(productRepository *memoryProductRepository, imageFileRepository *memoryImageFileRepository) *ProductImportServiceImpl {
+	return &ProductImportServiceImpl{
+		productRepository:   productRepository,
+		categoryRepository:  &memoryCategoryRepository{categories: []*model.Category{{Id: "c1", Name: "Kitchen"}}},
+		importJobRepository: &memoryImportJobRepository{jobs: map[string]model.ImportJob{}},
+		imageStore:          newImageStore(nil, imageFileRepository, &renditionGenerator{}),
+		productMapper:       &mapper.ProductMapper{},
+		syncMaxRows:         200,
+	}
+}
+
+func importCSV(t *testing.T, service *ProductImportServiceImpl, content string, dryRun bool, principal *auth.Principal) *product.ImportProductsResponse {
+	t.Helper()
+	response, async, err := service.ImportProducts(context.Background(), &product.ImportProductsRequest{
+		FileName: "products.csv",
+		Content:  []byte(content),
+		DryRun:   dryRun,
+	}, principal)
+	if err != nil {
+		t.Fatalf("ImportProducts() error = %v", err)
+	}
+	if async {
+		t.Fatal("the import ran in the background")
+	}
+	return response
+}
+
+// importErrorFields devuelve los campos con error de cada fila
+func importErrorFields(response *product.ImportProductsResponse) map[int][]string {
+	fields := map[int][]string{}
+	for _, rowError := range response.Errors {
+		fields[rowError.Row] = append(fields[rowError.Row], rowError.Field)
+	}
+	return fields
+}
+
+func TestMapImportColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		mapping map[string]string
+		want    map[string]int
+		wantErr []string
+	}{
+		{
+			name:   "headers match fields ignoring case, spaces, hyphens and underscores",
+			header: []string{"SKU", " Name ", "unpublish_at", "Publish-At", "Stock"},
+			want:   map[string]int{importFieldSku: 0, importFieldName: 1, importFieldUnpublishAt: 2, importFieldPublishAt: 3, importFieldStock: 4},
+		},
+		{
+			name:   "aliases",
+			header: []string{"sku", "Category Name", "Image URL"},
+			want:   map[string]int{importFieldSku: 0, importFieldCategory: 1, importFieldImage: 2},
+		},
+		{
+			name:   "the first column of a field wins",
+			header: []string{"sku", "categoryId", "category"},
+			want:   map[string]int{importFieldSku: 0, importFieldCategory: 1},
+		},
+		{
+			name:    "explicit mapping takes precedence over headers",
+			header:  []string{"Código", "Precio", "price", "Notes"},
+			mapping: map[string]string{"Código": "sku", "precio": "Price"},
+			want:    map[string]int{importFieldSku: 0, importFieldPrice: 1},
+		},
+		{
+			name:    "mapping to an unknown field",
+			header:  []string{"sku", "Color"},
+			mapping: map[string]string{"Color": "colour"},
+			wantErr: []string{"Color"},
+		},
+		{
+			name:    "mapping a column that is not in the file",
+			header:  []string{"sku"},
+			mapping: map[string]string{"Precio": "price"},
+			wantErr: []string{"Precio"},
+		},
+		{
+			name:    "without a SKU column",
+			header:  []string{"name", "price"},
+			wantErr: []string{importFieldSku},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			columns, err := mapImportColumns(test.header, test.mapping)
+			if test.wantErr != nil {
+				domainError, ok := exception.As(err)
+				if !ok || domainError.Code != exception.CodeInvalidFile {
+					t.Fatalf("error = %v, want %s", err, exception.CodeInvalidFile)
+				}
+				var fields []string
+				for _, field := range domainError.Fields {
+					fields = append(fields, field.Field)
+				}
+				if !slices.Equal(fields, test.wantErr) {
+					t.Errorf("fields = %v, want %v", fields, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mapImportColumns() error = %v", err)
+			}
+			if !maps.Equal(columns, test.want) {
+				t.Errorf("columns = %v, want %v", columns, test.want)
+			}
+		})
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "19.90", want: 19.9},
+		{value: "19,90", want: 19.9},
+		{value: "20", want: 20},
+		{value: "-3,5", want: -3.5},
+		{value: "1,234.50", wantErr: true},
+		{value: "1.234,50", wantErr: true},
+		{value: "1,2,3", wantErr: true},
+		{value: "ten", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseDecimal(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("parseDecimal(%q) = %v, want an error", test.value, got)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("parseDecimal(%q) = %v, %v, want %v", test.value, got, err, test.want)
+			}
+		})
+	}
+}
+
+// upsertCSV actualiza MUG-1, crea CUP-1 y tiene una fila con cada tipo de error
+const upsertCSV = `sku;name;price;discount;stock;category;status
+MUG-1;;12,50;;7;;
+CUP-1;Cup;3.5;0,5;2;kitchen;published
+;;;;;;
+OLD-1;Old mug;1;;1;;
+PLATE-1;Plate;1;;1;;
+BAD-1;Bad;abc;;x;garden;
+CUP-1;Cup again;1;;1;;
+MUG-1-B;;1;;1;;
+`
+
+func newImportRepository() *memoryProductRepository {
+	return newMemoryProductRepository(
+		&model.Product{Id: "p1", AuthorId: "author-1", Sku: "MUG-1", Name: "Mug", Price: 10, Discount: 1, Stock: 3, Status: model.ProductStatusPublished},
+		&model.Product{Id: "p2", AuthorId: "author-1", Sku: "OLD-1", Name: "Old mug", Price: 1, DeletedAt: "2024-01-01T00:00:00Z"},
+		&model.Product{Id: "p3", AuthorId: "author-2", Sku: "PLATE-1", Name: "Plate", Price: 1},
+	)
+}
+
+func TestImportProductsUpsertsBySku(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		t.Run(map[bool]string{true: "dry run", false: "import"}[dryRun], func(t *testing.T) {
+			productRepository := newImportRepository()
+			response := importCSV(t, newTestImportService(productRepository, &memoryImageFileRepository{files: map[string]*model.ImageFile{}}), upsertCSV, dryRun, author)
+
+			if response.Status != string(model.ImportJobCompleted) || response.DryRun != dryRun {
+				t.Errorf("status = %s, dry run = %v", response.Status, response.DryRun)
+			}
+			if response.TotalRows != 7 || response.Created != 1 || response.Updated != 1 || response.Failed != 5 {
+				t.Errorf("rows = %d, created = %d, updated = %d, failed = %d, want 7, 1, 1, 5", response.TotalRows, response.Created, response.Updated, response.Failed)
+			}
+			wantErrors := map[int][]string{
+				// La fila 4 está vacía y no cuenta
+				5: {importFieldSku},
+				// Producto de otro autor
+				6: {""},
+				7: {importFieldPrice, importFieldStock, importFieldCategory},
+				8: {importFieldSku},
+				9: {"name"},
+			}
+			if errors := importErrorFields(response); !maps.EqualFunc(errors, wantErrors, slices.Equal) {
+				t.Errorf("errors = %v, want %v", errors, wantErrors)
+			}
+			if productRepository.skuQueries != 1 {
+				t.Errorf("SKU queries = %d, want 1", productRepository.skuQueries)
+			}
+
+			mug := productRepository.get("p1")
+			created := productRepository.filter(func(p *model.Product) bool { return p.Sku == "CUP-1" })
+			if dryRun {
+				if len(productRepository.writeCalls) > 0 || len(created) > 0 || mug.Price != 10 {
+					t.Errorf("the dry run wrote products: write calls = %v", productRepository.writeCalls)
+				}
+				return
+			}
+			// Solo cambian las celdas con valor
+			if mug.Name != "Mug" || mug.Price != 12.5 || mug.Discount != 1 || mug.Stock != 7 || mug.Status != model.ProductStatusPublished {
+				t.Errorf("updated product = %+v", mug)
+			}
+			if len(created) != 1 {
+				t.Fatalf("created products = %d, want 1", len(created))
+			}
+			cup := created[0]
+			if cup.Name != "Cup" || cup.Price != 3.5 || cup.Discount != 0.5 || cup.Stock != 2 || cup.CategoryId != "c1" || cup.Status != model.ProductStatusPublished || cup.AuthorId != author.Subject {
+				t.Errorf("created product = %+v", cup)
+			}
+			if deleted := productRepository.filter(func(p *model.Product) bool { return p.Sku == "OLD-1" }); len(deleted) != 1 || !deleted[0].IsDeleted() {
+				t.Errorf("products with the SKU of a deleted product = %+v", deleted)
+			}
+			if plate := productRepository.get("p3"); plate.Name != "Plate" {
+				t.Errorf("product of another author = %+v", plate)
+			}
+		})
+	}
+}
+
+func TestImportProductsQueriesSkusInChunks(t *testing.T) {
+	productRepository := newMemoryProductRepository()
+	var content strings.Builder
+	content.WriteString("sku,name,price\n")
+	for i := range 65 {
+		fmt.Fprintf(&content, "SKU-%03d,Mug,1\n", i)
+	}
+
+	response := importCSV(t, newTestImportService(productRepository, &memoryImageFileRepository{files: map[string]*model.ImageFile{}}), content.String(), false, author)
+	if response.Created != 65 || response.Failed != 0 {
+		t.Errorf("created = %d, failed = %d, want 65, 0", response.Created, response.Failed)
+	}
+	if productRepository.skuQueries != 3 {
+		t.Errorf("SKU queries = %d, want 3", productRepository.skuQueries)
+	}
+}
+
+func TestImportProductsImageFileNames(t *testing.T) {
+	own := strings.Repeat("a", 64) + ".jpg"
+	foreign := strings.Repeat("b", 64) + ".png"
+	unreferenced := strings.Repeat("c", 64) + ".webp"
+	deleted := strings.Repeat("d", 64) + ".jpg"
+	productRepository := newMemoryProductRepository(
+		&model.Product{Id: "p1", AuthorId: "author-1", Sku: "MUG-1", Name: "Mug", FileImage: own},
+		&model.Product{Id: "p2", AuthorId: "author-2", Sku: "PLATE-1", Name: "Plate", FileImage: foreign},
+		&model.Product{Id: "p3", AuthorId: "author-1", Sku: "OLD-1", Name: "Old mug", FileImage: deleted, DeletedAt: "2024-01-01T00:00:00Z"},
+	)
+	imageFileRepository := &memoryImageFileRepository{files: map[string]*model.ImageFile{
+		own:          {FileName: own, ProductIds: []string{"p1"}, References: 1},
+		foreign:      {FileName: foreign, ProductIds: []string{"p2"}, References: 1},
+		unreferenced: {FileName: unreferenced},
+		deleted:      {FileName: deleted, ProductIds: []string{"p3"}, References: 1},
+	}}
+	content := "sku,name,price,image\n" +
+		"CUP-1,Cup,1," + own + "\n" +
+		"CUP-2,Cup,1," + foreign + "\n" +
+		"CUP-3,Cup,1," + unreferenced + "\n" +
+		"CUP-4,Cup,1," + deleted + "\n" +
+		"CUP-5,Cup,1,uploads/pending/" + own + "\n" +
+		"CUP-6,Cup,1,https://images.example.com/cup.jpg\n" +
+		"MUG-1,Mug,1," + own + "\n"
+
+	response := importCSV(t, newTestImportService(productRepository, imageFileRepository), content, false, author)
+	if response.Created != 1 || response.Updated != 1 || response.Failed != 5 {
+		t.Errorf("created = %d, updated = %d, failed = %d, want 1, 1, 5: %+v", response.Created, response.Updated, response.Failed, response.Errors)
+	}
+	for row := 3; row <= 7; row++ {
+		if fields := importErrorFields(response)[row]; !slices.Equal(fields, []string{importFieldImage}) {
+			t.Errorf("row %d errors = %v, want the image", row, fields)
+		}
+	}
+
+	created := productRepository.filter(func(p *model.Product) bool { return p.Sku == "CUP-1" })
+	if len(created) != 1 || !slices.Contains(created[0].MediaFiles(), own) {
+		t.Fatalf("created products = %+v", created)
+	}
+	if imageFile, _ := imageFileRepository.GetImageFile(context.Background(), own); !slices.Equal(imageFile.ProductIds, []string{"p1", created[0].Id}) {
+		t.Errorf("references = %v, want p1 and the new product", imageFile.ProductIds)
+	}
+}
@@ -2,92 +2,150 @@
 package impl
 
 import (
-	"os"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ruiborda/ecommerce-product-service/src/repository/impl"
 
 	"log/slog"
 
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
 	dto "github.com/ruiborda/ecommerce-product-service/src/dto/common"
 	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/imaging"
+	"github.com/ruiborda/ecommerce-product-service/src/logging"
 	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/metrics"
 	"github.com/ruiborda/ecommerce-product-service/src/model"
 	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// maxWriteAttempts es el número máximo de intentos de una modificación sin If-Match que coincide
+// con otras escrituras del mismo producto
+const maxWriteAttempts = 5
+
+// maxPublicSearchReads limita las lecturas de Firestore de una página del catálogo público cuando
+// los filtros de texto o precio descartan muchos productos; la página se devuelve incompleta con
+// su nextCursor
+const maxPublicSearchReads = 5
+
+// maxStatusBackfillErrors limita los errores detallados en el informe de BackfillStatus
+const maxStatusBackfillErrors = 100
+
 type ProductServiceImpl struct {
 	productRepository repository.ProductRepository
-	r2Repository      repository.R2Repository
+	imageStore        *imageStore
 	productMapper     *mapper.ProductMapper
 }
 
 func NewProductServiceImpl() *ProductServiceImpl {
+	storageRepository := impl.NewStorageRepository()
 	return &ProductServiceImpl{
 		productRepository: impl.NewProductRepositoryImpl(),
-		r2Repository: impl.NewR2RepositoryImpl(
-			"ecommerce",
-			os.Getenv("R2_ACCOUNT_ID"),
-			os.Getenv("R2_ACCESS_KEY"),
-			os.Getenv("R2_SECRET_KEY"),
-		),
-		productMapper: &mapper.ProductMapper{},
+		imageStore:        newImageStore(storageRepository, impl.NewImageFileRepositoryImpl(), newRenditionGenerator(storageRepository)),
+		productMapper:     &mapper.ProductMapper{ImageURL: newImageURLResolver(storageRepository).url},
 	}
 }
 
 // CreateProduct implementa la creación de un nuevo producto
-func (ps *ProductServiceImpl) CreateProduct(createRequest *product.CreateProductRequest, authorId string) (*product.CreateProductResponse, error) {
+func (ps *ProductServiceImpl) CreateProduct(ctx context.Context, createRequest *product.CreateProductRequest, principal *auth.Principal) (*product.CreateProductResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.CreateProduct")
+	defer span.End()
+
+	// Validar los datos de entrada
+	if err := validateProductFields(createRequest.Name, createRequest.Price, createRequest.Discount, createRequest.Stock); err != nil {
+		return nil, err
+	}
+
+	// Los productos nuevos son borradores salvo que se publiquen directamente
+	status := model.ProductStatus(createRequest.Status)
+	if status == "" {
+		status = model.ProductStatusDraft
+	}
+	if status != model.ProductStatusDraft && status != model.ProductStatusPublished {
+		return nil, exception.Validation(exception.CodeValidationFailed, "The product data is not valid").WithField("status", "must be draft or published")
+	}
+	publishAt, unpublishAt, err := normalizeSchedule(createRequest.PublishAt, createRequest.UnpublishAt)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generar un ID único para el producto
 	productId := uuid.New().String()
+	span.SetAttributes(attribute.String("product.id", productId))
+	ctx = logging.WithProductId(ctx, productId)
 
 	// Crear el modelo de producto usando el mapper y luego asignar el ID
 	productModel := ps.productMapper.CreateRequestToProduct(createRequest)
 	productModel.Id = productId
 
-	// Asignar el ID del autor extraído del token JWT
-	productModel.AuthorId = authorId
+	// Asignar como autor al usuario autenticado
+	productModel.AuthorId = principal.Subject
+
+	// Asignar el estado inicial y la programación
+	productModel.Status = status
+	productModel.PublishAt = publishAt
+	productModel.UnpublishAt = unpublishAt
 
-	// Procesar la imagen si existe
+	// Procesar la imagen si existe; es la imagen principal de la galería
 	if createRequest.ImageBase64 != "" {
-		fileName, err := ps.r2Repository.UploadBase64File(&createRequest.ImageBase64)
+		data, err := decodeImageBase64(createRequest.ImageBase64)
 		if err != nil {
-			slog.Error("Error uploading product image", "error", err)
-			return nil, err
+			return nil, imageUploadError(err)
+		}
+		primaryImage, err := ps.imageStore.store(ctx, productId, data)
+		if err != nil {
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error uploading product image", "error", err)
+			return nil, imageUploadError(err)
 		}
-		productModel.FileImage = fileName
+		productModel.SetPrimaryImage(primaryImage)
 	}
 
 	// Guardar el producto en la base de datos
-	createdProduct, err := ps.productRepository.CreateProduct(productModel)
+	createdProduct, err := ps.productRepository.CreateProduct(ctx, productModel)
 	if err != nil {
-		// Si hubo error y se subió una imagen, eliminarla
-		if productModel.FileImage != "" {
-			_ = ps.r2Repository.DeleteFile(productModel.FileImage)
-		}
-		slog.Error("Error creating product", "error", err)
-		return nil, err
+		// Si hubo error y se subió una imagen, liberarla aunque la petición se haya cancelado
+		ps.imageStore.release(ctx, productId, nil, productModel.Gallery()...)
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error creating product", "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
 	// Crear la respuesta usando el mapper
-	return ps.productMapper.ProductToCreateResponse(createdProduct), nil
+	return ps.productMapper.ProductToCreateResponse(ctx, createdProduct), nil
 }
 
 // GetProductById obtiene los detalles de un producto por su ID
-func (ps *ProductServiceImpl) GetProductById(id string) (*product.GetProductByIdResponse, error) {
+func (ps *ProductServiceImpl) GetProductById(ctx context.Context, id string) (*product.GetProductByIdResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.GetProductById", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
+
 	// Obtener el producto desde el repositorio
-	productModel, err := ps.productRepository.GetProductById(id)
+	productModel, err := ps.productRepository.GetProductById(ctx, id)
 	if err != nil {
-		slog.Error("Error getting product", "id", id, "error", err)
-		return nil, err
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting product", "id", id, "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
 	if productModel == nil {
-		return nil, nil
+		return nil, productNotFound()
 	}
 
 	// Crear la respuesta básica usando el mapper
-	response := ps.productMapper.ProductToGetByIdResponse(productModel)
+	response := ps.productMapper.ProductToGetByIdResponse(ctx, productModel)
 
 	// Agregar información adicional como el nombre de la categoría
 	// En una implementación real, aquí se obtendría el nombre de la categoría desde un servicio
@@ -97,124 +155,267 @@ func (ps *ProductServiceImpl) GetProductById(id string) (*product.GetProductById
 }
 
 // UpdateProduct actualiza un producto existente
-func (ps *ProductServiceImpl) UpdateProduct(id string, updateRequest *product.UpdateProductRequest) (*product.UpdateProductResponse, error) {
-	// Verificar si el producto existe
-	existingProduct, err := ps.productRepository.GetProductById(id)
+func (ps *ProductServiceImpl) UpdateProduct(ctx context.Context, id string, updateRequest *product.UpdateProductRequest, ifMatch string, principal *auth.Principal) (*product.UpdateProductResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.UpdateProduct", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
+
+	// Validar los datos de entrada
+	if err := validateProductFields(updateRequest.Name, updateRequest.Price, updateRequest.Discount, updateRequest.Stock); err != nil {
+		return nil, err
+	}
+	publishAt, unpublishAt, err := normalizeSchedule(updateRequest.PublishAt, updateRequest.UnpublishAt)
 	if err != nil {
-		slog.Error("Error getting product for update", "id", id, "error", err)
 		return nil, err
 	}
 
-	if existingProduct == nil {
-		return nil, nil
+	// La imagen nueva se sube una sola vez aunque la escritura se repita
+	var newImage model.ProductImage
+	var replacedImages []model.ProductImage
+	var storedFiles []string
+	updatedProduct, err := updateManagedProduct(ctx, ps.productRepository, id, ifMatch, principal, func(existingProduct *model.Product) error {
+		// Crear un modelo parcial con los datos de actualización
+		updateModel := ps.productMapper.UpdateRequestToProduct(updateRequest)
+
+		// Mantener campos que no deben cambiar
+		updateModel.Id = existingProduct.Id
+		updateModel.AuthorId = existingProduct.AuthorId
+		updateModel.FileImage = existingProduct.FileImage
+		updateModel.Images = existingProduct.Images
+		updateModel.CreatedAt = existingProduct.CreatedAt
+		updateModel.Status = existingProduct.EffectiveStatus()
+		updateModel.PublishAt = publishAt
+		updateModel.UnpublishAt = unpublishAt
+		updateModel.UpdateTime = existingProduct.UpdateTime
+
+		// Cambiar el precio de un producto existente requiere el permiso price.manage
+		if priceChanged(existingProduct, updateModel) && !principal.HasPermission(model.PriceManage) {
+			return exception.Forbidden(exception.CodeForbidden, "Changing the price, currency or discount requires the price.manage permission")
+		}
+
+		// Procesar la imagen si se proporcionó una nueva: reemplaza la imagen principal de la galería
+		storedFiles = existingProduct.MediaFiles()
+		if updateRequest.ImageBase64 != "" {
+			if newImage.FileName == "" {
+				data, err := decodeImageBase64(updateRequest.ImageBase64)
+				if err != nil {
+					return imageUploadError(err)
+				}
+				// Subir la nueva imagen; la anterior se libera cuando se haya guardado el producto
+				newImage, err = ps.imageStore.store(ctx, id, data)
+				if err != nil {
+					slog.ErrorContext(ctx, "Error uploading updated product image", "error", err)
+					return imageUploadError(err)
+				}
+			}
+			replacedImages = updateModel.SetPrimaryImage(newImage)
+		}
+
+		*existingProduct = *updateModel
+		return nil
+	})
+	if err != nil {
+		// Si hubo error y se subió una imagen nueva, liberarla salvo que el producto ya la usara
+		ps.imageStore.release(ctx, id, storedFiles, newImage)
+		tracing.RecordError(span, err)
+		return nil, err
 	}
 
-	// Crear un modelo parcial con los datos de actualización
-	updateModel := ps.productMapper.UpdateRequestToProduct(updateRequest)
+	// Liberar la imagen anterior si se reemplazó; se elimina si ningún otro producto la usa
+	ps.imageStore.release(ctx, id, updatedProduct.MediaFiles(), replacedImages...)
+
+	// Crear y devolver la respuesta usando el mapper
+	return ps.productMapper.ProductToUpdateResponse(ctx, updatedProduct), nil
+}
 
-	// Mantener campos que no deben cambiar
-	updateModel.Id = existingProduct.Id
-	updateModel.AuthorId = existingProduct.AuthorId
-	updateModel.FileImage = existingProduct.FileImage
-	updateModel.CreatedAt = existingProduct.CreatedAt
+// PatchProduct aplica un JSON Merge Patch (RFC 7396) o un JSON Patch (RFC 6902) a un producto.
+// Solo se modifican los campos incluidos en el patch; los valores cero explícitos se respetan.
+func (ps *ProductServiceImpl) PatchProduct(ctx context.Context, id string, patch []byte, patchType product.PatchType, ifMatch string, principal *auth.Principal) (*product.UpdateProductResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.PatchProduct", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
 
-	// Procesar la imagen si se proporcionó una nueva
-	if updateRequest.ImageBase64 != "" {
-		// Eliminar la imagen anterior si existe
-		if updateModel.FileImage != "" {
-			_ = ps.r2Repository.DeleteFile(updateModel.FileImage)
+	updatedProduct, err := updateManagedProduct(ctx, ps.productRepository, id, ifMatch, principal, func(existingProduct *model.Product) error {
+		// Aplicar el patch sobre la representación modificable del producto
+		patchedDocument, err := applyProductPatch(ps.productMapper.ProductToPatchDocument(existingProduct), patch, patchType)
+		if err != nil {
+			return err
 		}
 
-		// Subir la nueva imagen
-		fileName, err := ps.r2Repository.UploadBase64File(&updateRequest.ImageBase64)
+		if err := validateProductFields(patchedDocument.Name, patchedDocument.Price, patchedDocument.Discount, patchedDocument.Stock); err != nil {
+			return err
+		}
+		publishAt, unpublishAt, err := normalizeSchedule(patchedDocument.PublishAt, patchedDocument.UnpublishAt)
 		if err != nil {
-			slog.Error("Error uploading updated product image", "error", err)
-			return nil, err
+			return err
 		}
-		updateModel.FileImage = fileName
-	}
+		patchedDocument.PublishAt = publishAt
+		patchedDocument.UnpublishAt = unpublishAt
 
-	// Guardar los cambios en la base de datos
-	updatedProduct, err := ps.productRepository.UpdateProduct(updateModel)
-	if err != nil {
-		// Si hubo error y se subió una imagen nueva, eliminarla
-		if updateRequest.ImageBase64 != "" && updateModel.FileImage != "" {
-			_ = ps.r2Repository.DeleteFile(updateModel.FileImage)
+		// Copiar el producto leído (incluido su UpdateTime para la precondición) y aplicar los cambios
+		patchedProduct := *existingProduct
+		ps.productMapper.ApplyPatchDocument(patchedDocument, &patchedProduct)
+
+		// Cambiar el precio de un producto existente requiere el permiso price.manage
+		if priceChanged(existingProduct, &patchedProduct) && !principal.HasPermission(model.PriceManage) {
+			return exception.Forbidden(exception.CodeForbidden, "Changing the price, currency or discount requires the price.manage permission")
 		}
-		slog.Error("Error updating product", "id", id, "error", err)
+
+		*existingProduct = patchedProduct
+		return nil
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, err
 	}
 
-	// Crear y devolver la respuesta usando el mapper
-	return ps.productMapper.ProductToUpdateResponse(updatedProduct), nil
+	return ps.productMapper.ProductToUpdateResponse(ctx, updatedProduct), nil
 }
 
-// DeleteProduct elimina un producto por su ID
-func (ps *ProductServiceImpl) DeleteProduct(id string) (*product.DeleteProductByIdResponse, error) {
-	// Verificar si el producto existe
-	existingProduct, err := ps.productRepository.GetProductById(id)
+// DeleteProduct borra lógicamente un producto por su ID
+func (ps *ProductServiceImpl) DeleteProduct(ctx context.Context, id string, ifMatch string, principal *auth.Principal) (*product.DeleteProductByIdResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.DeleteProduct", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
+
+	// Borrado lógico: se registra quién y cuándo lo borró; las imágenes se conservan
+	// hasta que el job de purga elimine el producto definitivamente
+	_, err := updateManagedProduct(ctx, ps.productRepository, id, ifMatch, principal, func(existingProduct *model.Product) error {
+		existingProduct.DeletedAt = time.Now().UTC().Format(time.RFC3339)
+		existingProduct.DeletedBy = principal.Subject
+		return nil
+	})
 	if err != nil {
-		slog.Error("Error getting product for delete", "id", id, "error", err)
+		tracing.RecordError(span, err)
 		return nil, err
 	}
 
-	if existingProduct == nil {
-		return &product.DeleteProductByIdResponse{
-			Success: false,
-			Message: "Product not found",
-		}, nil
+	// Devolver respuesta exitosa
+	return &product.DeleteProductByIdResponse{
+		Success: true,
+		Message: "Product successfully deleted",
+	}, nil
+}
+
+// RestoreProduct recupera un producto borrado lógicamente
+func (ps *ProductServiceImpl) RestoreProduct(ctx context.Context, id string, principal *auth.Principal) (*product.GetProductByIdResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.RestoreProduct", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
+
+	deletedProduct, err := ps.productRepository.GetDeletedProductById(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting product for restore", "id", id, "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
-	// Eliminar la imagen asociada si existe
-	if existingProduct.FileImage != "" {
-		if err := ps.r2Repository.DeleteFile(existingProduct.FileImage); err != nil {
-			slog.Error("Error deleting product image", "fileName", existingProduct.FileImage, "error", err)
-		}
+	if deletedProduct == nil {
+		return nil, exception.NotFound(exception.CodeProductNotFound, "Deleted product not found")
 	}
 
-	// Eliminar el producto de la base de datos
-	err = ps.productRepository.DeleteProductById(id)
+	// Solo el autor o un administrador pueden recuperar el producto
+	if !principal.CanManage(deletedProduct.AuthorId) {
+		return nil, productNotOwned()
+	}
+
+	deletedProduct.DeletedAt = ""
+	deletedProduct.DeletedBy = ""
+	deletedProduct.UpdatedAt = time.Now().Format(time.RFC3339)
+	restoredProduct, err := ps.productRepository.UpdateProduct(ctx, deletedProduct)
 	if err != nil {
-		slog.Error("Error deleting product", "id", id, "error", err)
-		return nil, err
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error restoring product", "id", id, "error", err)
+		return nil, productWriteError(err, "")
 	}
 
-	// Devolver respuesta exitosa
-	return &product.DeleteProductByIdResponse{
-		Success: true,
-		Message: "Product successfully deleted",
-	}, nil
+	return ps.productMapper.ProductToGetByIdResponse(ctx, restoredProduct), nil
+}
+
+// PurgeDeletedProducts elimina definitivamente los productos borrados antes de la fecha indicada y
+// libera sus imágenes, que se eliminan si ningún otro producto las usa. Si no se puede liberar alguna
+// imagen, el producto se conserva para reintentarlo en la siguiente ejecución. Devuelve el número de
+// productos eliminados.
+func (ps *ProductServiceImpl) PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.PurgeDeletedProducts")
+	defer span.End()
+
+	deletedProducts, err := ps.productRepository.GetProductsDeletedBefore(ctx, deletedBefore.UTC().Format(time.RFC3339))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, exception.DatabaseError(err)
+	}
+
+	purged := 0
+	for _, p := range deletedProducts {
+		productCtx := logging.WithProductId(ctx, p.Id)
+		if !ps.imageStore.release(productCtx, p.Id, nil, p.Gallery()...) {
+			continue
+		}
+		if err := ps.productRepository.DeleteProductById(productCtx, p.Id); err != nil {
+			tracing.RecordError(span, err)
+			return purged, exception.DatabaseError(err)
+		}
+		purged++
+	}
+
+	span.SetAttributes(attribute.Int("products.purged", purged))
+	return purged, nil
 }
 
 // GetProductsPaginated obtiene productos con paginación
-func (ps *ProductServiceImpl) GetProductsPaginated(pageable *dto.Pageable) (*dto.PaginationResponse[product.GetProductsPaginatedResponse], error) {
-	// Obtener los productos desde el repositorio
-	products, err := ps.productRepository.GetProducts()
+func (ps *ProductServiceImpl) GetProductsPaginated(ctx context.Context, pageable *dto.Pageable, status string) (*dto.PaginationResponse[product.GetProductsPaginatedResponse], error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.GetProductsPaginated")
+	defer span.End()
+
+	statusFilter, err := parseStatusFilter(status)
 	if err != nil {
-		slog.Error("Error getting products for pagination", "error", err)
 		return nil, err
 	}
 
-	// Implementar paginación manual (en una implementación real, esto se haría en la base de datos)
-	totalElements := len(products)
-	startIndex := (pageable.Page - 1) * pageable.Size
-	endIndex := startIndex + pageable.Size
+	// Obtener los productos desde el repositorio
+	products, err := ps.productRepository.GetProducts(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting products for pagination", "error", err)
+		return nil, exception.DatabaseError(err)
+	}
+
+	return ps.paginateProducts(ctx, filterByStatus(products, statusFilter), pageable), nil
+}
+
+// GetMyProductsPaginated obtiene una lista paginada de los productos del usuario autenticado
+func (ps *ProductServiceImpl) GetMyProductsPaginated(ctx context.Context, pageable *dto.Pageable, status string, principal *auth.Principal) (*dto.PaginationResponse[product.GetProductsPaginatedResponse], error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.GetMyProductsPaginated")
+	defer span.End()
 
-	if startIndex >= totalElements {
-		startIndex = 0
-		endIndex = 0
+	statusFilter, err := parseStatusFilter(status)
+	if err != nil {
+		return nil, err
 	}
 
-	if endIndex > totalElements {
-		endIndex = totalElements
+	// Obtener desde el repositorio solo los productos del autor
+	products, err := ps.productRepository.GetProductsByAuthorId(ctx, principal.Subject)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting author products for pagination", "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
+	return ps.paginateProducts(ctx, filterByStatus(products, statusFilter), pageable), nil
+}
+
+// paginateProducts construye la página solicitada (en una implementación real, esto se haría en la base de datos)
+func (ps *ProductServiceImpl) paginateProducts(ctx context.Context, products []*model.Product, pageable *dto.Pageable) *dto.PaginationResponse[product.GetProductsPaginatedResponse] {
+	totalElements := len(products)
+	startIndex, endIndex := pageBounds(pageable.Page, pageable.Size, totalElements)
+
 	var paginatedProducts []*product.GetProductsPaginatedResponse
 
 	// Convertir los productos a DTOs usando el mapper
 	for i := startIndex; i < endIndex; i++ {
 		p := products[i]
-		paginatedProducts = append(paginatedProducts, ps.productMapper.ProductToGetPaginatedResponse(p))
+		paginatedProducts = append(paginatedProducts, ps.productMapper.ProductToGetPaginatedResponse(ctx, p))
 	}
 
 	// Construir la respuesta paginada
@@ -243,107 +444,63 @@ func (ps *ProductServiceImpl) GetProductsPaginated(pageable *dto.Pageable) (*dto
 
 	result.Data = &paginatedProducts
 
-	return result, nil
+	return result
 }
 
 // AdjustProductStock ajusta el stock de un producto
-func (ps *ProductServiceImpl) AdjustProductStock(id string, request *product.AdjustProductStockRequest) (*product.AdjustProductStockResponse, error) {
-	// Verificar si el producto existe
-	existingProduct, err := ps.productRepository.GetProductById(id)
+func (ps *ProductServiceImpl) AdjustProductStock(ctx context.Context, id string, request *product.AdjustProductStockRequest, ifMatch string, principal *auth.Principal) (*product.AdjustProductStockResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.AdjustProductStock", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
+
+	// Sin If-Match, un ajuste que coincide con otro se vuelve a aplicar sobre el stock actualizado
+	var previousStock int
+	updatedProduct, err := updateManagedProduct(ctx, ps.productRepository, id, ifMatch, principal, func(existingProduct *model.Product) error {
+		// Guardar el stock anterior
+		previousStock = existingProduct.Stock
+
+		// Actualizar el stock evitando que sea negativo
+		existingProduct.Stock = max(previousStock+request.Quantity, 0)
+
+		// Actualizar la fecha de modificación
+		existingProduct.UpdatedAt = time.Now().Format(time.RFC3339)
+		return nil
+	})
 	if err != nil {
-		slog.Error("Error getting product for stock adjustment", "id", id, "error", err)
-		return nil, err
-	}
-
-	if existingProduct == nil {
-		return nil, nil
-	}
-
-	// Guardar el stock anterior
-	previousStock := existingProduct.Stock
-
-	// Actualizar el stock
-	existingProduct.Stock = previousStock + request.Quantity
-
-	// Evitar stock negativo
-	if existingProduct.Stock < 0 {
-		existingProduct.Stock = 0
-	}
-
-	// Actualizar la fecha de modificación
-	existingProduct.UpdatedAt = time.Now().Format(time.RFC3339)
-
-	// Guardar los cambios
-	_, err = ps.productRepository.UpdateProduct(existingProduct)
-	if err != nil {
-		slog.Error("Error updating product stock", "id", id, "error", err)
+		tracing.RecordError(span, err)
 		return nil, err
 	}
+	metrics.ObserveStockAdjustment(request.Quantity)
 
 	// Crear respuesta manualmente ya que no tenemos un mapper específico para esto
 	return &product.AdjustProductStockResponse{
 		Id:            id,
 		PreviousStock: previousStock,
-		CurrentStock:  existingProduct.Stock,
+		CurrentStock:  updatedProduct.Stock,
+		ETag:          updatedProduct.ETag(),
 	}, nil
 }
 
 // SearchProducts busca productos con filtros avanzados
-func (ps *ProductServiceImpl) SearchProducts(request *product.SearchProductsRequest) (*dto.PaginationResponse[product.SearchProductsResponse], error) {
+func (ps *ProductServiceImpl) SearchProducts(ctx context.Context, request *product.SearchProductsRequest) (*dto.PaginationResponse[product.SearchProductsResponse], error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.SearchProducts")
+	defer span.End()
+
 	// Obtener todos los productos (en una implementación real se usarían filtros en la base de datos)
-	allProducts, err := ps.productRepository.GetProducts()
+	allProducts, err := ps.productRepository.GetProducts(ctx)
 	if err != nil {
-		slog.Error("Error getting products for search", "error", err)
-		return nil, err
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting products for search", "error", err)
+		return nil, exception.DatabaseError(err)
 	}
 
-	// Filtrar productos manualmente según los criterios (esto sería más eficiente en la base de datos)
-	var filteredProducts []*model.Product
-
-	for _, p := range allProducts {
-		include := true
-
-		// Filtrar por query en nombre o descripción
-		if request.Query != "" && !containsIgnoreCase(p.Name, request.Query) && !containsIgnoreCase(p.Description, request.Query) {
-			include = false
-		}
-
-		// Filtrar por categoría
-		if include && request.CategoryId != "" && p.CategoryId != request.CategoryId {
-			include = false
-		}
-
-		// Filtrar por precio mínimo
-		if include && request.PriceMin > 0 && p.Price < request.PriceMin {
-			include = false
-		}
-
-		// Filtrar por precio máximo
-		if include && request.PriceMax > 0 && p.Price > request.PriceMax {
-			include = false
-		}
-
-		if include {
-			filteredProducts = append(filteredProducts, p)
-		}
-	}
-
-	// Ordenar productos (implementación básica)
+	// Filtrar y ordenar los productos publicados según los criterios (esto sería más eficiente en la base de datos)
+	filteredProducts := filterProducts(filterByStatus(allProducts, model.ProductStatusPublished), request)
 	sortProducts(filteredProducts, request.SortBy, request.SortDirection)
 
 	// Aplicar paginación
 	totalElements := len(filteredProducts)
-	startIndex := (request.Page - 1) * request.Size
-	endIndex := startIndex + request.Size
-
-	if startIndex >= totalElements {
-		startIndex = 0
-		endIndex = 0
-	}
-
-	if endIndex > totalElements {
-		endIndex = totalElements
-	}
+	startIndex, endIndex := pageBounds(request.Page, request.Size, totalElements)
 
 	var paginatedProducts []*product.SearchProductsResponse
 
@@ -352,7 +509,7 @@ func (ps *ProductServiceImpl) SearchProducts(request *product.SearchProductsRequ
 		p := filteredProducts[i]
 
 		// Crear la respuesta básica usando el mapper
-		productResponse := ps.productMapper.ProductToSearchResponse(p)
+		productResponse := ps.productMapper.ProductToSearchResponse(ctx, p)
 
 		// Añadir información adicional que no viene del mapper
 		productResponse.CategoryName = "Categoría " + p.CategoryId // En una implementación real, se obtendría de un servicio
@@ -390,14 +547,494 @@ func (ps *ProductServiceImpl) SearchProducts(request *product.SearchProductsRequ
 	return result, nil
 }
 
+// ChangeProductStatus aplica una transición del ciclo de vida: draft → published → archived y archived → published
+func (ps *ProductServiceImpl) ChangeProductStatus(ctx context.Context, id string, request *product.ChangeProductStatusRequest, principal *auth.Principal) (*product.ChangeProductStatusResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.ChangeProductStatus", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
+
+	targetStatus := model.ProductStatus(request.Status)
+	if !targetStatus.IsValid() {
+		return nil, exception.Validation(exception.CodeValidationFailed, "The product status is not valid").WithField("status", "must be draft, published or archived")
+	}
+
+	var previousStatus model.ProductStatus
+	_, err := updateManagedProduct(ctx, ps.productRepository, id, "", principal, func(existingProduct *model.Product) error {
+		previousStatus = existingProduct.EffectiveStatus()
+		if !previousStatus.CanTransitionTo(targetStatus) {
+			return exception.Conflict(exception.CodeInvalidStatusTransition, "The product cannot change from "+string(previousStatus)+" to "+string(targetStatus))
+		}
+		applyStatus(existingProduct, targetStatus)
+		return nil
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Product status changed", "previous_status", string(previousStatus), "status", string(targetStatus))
+
+	return &product.ChangeProductStatusResponse{
+		Id:             id,
+		PreviousStatus: string(previousStatus),
+		Status:         string(targetStatus),
+	}, nil
+}
+
+// ProcessScheduledStatusChanges publica los productos cuya fecha publishAt ha llegado y archiva
+// los publicados cuya fecha unpublishAt ha llegado. Devuelve el número de productos modificados.
+func (ps *ProductServiceImpl) ProcessScheduledStatusChanges(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.ProcessScheduledStatusChanges")
+	defer span.End()
+
+	before := now.UTC().Format(time.RFC3339)
+	changed := 0
+
+	toPublish, err := ps.productRepository.GetProductsScheduledToPublish(ctx, before)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return changed, exception.DatabaseError(err)
+	}
+	for _, p := range toPublish {
+		if p.EffectiveStatus().CanTransitionTo(model.ProductStatusPublished) {
+			applyStatus(p, model.ProductStatusPublished)
+		} else {
+			// Ya publicado: solo se descarta la programación vencida
+			p.PublishAt = ""
+		}
+		if _, err := ps.productRepository.UpdateProduct(ctx, p); err != nil {
+			// Si el producto cambió mientras tanto, se reintenta en la siguiente ejecución
+			if errors.Is(err, repository.ErrVersionConflict) {
+				continue
+			}
+			tracing.RecordError(span, err)
+			return changed, exception.DatabaseError(err)
+		}
+		changed++
+	}
+
+	toUnpublish, err := ps.productRepository.GetProductsScheduledToUnpublish(ctx, before)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return changed, exception.DatabaseError(err)
+	}
+	for _, p := range toUnpublish {
+		// Si la publicación programada aún no ha llegado, la retirada espera a que ocurra
+		if p.PublishAt != "" && p.PublishAt > before {
+			continue
+		}
+		if p.EffectiveStatus().CanTransitionTo(model.ProductStatusArchived) {
+			applyStatus(p, model.ProductStatusArchived)
+		} else {
+			p.UnpublishAt = ""
+		}
+		if _, err := ps.productRepository.UpdateProduct(ctx, p); err != nil {
+			// Si el producto cambió mientras tanto, se reintenta en la siguiente ejecución
+			if errors.Is(err, repository.ErrVersionConflict) {
+				continue
+			}
+			tracing.RecordError(span, err)
+			return changed, exception.DatabaseError(err)
+		}
+		changed++
+	}
+
+	span.SetAttributes(attribute.Int("products.changed", changed))
+	return changed, nil
+}
+
+// GetPublicProductById obtiene la vista pública de un producto publicado
+func (ps *ProductServiceImpl) GetPublicProductById(ctx context.Context, id string) (*product.PublicProductResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.GetPublicProductById", attribute.String("product.id", id))
+	defer span.End()
+	ctx = logging.WithProductId(ctx, id)
+
+	productModel, err := ps.productRepository.GetProductById(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "Error getting public product", "id", id, "error", err)
+		return nil, exception.DatabaseError(err)
+	}
+
+	// Un producto no publicado no existe para el catálogo público
+	if productModel == nil || !isPublished(productModel) {
+		return nil, productNotFound()
+	}
+
+	return ps.productMapper.ProductToPublicResponse(ctx, productModel), nil
+}
+
+// SearchPublicProducts busca entre los productos publicados y devuelve su vista pública. Los
+// productos se leen de Firestore por páginas en orden de ID a partir del cursor; la categoría se
+// filtra en la consulta y el texto y el precio en cada página leída.
+func (ps *ProductServiceImpl) SearchPublicProducts(ctx context.Context, request *product.SearchPublicProductsRequest) (*product.SearchPublicProductsResponse, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.SearchPublicProducts")
+	defer span.End()
+
+	// El cursor es el ID de un documento
+	if strings.Contains(request.Cursor, "/") {
+		return nil, exception.Validation(exception.CodeValidationFailed, "The cursor is not valid").WithField("cursor", "must be the nextCursor of a previous page")
+	}
+
+	filter := &product.SearchProductsRequest{Query: request.Query, PriceMin: request.PriceMin, PriceMax: request.PriceMax}
+	products := make([]*product.PublicProductResponse, 0, request.Size)
+	cursor := request.Cursor
+	for reads := 0; reads < maxPublicSearchReads; reads++ {
+		page, lastId, err := ps.productRepository.GetPublishedProducts(ctx, request.CategoryId, cursor, request.Size)
+		if err != nil {
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "Error getting products for public search", "error", err)
+			return nil, exception.DatabaseError(err)
+		}
+		cursor = lastId
+		for _, p := range filterProducts(page, filter) {
+			if len(products) == request.Size {
+				// La página se llenó a mitad de la lectura: la siguiente sigue tras el último devuelto
+				cursor = products[len(products)-1].Id
+				break
+			}
+			products = append(products, ps.productMapper.ProductToPublicResponse(ctx, p))
+		}
+		if cursor == "" || len(products) == request.Size {
+			break
+		}
+	}
+	span.SetAttributes(attribute.Int("products.returned", len(products)))
+
+	return &product.SearchPublicProductsResponse{
+		Data:       products,
+		NextCursor: cursor,
+	}, nil
+}
+
+// BackfillStatus guarda como published el estado de los productos creados antes del ciclo de vida,
+// que no tienen el campo status y por eso no aparecen en la consulta del catálogo público. Recorre
+// también los de la papelera. Un producto que cambia mientras tanto cuenta como fallido y se
+// guarda al repetir el comando.
+func (ps *ProductServiceImpl) BackfillStatus(ctx context.Context) (*product.StatusBackfillReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "ProductService.BackfillStatus")
+	defer span.End()
+
+	report := &product.StatusBackfillReport{}
+	backfillProducts := func(products []*model.Product) error {
+		for _, p := range products {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			report.Products++
+			if p.Status != "" {
+				continue
+			}
+			p.Status = p.EffectiveStatus()
+			if _, err := ps.productRepository.UpdateProduct(ctx, p); err != nil {
+				report.Failed++
+				if len(report.Errors) < maxStatusBackfillErrors {
+					report.Errors = append(report.Errors, product.StatusBackfillError{ProductId: p.Id, Message: err.Error()})
+				}
+				continue
+			}
+			report.Updated++
+		}
+		return nil
+	}
+
+	err := ps.productRepository.ForEachProductChunk(ctx, "", repository.MaxBatchWrites, backfillProducts)
+	if err == nil || errors.Is(err, repository.ErrUndecodableProducts) {
+		var deletedProducts []*model.Product
+		deletedProducts, err = ps.productRepository.GetProductsDeletedBefore(ctx, time.Now().UTC().Format(time.RFC3339))
+		if err == nil {
+			err = backfillProducts(deletedProducts)
+		}
+	}
+
+	span.SetAttributes(attribute.Int("backfill.updated", report.Updated), attribute.Int("backfill.failed", report.Failed))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return report, exception.DatabaseError(err)
+	}
+	return report, nil
+}
+
+// validateProductFields valida los campos comunes de creación y actualización de productos
+func validateProductFields(name string, price float64, discount float64, stock int) error {
+	validationError := exception.Validation(exception.CodeValidationFailed, "The product data is not valid")
+	if strings.TrimSpace(name) == "" {
+		validationError.WithField("name", "is required")
+	}
+	if price < 0 {
+		validationError.WithField("price", "must not be negative")
+	}
+	if discount < 0 {
+		validationError.WithField("discount", "must not be negative")
+	}
+	if stock < 0 {
+		validationError.WithField("stock", "must not be negative")
+	}
+	if len(validationError.Fields) > 0 {
+		return validationError
+	}
+	return nil
+}
+
+// priceChanged indica si la actualización modifica el precio, la moneda o el descuento
+func priceChanged(existingProduct *model.Product, updateModel *model.Product) bool {
+	return existingProduct.Price != updateModel.Price ||
+		existingProduct.Currency != updateModel.Currency ||
+		existingProduct.Discount != updateModel.Discount
+}
+
+// productNotFound crea el error de producto no encontrado
+func productNotFound() error {
+	return exception.NotFound(exception.CodeProductNotFound, "Product not found")
+}
+
+// applyStatus cambia el estado del producto y descarta la programación que ya no aplica:
+// publicar elimina publishAt y archivar elimina unpublishAt
+func applyStatus(p *model.Product, status model.ProductStatus) {
+	p.Status = status
+	switch status {
+	case model.ProductStatusPublished:
+		p.PublishAt = ""
+	case model.ProductStatusArchived:
+		p.UnpublishAt = ""
+	}
+	p.UpdatedAt = time.Now().Format(time.RFC3339)
+}
+
+// versionMismatch crea el error de If-Match que no coincide con la versión actual
+func versionMismatch() error {
+	return exception.PreconditionFailed(exception.CodePreconditionFailed, "The product has been modified since it was read; fetch it again to get the current ETag")
+}
+
+// productWriteError distingue una escritura rechazada por un cambio concurrente de un fallo de
+// Firestore. El conflicto solo es un 412 si el cliente envió If-Match; si no, es un 409.
+func productWriteError(err error, ifMatch string) error {
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		return exception.DatabaseError(err)
+	}
+	if strings.TrimSpace(ifMatch) != "" {
+		return versionMismatch()
+	}
+	return exception.Conflict(exception.CodeConcurrentModification, "The product is being modified by another request; try again")
+}
+
+// updateManagedProduct lee el producto, comprueba la autoría y el If-Match, le aplica change y lo
+// guarda con la precondición de la versión leída. Si otra escritura se adelanta y el cliente no
+// envió If-Match, vuelve a leer el producto y repite change sobre la versión nueva, como máximo
+// maxWriteAttempts veces; con If-Match el conflicto se responde con 412.
+func updateManagedProduct(ctx context.Context, productRepository repository.ProductRepository, id string, ifMatch string, principal *auth.Principal, change func(existingProduct *model.Product) error) (*model.Product, error) {
+	for attempt := 1; ; attempt++ {
+		existingProduct, err := productRepository.GetProductById(ctx, id)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error getting product for update", "id", id, "error", err)
+			return nil, exception.DatabaseError(err)
+		}
+		if existingProduct == nil {
+			return nil, productNotFound()
+		}
+
+		// Solo el autor o un administrador pueden modificar el producto
+		if !principal.CanManage(existingProduct.AuthorId) {
+			return nil, productNotOwned()
+		}
+
+		// El cliente solo puede modificar la versión que leyó
+		if !existingProduct.MatchesIfMatch(ifMatch) {
+			return nil, versionMismatch()
+		}
+
+		if err := change(existingProduct); err != nil {
+			return nil, err
+		}
+
+		updatedProduct, err := productRepository.UpdateProduct(ctx, existingProduct)
+		if err == nil {
+			return updatedProduct, nil
+		}
+		if errors.Is(err, repository.ErrVersionConflict) && strings.TrimSpace(ifMatch) == "" && attempt < maxWriteAttempts {
+			slog.InfoContext(ctx, "Product changed concurrently, retrying write", "id", id, "attempt", attempt)
+			continue
+		}
+		slog.ErrorContext(ctx, "Error updating product", "id", id, "error", err)
+		return nil, productWriteError(err, ifMatch)
+	}
+}
+
+// productNotOwned crea el error de producto de otro autor
+func productNotOwned() error {
+	return exception.Forbidden(exception.CodeProductNotOwned, "Only the author or an administrator can manage this product")
+}
+
+// imageUploadError distingue una imagen inválida de un fallo del almacenamiento
+func imageUploadError(err error) error {
+	if errors.Is(err, imaging.ErrMetadataNotRemovable) {
+		return metadataNotRemovable("imageBase64")
+	}
+	if errors.Is(err, repository.ErrInvalidFile) {
+		return exception.Validation(exception.CodeInvalidImage, "The image is not a valid file").WithField("imageBase64", "must be a valid base64 encoded image")
+	}
+	return exception.StorageError(err)
+}
+
+// isPublished indica si el producto es visible en el catálogo público y en las búsquedas
+func isPublished(p *model.Product) bool {
+	return p != nil && p.EffectiveStatus() == model.ProductStatusPublished
+}
+
+// parseStatusFilter valida el filtro de estado de los listados; vacío significa todos los estados
+func parseStatusFilter(status string) (model.ProductStatus, error) {
+	statusFilter := model.ProductStatus(status)
+	if statusFilter != "" && !statusFilter.IsValid() {
+		return "", exception.Validation(exception.CodeValidationFailed, "The status filter is not valid").WithField("status", "must be draft, published or archived")
+	}
+	return statusFilter, nil
+}
+
+// filterByStatus devuelve los productos con el estado indicado; vacío devuelve todos
+func filterByStatus(products []*model.Product, status model.ProductStatus) []*model.Product {
+	if status == "" {
+		return products
+	}
+	filteredProducts := make([]*model.Product, 0, len(products))
+	for _, p := range products {
+		if p.EffectiveStatus() == status {
+			filteredProducts = append(filteredProducts, p)
+		}
+	}
+	return filteredProducts
+}
+
+// applyProductPatch aplica el patch al documento y lo vuelve a interpretar. Los campos
+// desconocidos o de solo lectura (id, authorId, status...) y los tipos incorrectos se rechazan.
+func applyProductPatch(document *product.PatchProductDocument, patch []byte, patchType product.PatchType) (*product.PatchProductDocument, error) {
+	original, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch patchType {
+	case product.JSONPatch:
+		jsonPatch, decodeErr := jsonpatch.DecodePatch(patch)
+		if decodeErr != nil {
+			return nil, invalidPatch(decodeErr)
+		}
+		patched, err = jsonPatch.Apply(original)
+	default:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	}
+	if err != nil {
+		return nil, invalidPatch(err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	var patchedDocument product.PatchProductDocument
+	if err := decoder.Decode(&patchedDocument); err != nil {
+		return nil, invalidPatch(err)
+	}
+	return &patchedDocument, nil
+}
+
+func invalidPatch(err error) error {
+	return exception.Validation(exception.CodeInvalidPatch, "The patch could not be applied: "+err.Error())
+}
+
+// normalizeSchedule valida las fechas de publicación y retirada programadas y las
+// convierte a RFC 3339 en UTC para que se puedan comparar como texto en Firestore
+func normalizeSchedule(publishAt string, unpublishAt string) (string, string, error) {
+	validationError := exception.Validation(exception.CodeValidationFailed, "The product schedule is not valid")
+
+	var publishTime, unpublishTime time.Time
+	var err error
+	if publishAt != "" {
+		if publishTime, err = time.Parse(time.RFC3339, publishAt); err != nil {
+			validationError.WithField("publishAt", "must be an RFC 3339 timestamp")
+		}
+	}
+	if unpublishAt != "" {
+		if unpublishTime, err = time.Parse(time.RFC3339, unpublishAt); err != nil {
+			validationError.WithField("unpublishAt", "must be an RFC 3339 timestamp")
+		}
+	}
+	if len(validationError.Fields) > 0 {
+		return "", "", validationError
+	}
+	if !publishTime.IsZero() && !unpublishTime.IsZero() && !unpublishTime.After(publishTime) {
+		return "", "", validationError.WithField("unpublishAt", "must be after publishAt")
+	}
+
+	return formatScheduleTime(publishTime), formatScheduleTime(unpublishTime), nil
+}
+
+func formatScheduleTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// filterProducts devuelve los productos que cumplen los filtros de la búsqueda
+func filterProducts(products []*model.Product, request *product.SearchProductsRequest) []*model.Product {
+	filteredProducts := make([]*model.Product, 0, len(products))
+	for _, p := range products {
+		// Filtrar por query en nombre o descripción
+		if request.Query != "" && !containsIgnoreCase(p.Name, request.Query) && !containsIgnoreCase(p.Description, request.Query) {
+			continue
+		}
+
+		// Filtrar por categoría
+		if request.CategoryId != "" && p.CategoryId != request.CategoryId {
+			continue
+		}
+
+		// Filtrar por rango de precio
+		if request.PriceMin > 0 && p.Price < request.PriceMin {
+			continue
+		}
+		if request.PriceMax > 0 && p.Price > request.PriceMax {
+			continue
+		}
+
+		filteredProducts = append(filteredProducts, p)
+	}
+	return filteredProducts
+}
+
+// pageBounds calcula los índices [inicio, fin) de la página solicitada
+func pageBounds(page int, size int, totalElements int) (int, int) {
+	startIndex := (page - 1) * size
+	if startIndex < 0 || startIndex >= totalElements {
+		return 0, 0
+	}
+	return startIndex, min(startIndex+size, totalElements)
+}
+
 // Función auxiliar para buscar texto ignorando mayúsculas/minúsculas
 func containsIgnoreCase(s, substr string) bool {
-	// Implementación simple para ejemplo
-	return true // En una implementación real se haría la comparación correctamente
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
 
-// Función auxiliar para ordenar productos
+// Función auxiliar para ordenar productos por price, name, createdAt o updatedAt (asc o desc)
 func sortProducts(products []*model.Product, sortBy, sortDirection string) {
-	// Implementación simple para ejemplo
-	// En una implementación real se ordenarían los productos según los criterios
+	var less func(a, b *model.Product) bool
+	switch sortBy {
+	case "price":
+		less = func(a, b *model.Product) bool { return a.Price < b.Price }
+	case "name":
+		less = func(a, b *model.Product) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "createdAt", "created_at":
+		less = func(a, b *model.Product) bool { return a.CreatedAt < b.CreatedAt }
+	case "updatedAt", "updated_at":
+		less = func(a, b *model.Product) bool { return a.UpdatedAt < b.UpdatedAt }
+	default:
+		return
+	}
+
+	descending := strings.EqualFold(sortDirection, "desc")
+	sort.SliceStable(products, func(i, j int) bool {
+		if descending {
+			return less(products[j], products[i])
+		}
+		return less(products[i], products[j])
+	})
 }
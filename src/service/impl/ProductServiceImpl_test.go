@@ -0,0 +1,573 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ruiborda/ecommerce-product-service/src/dto/auth"
+	"github.com/ruiborda/ecommerce-product-service/src/dto/product"
+	"github.com/ruiborda/ecommerce-product-service/src/exception"
+	"github.com/ruiborda/ecommerce-product-service/src/mapper"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+)
+
+func newTestProductService(productRepository *memoryProductRepository) *ProductServiceImpl {
+	return &ProductServiceImpl{
+		productRepository: productRepository,
+		productMapper:     &mapper.ProductMapper{},
+	}
+}
+
+// author es el autor de los productos de las pruebas, con todos los permisos
+var author = &auth.Principal{Subject: "author-1", Permissions: model.Permissions}
+
+// assertDomainError comprueba el tipo y el código del error devuelto por un servicio
+func assertDomainError(t *testing.T, err error, kind exception.Kind, code string) {
+	t.Helper()
+	domainError, ok := exception.As(err)
+	if !ok {
+		t.Fatalf("error = %v, want a %s domain error", err, code)
+	}
+	if domainError.Kind != kind || domainError.Code != code {
+		t.Fatalf("error = %s/%s (%v), want %s/%s", domainError.Kind, domainError.Code, err, kind, code)
+	}
+}
+
+func TestAdjustProductStockReappliesDeltaAfterConcurrentWrite(t *testing.T) {
+	productRepository := newMemoryProductRepository(&model.Product{Id: "p1", AuthorId: "author-1", Name: "Mug", Stock: 10})
+	concurrentWrites := 0
+	productRepository.beforeUpdate = func(*model.Product) {
+		// Otro pedido descuenta 2 unidades entre la lectura y la escritura del primer intento
+		if concurrentWrites == 0 {
+			concurrentWrites++
+			productRepository.touch("p1", func(p *model.Product) { p.Stock -= 2 })
+		}
+	}
+
+	response, err := newTestProductService(productRepository).AdjustProductStock(context.Background(), "p1", &product.AdjustProductStockRequest{Quantity: -3}, "", author)
+	if err != nil {
+		t.Fatalf("AdjustProductStock() error = %v", err)
+	}
+	if response.PreviousStock != 8 || response.CurrentStock != 5 {
+		t.Errorf("stock = %d -> %d, want 8 -> 5", response.PreviousStock, response.CurrentStock)
+	}
+	if stored := productRepository.get("p1"); stored.Stock != 5 {
+		t.Errorf("stored stock = %d, want 5", stored.Stock)
+	}
+	if response.ETag != productRepository.get("p1").ETag() {
+		t.Errorf("ETag = %s, want the stored version", response.ETag)
+	}
+}
+
+func TestAdjustProductStockWithIfMatchFailsAfterConcurrentWrite(t *testing.T) {
+	productRepository := newMemoryProductRepository(&model.Product{Id: "p1", AuthorId: "author-1", Name: "Mug", Stock: 10})
+	ifMatch := productRepository.get("p1").ETag()
+	productRepository.beforeUpdate = func(*model.Product) {
+		productRepository.beforeUpdate = nil
+		productRepository.touch("p1", func(p *model.Product) { p.Stock -= 2 })
+	}
+
+	_, err := newTestProductService(productRepository).AdjustProductStock(context.Background(), "p1", &product.AdjustProductStockRequest{Quantity: -3}, ifMatch, author)
+	assertDomainError(t, err, exception.KindPrecondition, exception.CodePreconditionFailed)
+	if stored := productRepository.get("p1"); stored.Stock != 8 {
+		t.Errorf("stored stock = %d, want 8", stored.Stock)
+	}
+}
+
+func TestAdjustProductStockGivesUpAfterMaxWriteAttempts(t *testing.T) {
+	productRepository := newMemoryProductRepository(&model.Product{Id: "p1", AuthorId: "author-1", Name: "Mug", Stock: 10})
+	attempts := 0
+	productRepository.beforeUpdate = func(*model.Product) {
+		attempts++
+		productRepository.touch("p1", func(p *model.Product) { p.Stock-- })
+	}
+
+	_, err := newTestProductService(productRepository).AdjustProductStock(context.Background(), "p1", &product.AdjustProductStockRequest{Quantity: 5}, "", author)
+	assertDomainError(t, err, exception.KindConflict, exception.CodeConcurrentModification)
+	if attempts != maxWriteAttempts {
+		t.Errorf("attempts = %d, want %d", attempts, maxWriteAttempts)
+	}
+}
+
+func TestWritesCheckIfMatchBeforeWriting(t *testing.T) {
+	productRepository := newMemoryProductRepository(&model.Product{Id: "p1", AuthorId: "author-1", Name: "Mug", Price: 10, Stock: 10})
+	current := productRepository.get("p1").ETag()
+	service := newTestProductService(productRepository)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		wantErr bool
+	}{
+		{name: "no If-Match", ifMatch: ""},
+		{name: "current ETag", ifMatch: current},
+		{name: "any version", ifMatch: "*"},
+		{name: "stale ETag", ifMatch: `"stale"`, wantErr: true},
+		{name: "weak ETag", ifMatch: "W/" + current, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ifMatch := test.ifMatch
+			if ifMatch == current {
+				// Cada caso correcto escribe una versión nueva
+				ifMatch = productRepository.get("p1").ETag()
+			}
+			_, err := service.PatchProduct(ctx, "p1", []byte(`{"stock":3}`), product.MergePatch, ifMatch, author)
+			if test.wantErr {
+				assertDomainError(t, err, exception.KindPrecondition, exception.CodePreconditionFailed)
+				return
+			}
+			if err != nil {
+				t.Fatalf("PatchProduct() error = %v", err)
+			}
+		})
+	}
+}
+
+// seller es un autor sin el permiso price.manage
+var seller = authorWithout(model.PriceManage)
+
+// authorWithout devuelve el autor de los productos de las pruebas sin los permisos indicados
+func authorWithout(permissions ...model.Permission) *auth.Principal {
+	return &auth.Principal{Subject: author.Subject, Permissions: slices.DeleteFunc(slices.Clone(model.Permissions), func(p model.Permission) bool {
+		return slices.Contains(permissions, p)
+	})}
+}
+
+func TestPatchProduct(t *testing.T) {
+	tests := []struct {
+		name      string
+		patchType product.PatchType
+		patch     string
+		principal *auth.Principal
+		wantKind  exception.Kind
+		wantCode  string
+		want      func(p *model.Product) bool
+	}{
+		{
+			name:      "merge patch keeps an explicit zero discount",
+			patchType: product.MergePatch,
+			patch:     `{"discount":0}`,
+			want:      func(p *model.Product) bool { return p.Discount == 0 && p.Price == 20 && p.Stock == 10 },
+		},
+		{
+			name:      "merge patch null removes an optional field",
+			patchType: product.MergePatch,
+			patch:     `{"description":null,"unpublishAt":null}`,
+			want: func(p *model.Product) bool {
+				return p.Description == "" && p.UnpublishAt == "" && p.Name == "Mug" && p.PublishAt == "2030-01-01T00:00:00Z"
+			},
+		},
+		{
+			name:      "merge patch normalizes the schedule",
+			patchType: product.MergePatch,
+			patch:     `{"publishAt":"2030-01-01T02:00:00+02:00"}`,
+			want:      func(p *model.Product) bool { return p.PublishAt == "2030-01-01T00:00:00Z" },
+		},
+		{
+			name:      "JSON patch replace",
+			patchType: product.JSONPatch,
+			patch:     `[{"op":"replace","path":"/name","value":"Cup"},{"op":"replace","path":"/stock","value":0}]`,
+			want:      func(p *model.Product) bool { return p.Name == "Cup" && p.Stock == 0 && p.Description == "Blue mug" },
+		},
+		{
+			name:      "JSON patch remove",
+			patchType: product.JSONPatch,
+			patch:     `[{"op":"remove","path":"/description"}]`,
+			want:      func(p *model.Product) bool { return p.Description == "" && p.Name == "Mug" },
+		},
+		{
+			name:      "invalid merge patch",
+			patchType: product.MergePatch,
+			patch:     `{"name":`,
+			wantKind:  exception.KindValidation,
+			wantCode:  exception.CodeInvalidPatch,
+		},
+		{
+			name:      "invalid JSON patch",
+			patchType: product.JSONPatch,
+			patch:     `{"op":"replace"}`,
+			wantKind:  exception.KindValidation,
+			wantCode:  exception.CodeInvalidPatch,
+		},
+		{
+			name:      "failed JSON patch test",
+			patchType: product.JSONPatch,
+			patch:     `[{"op":"test","path":"/stock","value":3},{"op":"replace","path":"/stock","value":0}]`,
+			wantKind:  exception.KindValidation,
+			wantCode:  exception.CodeInvalidPatch,
+		},
+		{
+			name:      "read-only field",
+			patchType: product.MergePatch,
+			patch:     `{"authorId":"author-2"}`,
+			wantKind:  exception.KindValidation,
+			wantCode:  exception.CodeInvalidPatch,
+		},
+		{
+			name:      "wrong type",
+			patchType: product.MergePatch,
+			patch:     `{"price":"ten"}`,
+			wantKind:  exception.KindValidation,
+			wantCode:  exception.CodeInvalidPatch,
+		},
+		{
+			name:      "invalid value",
+			patchType: product.MergePatch,
+			patch:     `{"stock":-1}`,
+			wantKind:  exception.KindValidation,
+			wantCode:  exception.CodeValidationFailed,
+		},
+		{
+			name:      "null required field",
+			patchType: product.MergePatch,
+			patch:     `{"name":null}`,
+			wantKind:  exception.KindValidation,
+			wantCode:  exception.CodeValidationFailed,
+		},
+		{
+			name:      "without price.manage when the price is untouched",
+			patchType: product.MergePatch,
+			patch:     `{"name":"Cup","price":20,"discount":5}`,
+			principal: seller,
+			want:      func(p *model.Product) bool { return p.Name == "Cup" && p.Price == 20 && p.Discount == 5 },
+		},
+		{
+			name:      "without price.manage when the price changes",
+			patchType: product.MergePatch,
+			patch:     `{"name":"Cup","discount":0}`,
+			principal: seller,
+			wantKind:  exception.KindForbidden,
+			wantCode:  exception.CodeForbidden,
+		},
+		{
+			name:      "without price.manage when the currency changes",
+			patchType: product.JSONPatch,
+			patch:     `[{"op":"replace","path":"/currency","value":"EUR"}]`,
+			principal: seller,
+			wantKind:  exception.KindForbidden,
+			wantCode:  exception.CodeForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			productRepository := newMemoryProductRepository(&model.Product{
+				Id: "p1", AuthorId: "author-1", Name: "Mug", Description: "Blue mug", Price: 20, Currency: "USD", Discount: 5, Stock: 10,
+				PublishAt: "2030-01-01T00:00:00Z", UnpublishAt: "2030-02-01T00:00:00Z",
+			})
+			original := productRepository.get("p1")
+			principal := test.principal
+			if principal == nil {
+				principal = author
+			}
+
+			response, err := newTestProductService(productRepository).PatchProduct(context.Background(), "p1", []byte(test.patch), test.patchType, "", principal)
+			stored := productRepository.get("p1")
+			if test.wantCode != "" {
+				assertDomainError(t, err, test.wantKind, test.wantCode)
+				if stored.ETag() != original.ETag() {
+					t.Error("the product was written")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PatchProduct() error = %v", err)
+			}
+			if !test.want(stored) {
+				t.Errorf("stored product = %+v", stored)
+			}
+			if stored.AuthorId != original.AuthorId || stored.Id != original.Id {
+				t.Errorf("read-only fields changed: %+v", stored)
+			}
+			if response.ETag != stored.ETag() {
+				t.Errorf("ETag = %s, want %s", response.ETag, stored.ETag())
+			}
+		})
+	}
+}
+
+func TestProcessScheduledStatusChanges(t *testing.T) {
+	const past, earlier, future = "2030-01-09T00:00:00Z", "2030-01-08T00:00:00Z", "2030-01-11T00:00:00Z"
+	now := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		product         model.Product
+		wantStatus      model.ProductStatus
+		wantPublishAt   string
+		wantUnpublishAt string
+		wantChanged     int
+	}{
+		{
+			name:        "publishAt passed publishes a draft",
+			product:     model.Product{Status: model.ProductStatusDraft, PublishAt: past},
+			wantStatus:  model.ProductStatusPublished,
+			wantChanged: 1,
+		},
+		{
+			name:        "publishAt passed publishes an archived product",
+			product:     model.Product{Status: model.ProductStatusArchived, PublishAt: past},
+			wantStatus:  model.ProductStatusPublished,
+			wantChanged: 1,
+		},
+		{
+			name:        "publishAt passed on a published product only clears the schedule",
+			product:     model.Product{Status: model.ProductStatusPublished, PublishAt: past},
+			wantStatus:  model.ProductStatusPublished,
+			wantChanged: 1,
+		},
+		{
+			name:          "publishAt in the future",
+			product:       model.Product{Status: model.ProductStatusDraft, PublishAt: future},
+			wantStatus:    model.ProductStatusDraft,
+			wantPublishAt: future,
+		},
+		{
+			name:        "unpublishAt passed archives a published product",
+			product:     model.Product{Status: model.ProductStatusPublished, UnpublishAt: past},
+			wantStatus:  model.ProductStatusArchived,
+			wantChanged: 1,
+		},
+		{
+			name:        "unpublishAt passed on a product without status archives it",
+			product:     model.Product{UnpublishAt: past},
+			wantStatus:  model.ProductStatusArchived,
+			wantChanged: 1,
+		},
+		{
+			name:        "unpublishAt passed on a draft only clears the schedule",
+			product:     model.Product{Status: model.ProductStatusDraft, UnpublishAt: past},
+			wantStatus:  model.ProductStatusDraft,
+			wantChanged: 1,
+		},
+		{
+			name:            "unpublishAt passed before a future publishAt waits for the publication",
+			product:         model.Product{Status: model.ProductStatusDraft, PublishAt: future, UnpublishAt: past},
+			wantStatus:      model.ProductStatusDraft,
+			wantPublishAt:   future,
+			wantUnpublishAt: past,
+		},
+		{
+			name:        "publishAt and unpublishAt passed publish and then archive",
+			product:     model.Product{Status: model.ProductStatusDraft, PublishAt: earlier, UnpublishAt: past},
+			wantStatus:  model.ProductStatusArchived,
+			wantChanged: 2,
+		},
+		{
+			name:          "products in the trash are ignored",
+			product:       model.Product{Status: model.ProductStatusDraft, PublishAt: past, DeletedAt: earlier},
+			wantStatus:    model.ProductStatusDraft,
+			wantPublishAt: past,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := test.product
+			p.Id, p.AuthorId, p.Name = "p1", "author-1", "Mug"
+			productRepository := newMemoryProductRepository(&p)
+
+			changed, err := newTestProductService(productRepository).ProcessScheduledStatusChanges(context.Background(), now)
+			if err != nil {
+				t.Fatalf("ProcessScheduledStatusChanges() error = %v", err)
+			}
+			if changed != test.wantChanged {
+				t.Errorf("changed = %d, want %d", changed, test.wantChanged)
+			}
+			stored := productRepository.get("p1")
+			if stored.EffectiveStatus() != test.wantStatus || stored.PublishAt != test.wantPublishAt || stored.UnpublishAt != test.wantUnpublishAt {
+				t.Errorf("stored = %s, publishAt %q, unpublishAt %q, want %s, %q, %q",
+					stored.EffectiveStatus(), stored.PublishAt, stored.UnpublishAt, test.wantStatus, test.wantPublishAt, test.wantUnpublishAt)
+			}
+		})
+	}
+}
+
+func TestProcessScheduledStatusChangesSkipsConcurrentWrites(t *testing.T) {
+	productRepository := newMemoryProductRepository(&model.Product{Id: "p1", AuthorId: "author-1", Name: "Mug", Status: model.ProductStatusDraft, PublishAt: "2030-01-09T00:00:00Z"})
+	productRepository.beforeUpdate = func(*model.Product) {
+		productRepository.beforeUpdate = nil
+		productRepository.touch("p1", func(p *model.Product) { p.Name = "Cup" })
+	}
+
+	changed, err := newTestProductService(productRepository).ProcessScheduledStatusChanges(context.Background(), time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil || changed != 0 {
+		t.Fatalf("ProcessScheduledStatusChanges() = %d, %v, want 0 and no error", changed, err)
+	}
+	// La siguiente ejecución publica el producto con los cambios de la otra escritura
+	if stored := productRepository.get("p1"); stored.Status != model.ProductStatusDraft || stored.Name != "Cup" {
+		t.Errorf("stored = %+v", stored)
+	}
+}
+
+// publicCatalog son los productos de las pruebas del catálogo público
+func publicCatalog() []*model.Product {
+	published := func(id string, name string, price float64) *model.Product {
+		return &model.Product{Id: id, AuthorId: "author-1", Name: name, Price: price, Stock: 3, CategoryId: "mugs", Status: model.ProductStatusPublished}
+	}
+	cap := published("p5", "Blue cap", 15)
+	cap.CategoryId = "caps"
+	deleted := published("p8", "Old mug", 5)
+	deleted.DeletedAt = "2024-01-01T00:00:00Z"
+	return []*model.Product{
+		published("p1", "Red mug", 10),
+		{Id: "p2", AuthorId: "author-1", Name: "Draft mug", Status: model.ProductStatusDraft},
+		published("p3", "Green plate", 20),
+		published("p4", "Blue mug", 30),
+		cap,
+		// Sin estado: no aparece en la búsqueda hasta que backfill-status lo guarda como published
+		{Id: "p6", AuthorId: "author-1", Name: "Legacy mug"},
+		{Id: "p7", AuthorId: "author-1", Name: "Archived mug", Status: model.ProductStatusArchived},
+		deleted,
+	}
+}
+
+func TestSearchPublicProducts(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     product.SearchPublicProductsRequest
+		wantPages   [][]string
+		wantQueries int
+	}{
+		{
+			name:    "pages through the published products",
+			request: product.SearchPublicProductsRequest{Size: 2},
+			// La última lectura solo encuentra el producto de la papelera
+			wantPages:   [][]string{{"p1", "p3"}, {"p4", "p5"}, {}},
+			wantQueries: 3,
+		},
+		{
+			name:    "continues after the last returned product when a read fills the page",
+			request: product.SearchPublicProductsRequest{Size: 2, PriceMin: 15},
+			// La primera lectura (p1, p3) no llena la página; la segunda (p4, p5) la llena con p4
+			wantPages:   [][]string{{"p3", "p4"}, {"p5"}},
+			wantQueries: 4,
+		},
+		{
+			name:        "filters by category in the query",
+			request:     product.SearchPublicProductsRequest{Size: 10, CategoryId: "caps"},
+			wantPages:   [][]string{{"p5"}},
+			wantQueries: 1,
+		},
+		{
+			name:        "filters by text and price",
+			request:     product.SearchPublicProductsRequest{Size: 10, Query: "MUG", PriceMax: 25},
+			wantPages:   [][]string{{"p1"}},
+			wantQueries: 1,
+		},
+		{
+			name:    "returns an incomplete page after the maximum number of reads",
+			request: product.SearchPublicProductsRequest{Size: 1, Query: "plates"},
+			// Cinco lecturas de un documento (p1, p3, p4, p5 y p8) sin coincidencias
+			wantPages:   [][]string{{}, {}},
+			wantQueries: maxPublicSearchReads + 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			productRepository := newMemoryProductRepository(publicCatalog()...)
+			service := newTestProductService(productRepository)
+
+			var pages [][]string
+			request := test.request
+			for {
+				response, err := service.SearchPublicProducts(context.Background(), &request)
+				if err != nil {
+					t.Fatalf("SearchPublicProducts() error = %v", err)
+				}
+				ids := []string{}
+				for _, p := range response.Data {
+					ids = append(ids, p.Id)
+				}
+				pages = append(pages, ids)
+				if response.NextCursor == "" || len(pages) > len(test.wantPages) {
+					break
+				}
+				request.Cursor = response.NextCursor
+			}
+			if !slices.EqualFunc(pages, test.wantPages, slices.Equal[[]string]) {
+				t.Errorf("pages = %v, want %v", pages, test.wantPages)
+			}
+			if productRepository.publishedQueries != test.wantQueries {
+				t.Errorf("queries = %d, want %d", productRepository.publishedQueries, test.wantQueries)
+			}
+		})
+	}
+}
+
+func TestSearchPublicProductsRejectsInvalidCursor(t *testing.T) {
+	service := newTestProductService(newMemoryProductRepository(publicCatalog()...))
+	_, err := service.SearchPublicProducts(context.Background(), &product.SearchPublicProductsRequest{Size: 10, Cursor: "products/p1"})
+	assertDomainError(t, err, exception.KindValidation, exception.CodeValidationFailed)
+}
+
+func TestPublicProductsHideInternalFields(t *testing.T) {
+	outOfStock := &model.Product{Id: "p9", AuthorId: "author-1", Name: "Sold out mug", Stock: 0, Status: model.ProductStatusPublished, CreatedAt: "2030-01-01T00:00:00Z"}
+	service := newTestProductService(newMemoryProductRepository(append(publicCatalog(), outOfStock)...))
+
+	searchResponse, err := service.SearchPublicProducts(context.Background(), &product.SearchPublicProductsRequest{Size: 10})
+	if err != nil {
+		t.Fatalf("SearchPublicProducts() error = %v", err)
+	}
+	byIdResponse, err := service.GetPublicProductById(context.Background(), "p9")
+	if err != nil {
+		t.Fatalf("GetPublicProductById() error = %v", err)
+	}
+
+	for _, response := range append(searchResponse.Data, byIdResponse) {
+		var fields map[string]any
+		body, _ := json.Marshal(response)
+		if err := json.Unmarshal(body, &fields); err != nil {
+			t.Fatal(err)
+		}
+		for _, hidden := range []string{"authorId", "authorName", "stock", "status", "createdAt", "updatedAt", "publishAt", "unpublishAt", "deletedAt"} {
+			if _, ok := fields[hidden]; ok {
+				t.Errorf("%s: public response includes %q: %s", response.Id, hidden, body)
+			}
+		}
+		if wantInStock := response.Id != "p9"; fields["inStock"] != wantInStock {
+			t.Errorf("%s: inStock = %v, want %v", response.Id, fields["inStock"], wantInStock)
+		}
+	}
+}
+
+func TestGetPublicProductByIdHidesUnpublishedProducts(t *testing.T) {
+	service := newTestProductService(newMemoryProductRepository(publicCatalog()...))
+	for _, id := range []string{"p2", "p7", "p8", "missing"} {
+		_, err := service.GetPublicProductById(context.Background(), id)
+		assertDomainError(t, err, exception.KindNotFound, exception.CodeProductNotFound)
+	}
+	// Los productos sin estado se consideran publicados
+	if _, err := service.GetPublicProductById(context.Background(), "p6"); err != nil {
+		t.Errorf("GetPublicProductById(p6) error = %v", err)
+	}
+}
+
+func TestBackfillStatus(t *testing.T) {
+	legacyDeleted := &model.Product{Id: "p10", AuthorId: "author-1", Name: "Legacy cup", DeletedAt: "2024-01-01T00:00:00Z"}
+	productRepository := newMemoryProductRepository(append(publicCatalog(), legacyDeleted)...)
+	service := newTestProductService(productRepository)
+
+	report, err := service.BackfillStatus(context.Background())
+	if err != nil {
+		t.Fatalf("BackfillStatus() error = %v", err)
+	}
+	if report.Products != 9 || report.Updated != 2 || report.Failed != 0 {
+		t.Errorf("report = %+v, want 9 products and 2 updated", report)
+	}
+	for id, want := range map[string]model.ProductStatus{"p6": model.ProductStatusPublished, "p10": model.ProductStatusPublished, "p2": model.ProductStatusDraft, "p7": model.ProductStatusArchived} {
+		if got := productRepository.get(id).Status; got != want {
+			t.Errorf("%s status = %q, want %q", id, got, want)
+		}
+	}
+
+	response, err := service.SearchPublicProducts(context.Background(), &product.SearchPublicProductsRequest{Size: 10, Query: "legacy"})
+	if err != nil || len(response.Data) != 1 || response.Data[0].Id != "p6" {
+		t.Errorf("SearchPublicProducts() = %+v, %v, want p6", response, err)
+	}
+}
@@ -0,0 +1,156 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"github.com/ruiborda/ecommerce-product-service/src/imaging"
+	"github.com/ruiborda/ecommerce-product-service/src/model"
+	"github.com/ruiborda/ecommerce-product-service/src/repository"
+	"github.com/ruiborda/ecommerce-product-service/src/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultImageRenditions son las variantes que se generan si no se configura PRODUCT_IMAGE_RENDITIONS
+const defaultImageRenditions = "thumbnail=160,medium=640,large=1280"
+
+// renditionGenerator genera y sube las variantes en WebP de las imágenes de la galería
+type renditionGenerator struct {
+	storageRepository repository.StorageRepository
+	renditions        []imaging.Rendition
+	maxPixels         int
+	maxFileSize       int64
+}
+
+func newRenditionGenerator(storageRepository repository.StorageRepository) *renditionGenerator {
+	spec := config.GetEnv("PRODUCT_IMAGE_RENDITIONS", defaultImageRenditions)
+	renditions, err := imaging.ParseRenditions(spec)
+	if err != nil {
+		slog.Warn("Invalid PRODUCT_IMAGE_RENDITIONS, using default", "value", spec, "error", err)
+		renditions, _ = imaging.ParseRenditions(defaultImageRenditions)
+	}
+	return &renditionGenerator{
+		storageRepository: storageRepository,
+		renditions:        renditions,
+		maxPixels:         config.GetEnvInt("PRODUCT_IMAGE_MAX_PIXELS", 40_000_000),
+		maxFileSize:       int64(config.GetEnvInt("PRODUCT_IMAGE_MAX_SIZE", 10<<20)),
+	}
+}
+
+// apply genera las variantes de la imagen ya subida y las asigna. Si no se pueden generar la
+// imagen se conserva sin variantes: se pueden completar después con el backfill.
+func (g *renditionGenerator) apply(ctx context.Context, image *model.ProductImage, data []byte) {
+	renditions, err := g.generate(ctx, image.FileName, data)
+	if err != nil {
+		logRenditionError(ctx, image.FileName, err)
+		return
+	}
+	image.Renditions = renditions
+}
+
+// applyFromStorage es como apply pero lee la imagen del almacenamiento, para las subidas que no
+// pasan enteras por la memoria del servicio
+func (g *renditionGenerator) applyFromStorage(ctx context.Context, image *model.ProductImage) {
+	renditions, err := g.generateFromStorage(ctx, image.FileName)
+	if err != nil {
+		logRenditionError(ctx, image.FileName, err)
+		return
+	}
+	image.Renditions = renditions
+}
+
+// generate genera las variantes configuradas de la imagen y las sube con claves predecibles
+// derivadas de fileName. Si falla alguna subida, las ya subidas se conservan: los productos que
+// comparten el archivo pueden usarlas y se eliminan junto con el original.
+func (g *renditionGenerator) generate(ctx context.Context, fileName string, data []byte) (map[string]model.ImageRendition, error) {
+	if len(g.renditions) == 0 {
+		return nil, nil
+	}
+	ctx, span := tracing.StartSpan(ctx, "RenditionGenerator.Generate", attribute.String("file.name", fileName))
+	defer span.End()
+
+	generated, err := imaging.GenerateRenditions(data, g.renditions, g.maxPixels)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	renditions := make(map[string]model.ImageRendition, len(generated))
+	for _, rendition := range generated {
+		key := imaging.RenditionKey(fileName, rendition.Name)
+		if err := g.storageRepository.PutFile(ctx, key, rendition.Data, imaging.RenditionContentType); err != nil {
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+		renditions[rendition.Name] = model.ImageRendition{FileName: key, Width: rendition.Width, Height: rendition.Height}
+	}
+	return renditions, nil
+}
+
+// generateFromStorage descarga la imagen original y genera sus variantes
+func (g *renditionGenerator) generateFromStorage(ctx context.Context, fileName string) (map[string]model.ImageRendition, error) {
+	file, err := g.storageRepository.OpenFile(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(file, g.maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > g.maxFileSize {
+		return nil, imaging.ErrImageTooLarge
+	}
+	return g.generate(ctx, fileName, data)
+}
+
+// missing indica si a la imagen le falta alguna de las variantes configuradas
+func (g *renditionGenerator) missing(image model.ProductImage) bool {
+	for _, rendition := range g.renditions {
+		if _, ok := image.Renditions[rendition.Name]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keys devuelve las claves de las variantes configuradas de la imagen fileName
+func (g *renditionGenerator) keys(fileName string) []string {
+	keys := make([]string, 0, len(g.renditions))
+	for _, rendition := range g.renditions {
+		keys = append(keys, imaging.RenditionKey(fileName, rendition.Name))
+	}
+	return keys
+}
+
+// delete elimina los archivos de las variantes, por ejemplo si no se llegaron a guardar en el producto
+func (g *renditionGenerator) delete(ctx context.Context, renditions map[string]model.ImageRendition) {
+	for _, rendition := range renditions {
+		if err := g.storageRepository.DeleteFile(context.WithoutCancel(ctx), rendition.FileName); err != nil {
+			slog.ErrorContext(ctx, "Error deleting image rendition", "fileName", rendition.FileName, "error", err)
+		}
+	}
+}
+
+// logRenditionError registra por qué no se generaron las variantes; los formatos que no se pueden
+// decodificar (AVIF) y las imágenes demasiado grandes no son errores del servicio
+func logRenditionError(ctx context.Context, fileName string, err error) {
+	if errors.Is(err, imaging.ErrUnsupportedImage) || errors.Is(err, imaging.ErrImageTooLarge) {
+		slog.InfoContext(ctx, "Image renditions skipped", "fileName", fileName, "reason", err.Error())
+		return
+	}
+	slog.ErrorContext(ctx, "Error generating image renditions", "fileName", fileName, "error", err)
+}
+
+// deleteImageFiles elimina archivos que ningún producto referencia, como los temporales o las variantes que ya no se usan
+func deleteImageFiles(ctx context.Context, storageRepository repository.StorageRepository, files []string) {
+	for _, fileName := range files {
+		if err := storageRepository.DeleteFile(context.WithoutCancel(ctx), fileName); err != nil {
+			slog.ErrorContext(ctx, "Error deleting product image", "fileName", fileName, "error", err)
+		}
+	}
+}
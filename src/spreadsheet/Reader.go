@@ -0,0 +1,95 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format es el formato de una hoja de cálculo
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ErrUnsupportedFormat indica que el formato de hoja de cálculo no está soportado
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+
+// utf8BOM es la marca con la que Excel empieza los CSV exportados en UTF-8
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// DetectFormat obtiene el formato a partir de la extensión del archivo o, si no la tiene,
+// del contenido (los XLSX son archivos ZIP)
+func DetectFormat(fileName string, content []byte) (Format, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	case "":
+		if bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+			return FormatXLSX, nil
+		}
+		return FormatCSV, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Read devuelve las filas de la primera hoja del archivo, incluida la cabecera.
+// Las filas pueden tener distinto número de columnas.
+func Read(format Format, content []byte) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(content)
+	case FormatXLSX:
+		return readXLSX(content)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// readCSV lee un CSV separado por comas o por punto y coma (habitual en hojas de cálculo
+// configuradas en español); el separador se deduce de la cabecera
+func readCSV(content []byte) ([][]string, error) {
+	content = bytes.TrimPrefix(content, utf8BOM)
+
+	header, _, _ := bytes.Cut(content, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(content))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return rows, nil
+}
+
+func readXLSX(content []byte) ([][]string, error) {
+	file, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("invalid XLSX: the workbook has no sheets")
+	}
+	rows, err := file.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	return rows, nil
+}
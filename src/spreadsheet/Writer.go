@@ -0,0 +1,249 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// FormatJSONL es el formato JSON Lines: un objeto JSON por línea. Solo se usa para exportar.
+const FormatJSONL Format = "jsonl"
+
+// Writer escribe filas en una hoja de cálculo a medida que se generan, sin acumularlas en memoria
+type Writer interface {
+	// Write escribe una fila con un valor por columna de la cabecera. Los números se
+	// conservan como números en JSON Lines y XLSX.
+	Write(values []any) error
+	// Flush envía al destino las filas pendientes (en XLSX no hace nada: el libro se escribe al cerrar)
+	Flush() error
+	// Close termina el archivo; no cierra el io.Writer de destino
+	Close() error
+	// Discard libera los recursos sin terminar el archivo, para que un error a mitad de la
+	// escritura no produzca un archivo que parezca completo
+	Discard()
+}
+
+// flusher es implementado por los destinos que pueden enviar lo escrito al cliente, como http.ResponseWriter
+type flusher interface {
+	Flush()
+}
+
+// ParseFormat obtiene el formato de exportación a partir de su nombre
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatCSV, FormatJSONL, FormatXLSX:
+		return format, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType devuelve el tipo MIME del formato
+func ContentType(format Format) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/jsonl; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// NewWriter crea un Writer del formato indicado y escribe la cabecera
+func NewWriter(format Format, w io.Writer, header []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, header)
+	case FormatJSONL:
+		return newJSONLWriter(w, header), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, header)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// flushDestination envía lo escrito al cliente si el destino lo permite
+func flushDestination(w io.Writer) {
+	if f, ok := w.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// csvWriter escribe un CSV separado por comas con la marca BOM para que Excel detecte UTF-8.
+// Nada llega al destino hasta el primer Flush.
+type csvWriter struct {
+	destination io.Writer
+	buffer      *bufio.Writer
+	writer      *csv.Writer
+	record      []string
+}
+
+func newCSVWriter(w io.Writer, header []string) (*csvWriter, error) {
+	buffer := bufio.NewWriter(w)
+	_, _ = buffer.Write(utf8BOM)
+	cw := &csvWriter{destination: w, buffer: buffer, writer: csv.NewWriter(buffer), record: make([]string, len(header))}
+	if err := cw.writer.Write(header); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(values []any) error {
+	for i, value := range values {
+		cw.record[i] = formatCell(value)
+	}
+	return cw.writer.Write(cw.record[:len(values)])
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.writer.Flush()
+	if err := cw.writer.Error(); err != nil {
+		return err
+	}
+	if err := cw.buffer.Flush(); err != nil {
+		return err
+	}
+	flushDestination(cw.destination)
+	return nil
+}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
+
+func (cw *csvWriter) Discard() {}
+
+// formatCell convierte un valor en el texto de una celda CSV
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonlWriter escribe cada fila como un objeto con las columnas de la cabecera en orden.
+// Nada llega al destino hasta el primer Flush.
+type jsonlWriter struct {
+	destination io.Writer
+	writer      *bufio.Writer
+	keys        [][]byte
+}
+
+func newJSONLWriter(w io.Writer, header []string) *jsonlWriter {
+	keys := make([][]byte, len(header))
+	for i, column := range header {
+		keys[i], _ = json.Marshal(column)
+	}
+	return &jsonlWriter{destination: w, writer: bufio.NewWriter(w), keys: keys}
+}
+
+func (jw *jsonlWriter) Write(values []any) error {
+	_ = jw.writer.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			_ = jw.writer.WriteByte(',')
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, _ = jw.writer.Write(jw.keys[i])
+		_ = jw.writer.WriteByte(':')
+		_, _ = jw.writer.Write(encoded)
+	}
+	_, err := jw.writer.WriteString("}\n")
+	return err
+}
+
+func (jw *jsonlWriter) Flush() error {
+	if err := jw.writer.Flush(); err != nil {
+		return err
+	}
+	flushDestination(jw.destination)
+	return nil
+}
+
+func (jw *jsonlWriter) Close() error {
+	return jw.Flush()
+}
+
+func (jw *jsonlWriter) Discard() {}
+
+// xlsxWriter usa el StreamWriter de excelize, que pasa las filas a un archivo temporal cuando
+// superan su búfer; el libro completo se escribe en el destino al cerrar
+type xlsxWriter struct {
+	destination io.Writer
+	file        *excelize.File
+	stream      *excelize.StreamWriter
+	row         int
+}
+
+func newXLSXWriter(w io.Writer, header []string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	xw := &xlsxWriter{destination: w, file: file, stream: stream}
+
+	headerValues := make([]any, len(header))
+	for i, column := range header {
+		headerValues[i] = column
+	}
+	if err := xw.Write(headerValues); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return xw, nil
+}
+
+func (xw *xlsxWriter) Write(values []any) error {
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.stream.SetRow(cell, values)
+}
+
+func (xw *xlsxWriter) Flush() error {
+	return nil
+}
+
+func (xw *xlsxWriter) Close() error {
+	// Close elimina los archivos temporales del StreamWriter
+	defer func() { _ = xw.file.Close() }()
+
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	if err := xw.file.Write(xw.destination); err != nil {
+		return err
+	}
+	flushDestination(xw.destination)
+	return nil
+}
+
+func (xw *xlsxWriter) Discard() {
+	_ = xw.file.Close()
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/ruiborda/ecommerce-product-service/src/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/ruiborda/ecommerce-product-service"
+
+// ServiceName devuelve el nombre del servicio que se reporta en las trazas
+func ServiceName() string {
+	return config.GetEnv("OTEL_SERVICE_NAME", "ecommerce-product-service")
+}
+
+// Init configura el TracerProvider global según OTEL_TRACES_EXPORTER (otlp, stdout o none)
+// y devuelve la función que vacía y cierra el exportador.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	// Propagación W3C trace-context y baggage desde las cabeceras entrantes
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	exporterName := config.GetEnv("OTEL_TRACES_EXPORTER", "none")
+	switch exporterName {
+	case "otlp":
+		// El endpoint se configura con OTEL_EXPORTER_OTLP_ENDPOINT / OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "none", "":
+		slog.Info("Tracing exporter disabled")
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q", exporterName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName()),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.GetEnvFloat("OTEL_TRACES_SAMPLE_RATIO", 1)))),
+	)
+	otel.SetTracerProvider(provider)
+
+	slog.Info("Tracing exporter enabled", "exporter", exporterName)
+	return provider.Shutdown, nil
+}
+
+// StartSpan inicia un span hijo del span presente en el contexto
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartFirestoreSpan inicia un span de cliente para una operación de Firestore
+func StartFirestoreSpan(ctx context.Context, name string, collection string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String("firestore"),
+			semconv.DBCollectionName(collection),
+		),
+	)
+}
+
+// RecordError marca el span como fallido con el error indicado
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}